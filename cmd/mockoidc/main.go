@@ -0,0 +1,28 @@
+// Command mockoidc runs a throwaway OpenID Connect issuer for trying out single sign-on
+// locally and for the e2e tests. Any username signs in, don't expose it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"watchma/pkg/oidc"
+)
+
+func main() {
+	port := flag.Int("port", 58009, "port to listen on")
+	clientID := flag.String("client-id", "watchma", "client ID watchma is configured with")
+	clientSecret := flag.String("client-secret", "watchma-secret", "client secret watchma is configured with")
+	flag.Parse()
+
+	issuerURL := fmt.Sprintf("http://localhost:%d", *port)
+	issuer, err := oidc.NewMockIssuer(issuerURL, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatal("Failed to create mock issuer:", err)
+	}
+
+	log.Printf("Mock OIDC issuer at %s", issuerURL)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), issuer))
+}
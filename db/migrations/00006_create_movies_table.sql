@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS movies (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    community_rating REAL NOT NULL DEFAULT 0,
+    critic_rating INTEGER NOT NULL DEFAULT 0,
+    genres TEXT NOT NULL DEFAULT '[]', -- JSON array of genre names
+    official_rating TEXT NOT NULL DEFAULT '',
+    premiere_date TEXT NOT NULL DEFAULT '',
+    primary_image_tag TEXT NOT NULL DEFAULT '',
+    production_year INTEGER NOT NULL DEFAULT 0,
+    last_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_movies_name ON movies (name);
+CREATE INDEX idx_movies_last_seen_at ON movies (last_seen_at);
+
+-- One row per provider, remembers when the last successful sync started
+CREATE TABLE IF NOT EXISTS library_syncs (
+    provider TEXT PRIMARY KEY,
+    last_synced_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS library_syncs;
+DROP INDEX IF EXISTS idx_movies_last_seen_at;
+DROP INDEX IF EXISTS idx_movies_name;
+DROP TABLE IF EXISTS movies;
+-- +goose StatementEnd
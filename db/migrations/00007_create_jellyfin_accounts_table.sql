@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Links a local user to a Jellyfin user. The access token is the one Jellyfin issued
+-- when that user signed in, so library requests made with it respect their parental
+-- controls and library access.
+CREATE TABLE IF NOT EXISTS jellyfin_accounts (
+    user_id INTEGER PRIMARY KEY,
+    jellyfin_user_id TEXT NOT NULL UNIQUE,
+    access_token TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS jellyfin_accounts;
+-- +goose StatementEnd
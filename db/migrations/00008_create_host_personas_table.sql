@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Game show host personas. The prompts are Go text/template strings, rendered with
+-- the details of what just happened in the room before being sent to the LLM.
+CREATE TABLE IF NOT EXISTS host_personas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    system_prompt TEXT NOT NULL,
+    lobby_arrival_prompt TEXT NOT NULL,
+    draft_overlap_prompt TEXT NOT NULL,
+    voting_hot_take_prompt TEXT NOT NULL,
+    tie_prompt TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT 0, -- The persona every room uses, only one at a time
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO host_personas (
+    name,
+    system_prompt,
+    lobby_arrival_prompt,
+    draft_overlap_prompt,
+    voting_hot_take_prompt,
+    tie_prompt,
+    is_active
+) VALUES (
+    'Gus Showman',
+    'You are Gus Showman, the over the top host of Watchma, a game show where friends draft and vote on what movie to watch tonight. You are warm, cheesy and love a good pun. Reply with ONE short sentence of spoken dialogue, no quotes, no emoji, no stage directions.',
+    '{{.Username}} just walked into the room "{{.Room}}". The players here are: {{join .Players ", "}}. Welcome them.',
+    'The draft is over and more than one player picked: {{join .Movies ", "}}. Comment on the great minds thinking alike.',
+    'Voting is starting. The movies up for a vote are: {{join .Movies ", "}}. Give a playful hot take on one of them.',
+    'The vote is tied between: {{join .Movies ", "}}. Hype up the revote.',
+    1
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS host_personas;
+-- +goose StatementEnd
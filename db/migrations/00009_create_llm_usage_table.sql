@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per LLM call, used for the usage report and the monthly budget
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_name TEXT NOT NULL DEFAULT '', -- Empty for calls made outside a room
+    purpose TEXT NOT NULL,              -- What the call was for, e.g. announcement
+    backend TEXT NOT NULL,
+    model TEXT NOT NULL,
+    prompt_tokens INTEGER NOT NULL,
+    completion_tokens INTEGER NOT NULL,
+    cost_usd REAL NOT NULL,             -- Estimated from the configured token prices
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage (created_at);
+
+-- Server wide settings admins can change without a restart
+CREATE TABLE IF NOT EXISTS app_settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS app_settings;
+DROP INDEX IF EXISTS idx_llm_usage_created_at;
+DROP TABLE IF EXISTS llm_usage;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE movies ADD COLUMN overview TEXT NOT NULL DEFAULT '';
+
+-- Forget the last sync so the next one is a full sync and fills in the overviews
+DELETE FROM library_syncs;
+
+-- One embedding per movie for vibe search, of its title, overview and genres
+CREATE TABLE IF NOT EXISTS movie_embeddings (
+    movie_id TEXT PRIMARY KEY,
+    model TEXT NOT NULL,
+    content_hash TEXT NOT NULL, -- Hash of the model and embedded text, to spot stale vectors
+    vector BLOB NOT NULL,       -- Little endian float32s, normalized to unit length
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (movie_id) REFERENCES movies (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS movie_embeddings;
+ALTER TABLE movies DROP COLUMN overview;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Admins can manage users, host personas and AI usage, and see the debug page
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK(role IN ('user', 'admin'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Codes admins hand out so new users can sign up while registration is invite only
+CREATE TABLE IF NOT EXISTS invite_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL UNIQUE,
+    created_by INTEGER NOT NULL,
+    max_uses INTEGER NOT NULL DEFAULT 1, -- 0 is unlimited
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME,                 -- NULL never expires
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Which user signed up with which code
+CREATE TABLE IF NOT EXISTS invite_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invite_code_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    redeemed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (invite_code_id) REFERENCES invite_codes (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS invite_redemptions;
+DROP TABLE IF EXISTS invite_codes;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Links a local user to an account at an OpenID Connect issuer. The subject is the
+-- issuer's stable ID for the person, so renaming them there doesn't lose the link.
+CREATE TABLE IF NOT EXISTS oidc_accounts (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_oidc_accounts_user_id ON oidc_accounts (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_oidc_accounts_user_id;
+DROP TABLE IF EXISTS oidc_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Guests join a single room without a password. guest_room is the room they joined, empty
+-- for full accounts, and guest_expires_at is when the guest and everything they did is
+-- deleted, unless they convert to a full account first.
+ALTER TABLE users ADD COLUMN guest_room TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN guest_expires_at DATETIME;
+CREATE INDEX IF NOT EXISTS idx_users_guest_expires_at ON users (guest_expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_guest_expires_at;
+ALTER TABLE users DROP COLUMN guest_expires_at;
+ALTER TABLE users DROP COLUMN guest_room;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Room settings a user saved under a name, to host the same kind of room again
+CREATE TABLE IF NOT EXISTS room_presets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    max_draft_count INTEGER NOT NULL,
+    max_players INTEGER NOT NULL,
+    visibility TEXT NOT NULL DEFAULT 'public', -- The password isn't kept, it's asked for when hosting
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS room_presets;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Runtime in minutes, 0 when unknown. Rooms can limit their draft to movies under a
+-- length.
+ALTER TABLE movies ADD COLUMN runtime_minutes INTEGER NOT NULL DEFAULT 0;
+-- Forgetting the last sync makes the next one a full sync, which fills in the runtime
+-- of movies that haven't changed
+DELETE FROM library_syncs;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE movies DROP COLUMN runtime_minutes;
+-- +goose StatementEnd
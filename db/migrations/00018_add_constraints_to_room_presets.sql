@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Which movies a preset's rooms draft from, 0 and empty are no limit
+ALTER TABLE room_presets ADD COLUMN genres TEXT NOT NULL DEFAULT '[]'; -- JSON array of genre names
+ALTER TABLE room_presets ADD COLUMN max_rating TEXT NOT NULL DEFAULT '';
+ALTER TABLE room_presets ADD COLUMN min_year INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE room_presets ADD COLUMN max_year INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE room_presets ADD COLUMN max_runtime INTEGER NOT NULL DEFAULT 0; -- Minutes
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE room_presets DROP COLUMN max_runtime;
+ALTER TABLE room_presets DROP COLUMN max_year;
+ALTER TABLE room_presets DROP COLUMN min_year;
+ALTER TABLE room_presets DROP COLUMN max_rating;
+ALTER TABLE room_presets DROP COLUMN genres;
+-- +goose StatementEnd
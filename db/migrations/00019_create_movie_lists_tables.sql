@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Movies a user wants to watch, rooms can draft from their players' watchlists
+CREATE TABLE IF NOT EXISTS watchlist_items (
+    user_id INTEGER NOT NULL,
+    movie_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, movie_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Collections and playlists a user imported from the movie provider, rooms can draft
+-- from one of them
+CREATE TABLE IF NOT EXISTS movie_lists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL, -- 'collection' or 'playlist'
+    source_id TEXT NOT NULL, -- The list's ID on the provider, importing again updates it
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, source_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS movie_list_items (
+    list_id INTEGER NOT NULL,
+    movie_id TEXT NOT NULL,
+    position INTEGER NOT NULL, -- Order of the movie in the provider's list
+    PRIMARY KEY (list_id, movie_id),
+    FOREIGN KEY (list_id) REFERENCES movie_lists (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS movie_list_items;
+DROP TABLE IF EXISTS movie_lists;
+DROP TABLE IF EXISTS watchlist_items;
+-- +goose StatementEnd
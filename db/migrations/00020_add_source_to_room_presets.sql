@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Where a preset's rooms draft from, the whole library unless set
+ALTER TABLE room_presets ADD COLUMN source TEXT NOT NULL DEFAULT ''; -- '', 'watchlists' or 'list'
+ALTER TABLE room_presets ADD COLUMN source_list_id INTEGER NOT NULL DEFAULT 0; -- movie_lists.id when source is 'list'
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE room_presets DROP COLUMN source_list_id;
+ALTER TABLE room_presets DROP COLUMN source;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- name: DeleteAppSetting :exec
+DELETE FROM app_settings
+WHERE key = ?;
+
+-- name: GetAppSetting :one
+SELECT value FROM app_settings
+WHERE key = ?
+LIMIT 1;
+
+-- name: SetAppSetting :exec
+INSERT INTO app_settings (key, value)
+VALUES (?, ?)
+ON CONFLICT (key) DO UPDATE SET
+    value = excluded.value,
+    updated_at = CURRENT_TIMESTAMP;
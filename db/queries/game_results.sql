@@ -31,3 +31,7 @@ FROM game_results
 GROUP BY winning_movie_id, winning_movie_name
 ORDER BY win_count DESC
 LIMIT ?;
+
+-- name: DeleteGameParticipantsByUserID :exec
+DELETE FROM game_participants
+WHERE user_id = ?;
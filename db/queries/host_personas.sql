@@ -0,0 +1,43 @@
+-- name: ListHostPersonas :many
+SELECT * FROM host_personas
+ORDER BY name;
+
+-- name: GetHostPersona :one
+SELECT * FROM host_personas
+WHERE id = ?
+LIMIT 1;
+
+-- name: GetActiveHostPersona :one
+SELECT * FROM host_personas
+WHERE is_active = 1
+LIMIT 1;
+
+-- name: CreateHostPersona :one
+INSERT INTO host_personas (
+    name,
+    system_prompt,
+    lobby_arrival_prompt,
+    draft_overlap_prompt,
+    voting_hot_take_prompt,
+    tie_prompt
+) VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateHostPersona :exec
+UPDATE host_personas
+SET name = ?,
+    system_prompt = ?,
+    lobby_arrival_prompt = ?,
+    draft_overlap_prompt = ?,
+    voting_hot_take_prompt = ?,
+    tie_prompt = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetActiveHostPersona :exec
+UPDATE host_personas
+SET is_active = (id = ?);
+
+-- name: DeleteHostPersona :exec
+DELETE FROM host_personas
+WHERE id = ?;
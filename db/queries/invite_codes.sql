@@ -0,0 +1,46 @@
+-- name: CreateInviteCode :one
+INSERT INTO invite_codes (code, created_by, max_uses, expires_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListInviteCodes :many
+SELECT ic.*, COALESCE(u.username, '') AS created_by_username
+FROM invite_codes ic
+LEFT JOIN users u ON u.id = ic.created_by
+ORDER BY ic.created_at DESC, ic.id DESC;
+
+-- name: GetInviteCodeByCode :one
+SELECT * FROM invite_codes
+WHERE code = ?
+LIMIT 1;
+
+-- Only counts the use if the code still has uses left and hasn't expired, so two
+-- people racing for the last use can't both get in
+-- name: RedeemInviteCode :execrows
+UPDATE invite_codes
+SET uses = uses + 1
+WHERE id = sqlc.arg(id)
+  AND (max_uses = 0 OR uses < max_uses)
+  AND (expires_at IS NULL OR expires_at > sqlc.arg(now));
+
+-- name: DeleteInviteCode :exec
+DELETE FROM invite_codes
+WHERE id = ?;
+
+-- name: CreateInviteRedemption :exec
+INSERT INTO invite_redemptions (invite_code_id, user_id)
+VALUES (?, ?);
+
+-- name: ListInviteRedemptions :many
+SELECT ir.invite_code_id, u.username, ir.redeemed_at
+FROM invite_redemptions ir
+INNER JOIN users u ON u.id = ir.user_id
+ORDER BY ir.redeemed_at;
+
+-- name: DeleteInviteRedemptionsByCodeID :exec
+DELETE FROM invite_redemptions
+WHERE invite_code_id = ?;
+
+-- name: DeleteInviteRedemptionsByUserID :exec
+DELETE FROM invite_redemptions
+WHERE user_id = ?;
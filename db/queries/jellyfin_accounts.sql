@@ -0,0 +1,22 @@
+-- name: UpsertJellyfinAccount :exec
+INSERT INTO jellyfin_accounts (user_id, jellyfin_user_id, access_token)
+VALUES (?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE SET
+    jellyfin_user_id = excluded.jellyfin_user_id,
+    access_token = excluded.access_token,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetJellyfinAccountByJellyfinUserID :one
+SELECT * FROM jellyfin_accounts
+WHERE jellyfin_user_id = ?
+LIMIT 1;
+
+-- name: GetJellyfinAccountByUsername :one
+SELECT ja.* FROM jellyfin_accounts ja
+INNER JOIN users u ON u.id = ja.user_id
+WHERE u.username = ?
+LIMIT 1;
+
+-- name: DeleteJellyfinAccount :exec
+DELETE FROM jellyfin_accounts
+WHERE user_id = ?;
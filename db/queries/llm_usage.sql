@@ -0,0 +1,44 @@
+-- name: CreateLLMUsage :exec
+INSERT INTO llm_usage (
+    room_name,
+    purpose,
+    backend,
+    model,
+    prompt_tokens,
+    completion_tokens,
+    cost_usd
+) VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetLLMCostThisMonth :one
+SELECT CAST(COALESCE(SUM(cost_usd), 0) AS REAL) AS total_cost
+FROM llm_usage
+WHERE created_at >= strftime('%Y-%m-01', 'now');
+
+-- name: GetLLMUsageByMonth :many
+SELECT
+    CAST(strftime('%Y-%m', created_at) AS TEXT) AS month,
+    COUNT(*) AS calls,
+    CAST(COALESCE(SUM(prompt_tokens), 0) AS INTEGER) AS prompt_tokens,
+    CAST(COALESCE(SUM(completion_tokens), 0) AS INTEGER) AS completion_tokens,
+    CAST(COALESCE(SUM(cost_usd), 0) AS REAL) AS cost_usd
+FROM llm_usage
+GROUP BY month
+ORDER BY month DESC
+LIMIT ?;
+
+-- name: GetLLMUsageByPurposeThisMonth :many
+SELECT
+    purpose,
+    COUNT(*) AS calls,
+    CAST(COALESCE(SUM(prompt_tokens), 0) AS INTEGER) AS prompt_tokens,
+    CAST(COALESCE(SUM(completion_tokens), 0) AS INTEGER) AS completion_tokens,
+    CAST(COALESCE(SUM(cost_usd), 0) AS REAL) AS cost_usd
+FROM llm_usage
+WHERE created_at >= strftime('%Y-%m-01', 'now')
+GROUP BY purpose
+ORDER BY cost_usd DESC;
+
+-- name: ListRecentLLMUsage :many
+SELECT * FROM llm_usage
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
@@ -0,0 +1,15 @@
+-- name: DeleteOrphanedMovieEmbeddings :execrows
+DELETE FROM movie_embeddings
+WHERE movie_id NOT IN (SELECT id FROM movies);
+
+-- name: ListMovieEmbeddings :many
+SELECT * FROM movie_embeddings;
+
+-- name: UpsertMovieEmbedding :exec
+INSERT INTO movie_embeddings (movie_id, model, content_hash, vector)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (movie_id) DO UPDATE SET
+    model = excluded.model,
+    content_hash = excluded.content_hash,
+    vector = excluded.vector,
+    created_at = CURRENT_TIMESTAMP;
@@ -0,0 +1,53 @@
+-- Movies that left the library aren't counted
+-- name: ListMovieLists :many
+SELECT movie_lists.*, COUNT(movies.id) AS movie_count
+FROM movie_lists
+LEFT JOIN movie_list_items ON movie_list_items.list_id = movie_lists.id
+LEFT JOIN movies ON movies.id = movie_list_items.movie_id
+WHERE movie_lists.user_id = ?
+GROUP BY movie_lists.id
+ORDER BY movie_lists.name COLLATE NOCASE;
+
+-- name: GetMovieList :one
+SELECT * FROM movie_lists
+WHERE id = ? AND user_id = ?
+LIMIT 1;
+
+-- Importing a list the user already has renames it in place, so rooms and presets
+-- drafting from it keep working
+-- name: UpsertMovieList :one
+INSERT INTO movie_lists (user_id, kind, source_id, name)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, source_id) DO UPDATE
+SET kind = excluded.kind,
+    name = excluded.name,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteMovieList :exec
+DELETE FROM movie_lists
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteMovieListsByUserID :exec
+DELETE FROM movie_lists
+WHERE user_id = ?;
+
+-- name: ListMovieListMovies :many
+SELECT movies.* FROM movie_list_items
+JOIN movies ON movies.id = movie_list_items.movie_id
+WHERE movie_list_items.list_id = ?
+ORDER BY movie_list_items.position;
+
+-- Playlists can have a movie more than once, only the first one is kept
+-- name: AddMovieListItem :exec
+INSERT INTO movie_list_items (list_id, movie_id, position)
+VALUES (?, ?, ?)
+ON CONFLICT (list_id, movie_id) DO NOTHING;
+
+-- name: DeleteMovieListItems :exec
+DELETE FROM movie_list_items
+WHERE list_id = ?;
+
+-- name: DeleteMovieListItemsByUserID :exec
+DELETE FROM movie_list_items
+WHERE list_id IN (SELECT id FROM movie_lists WHERE user_id = ?);
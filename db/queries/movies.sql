@@ -0,0 +1,84 @@
+-- name: UpsertMovie :exec
+INSERT INTO movies (
+    id,
+    name,
+    community_rating,
+    critic_rating,
+    genres,
+    official_rating,
+    premiere_date,
+    primary_image_tag,
+    production_year,
+    runtime_minutes,
+    overview,
+    last_seen_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    community_rating = excluded.community_rating,
+    critic_rating = excluded.critic_rating,
+    genres = excluded.genres,
+    official_rating = excluded.official_rating,
+    premiere_date = excluded.premiere_date,
+    primary_image_tag = excluded.primary_image_tag,
+    production_year = excluded.production_year,
+    runtime_minutes = excluded.runtime_minutes,
+    overview = excluded.overview,
+    last_seen_at = excluded.last_seen_at,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: TouchMovie :exec
+UPDATE movies
+SET last_seen_at = ?
+WHERE id = ?;
+
+-- name: DeleteMoviesNotSeenSince :execrows
+DELETE FROM movies
+WHERE last_seen_at < ?;
+
+-- name: CountMovies :one
+SELECT COUNT(*) FROM movies;
+
+-- name: GetMovieImageTag :one
+SELECT primary_image_tag FROM movies
+WHERE id = ?
+LIMIT 1;
+
+-- name: ListMovies :many
+SELECT * FROM movies
+ORDER BY rowid;
+
+-- name: SearchMovies :many
+SELECT * FROM movies
+WHERE (
+    CAST(sqlc.arg(genre) AS TEXT) = ''
+    OR EXISTS (SELECT 1 FROM json_each(movies.genres) WHERE json_each.value = sqlc.arg(genre))
+)
+AND name LIKE '%' || CAST(sqlc.arg(search) AS TEXT) || '%'
+ORDER BY
+    CASE WHEN CAST(sqlc.arg(sort_by) AS TEXT) = 'name' AND NOT CAST(sqlc.arg(descending) AS BOOLEAN) THEN name COLLATE NOCASE END ASC,
+    CASE WHEN sqlc.arg(sort_by) = 'name' AND sqlc.arg(descending) THEN name COLLATE NOCASE END DESC,
+    CASE WHEN sqlc.arg(sort_by) = 'year' AND NOT sqlc.arg(descending) THEN production_year END ASC,
+    CASE WHEN sqlc.arg(sort_by) = 'year' AND sqlc.arg(descending) THEN production_year END DESC,
+    CASE WHEN sqlc.arg(sort_by) = 'critic' AND NOT sqlc.arg(descending) THEN critic_rating END ASC,
+    CASE WHEN sqlc.arg(sort_by) = 'critic' AND sqlc.arg(descending) THEN critic_rating END DESC,
+    CASE WHEN sqlc.arg(sort_by) = 'community' AND NOT sqlc.arg(descending) THEN community_rating END ASC,
+    CASE WHEN sqlc.arg(sort_by) = 'community' AND sqlc.arg(descending) THEN community_rating END DESC,
+    rowid;
+
+-- name: GetLibrarySync :one
+SELECT * FROM library_syncs
+WHERE provider = ?
+LIMIT 1;
+
+-- name: UpsertLibrarySync :exec
+INSERT INTO library_syncs (provider, last_synced_at)
+VALUES (?, ?)
+ON CONFLICT (provider) DO UPDATE SET
+    last_synced_at = excluded.last_synced_at;
+
+-- The movies table only holds one provider's library, so another provider's sync state
+-- is stale once this one has synced
+-- name: DeleteOtherLibrarySyncs :exec
+DELETE FROM library_syncs
+WHERE provider != ?;
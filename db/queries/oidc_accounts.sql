@@ -0,0 +1,12 @@
+-- name: CreateOIDCAccount :exec
+INSERT INTO oidc_accounts (issuer, subject, user_id)
+VALUES (?, ?, ?);
+
+-- name: GetOIDCAccount :one
+SELECT * FROM oidc_accounts
+WHERE issuer = ? AND subject = ?
+LIMIT 1;
+
+-- name: DeleteOIDCAccountsByUserID :exec
+DELETE FROM oidc_accounts
+WHERE user_id = ?;
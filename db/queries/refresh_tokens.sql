@@ -1,12 +1,36 @@
 -- name: CreateSession :exec
-INSERT INTO refresh_tokens (user_id, token, expires_at, created_at)
-VALUES (?, ?, ?, CURRENT_TIMESTAMP);
+INSERT INTO refresh_tokens (user_id, token, expires_at, user_agent, created_at, last_seen_at)
+VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
 
 -- name: GetUserIDByToken :one
 SELECT user_id FROM refresh_tokens
 WHERE token = ?
 LIMIT 1;
 
+-- Slides the session's expiry forward. Only writes when the session hasn't been
+-- renewed since stale_before, so not every request costs a write.
+-- name: RenewSession :exec
+UPDATE refresh_tokens
+SET last_seen_at = sqlc.arg(now), expires_at = sqlc.arg(expires_at)
+WHERE token = sqlc.arg(token) AND last_seen_at < sqlc.arg(stale_before);
+
+-- name: ListSessionsByUserID :many
+SELECT * FROM refresh_tokens
+WHERE user_id = sqlc.arg(user_id) AND expires_at > sqlc.arg(now)
+ORDER BY last_seen_at DESC;
+
 -- name: DeleteSession :exec
 DELETE FROM refresh_tokens
 WHERE token = ?;
+
+-- name: DeleteUserSession :exec
+DELETE FROM refresh_tokens
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteSessionsByUserID :exec
+DELETE FROM refresh_tokens
+WHERE user_id = ?;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at <= ?;
@@ -0,0 +1,47 @@
+-- name: ListRoomPresets :many
+SELECT * FROM room_presets
+WHERE user_id = ?
+ORDER BY name;
+
+-- name: GetRoomPreset :one
+SELECT * FROM room_presets
+WHERE id = ? AND user_id = ?
+LIMIT 1;
+
+-- Saving under a name the user already has overwrites that preset
+-- name: UpsertRoomPreset :one
+INSERT INTO room_presets (
+    user_id,
+    name,
+    max_draft_count,
+    max_players,
+    visibility,
+    genres,
+    max_rating,
+    min_year,
+    max_year,
+    max_runtime,
+    source,
+    source_list_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id, name) DO UPDATE
+SET max_draft_count = excluded.max_draft_count,
+    max_players = excluded.max_players,
+    visibility = excluded.visibility,
+    genres = excluded.genres,
+    max_rating = excluded.max_rating,
+    min_year = excluded.min_year,
+    max_year = excluded.max_year,
+    max_runtime = excluded.max_runtime,
+    source = excluded.source,
+    source_list_id = excluded.source_list_id,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteRoomPreset :exec
+DELETE FROM room_presets
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteRoomPresetsByUserID :exec
+DELETE FROM room_presets
+WHERE user_id = ?;
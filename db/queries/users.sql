@@ -3,6 +3,11 @@ INSERT INTO users (username, password_hash)
 VALUES (?, ?)
 RETURNING *;
 
+-- name: CreateGuestUser :one
+INSERT INTO users (username, password_hash, guest_room, guest_expires_at)
+VALUES (sqlc.arg(username), '', sqlc.arg(guest_room), sqlc.arg(expires_at))
+RETURNING *;
+
 -- name: GetUserByUsername :one
 SELECT * FROM users
 WHERE username = ?
@@ -16,5 +21,49 @@ LIMIT 1;
 -- name: GetUserBySessionToken :one
 SELECT u.* FROM users u
 INNER JOIN refresh_tokens rt ON u.id = rt.user_id
-WHERE rt.token = ?
+WHERE rt.token = sqlc.arg(token) AND rt.expires_at > sqlc.arg(now)
+  AND (u.guest_expires_at IS NULL OR u.guest_expires_at > sqlc.arg(now))
 LIMIT 1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CountAdmins :one
+SELECT COUNT(*) FROM users
+WHERE role = 'admin';
+
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY username;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?;
+
+-- name: ConvertGuestUser :exec
+UPDATE users
+SET username = ?, password_hash = ?, guest_room = '', guest_expires_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND guest_room != '';
+
+-- name: ExpireRoomGuests :exec
+-- Only ever brings the expiry forward, a guest's deadline never moves back
+UPDATE users
+SET guest_expires_at = sqlc.arg(expires_at)
+WHERE guest_room = sqlc.arg(room) AND guest_expires_at > sqlc.arg(expires_at);
+
+-- name: MoveGuest :exec
+-- A guest follows their room into a rematch, with a fresh lifetime for the new game
+UPDATE users
+SET guest_room = sqlc.arg(guest_room), guest_expires_at = sqlc.arg(expires_at)
+WHERE id = sqlc.arg(id) AND guest_room != '';
+
+-- name: ListExpiredGuestIDs :many
+SELECT id FROM users
+WHERE guest_room != '' AND guest_expires_at <= sqlc.arg(now);
@@ -14,23 +14,31 @@ WHERE user_id = ?
 ORDER BY created_at DESC;
 
 -- name: GetUserMovieDraftCounts :many
+-- Guests' picks only count once they convert to a full account
 SELECT
-  movie_id,
-  movie_name,
-  COALESCE(SUM(CASE WHEN action = 'selected' THEN 1 ELSE -1 END),0) as net_count
-FROM vote_events
-WHERE user_id = ? AND event_type = 'draft_toggle'
-GROUP BY movie_id, movie_name
+  ve.movie_id,
+  ve.movie_name,
+  COALESCE(SUM(CASE WHEN ve.action = 'selected' THEN 1 ELSE -1 END),0) as net_count
+FROM vote_events ve
+JOIN users u ON u.id = ve.user_id
+WHERE ve.user_id = ? AND ve.event_type = 'draft_toggle' AND u.guest_room = ''
+GROUP BY ve.movie_id, ve.movie_name
 HAVING net_count > 0
 ORDER BY net_count DESC;
 
 -- name: GetUserMovieVoteCounts :many
+-- Guests' picks only count once they convert to a full account
 SELECT
-  movie_id,
-  movie_name,
-  COALESCE(SUM(CASE WHEN action = 'selected' THEN 1 ELSE -1 END),0) as net_count
-FROM vote_events
-WHERE user_id = ? AND event_type = 'vote_toggle'
-GROUP BY movie_id, movie_name
+  ve.movie_id,
+  ve.movie_name,
+  COALESCE(SUM(CASE WHEN ve.action = 'selected' THEN 1 ELSE -1 END),0) as net_count
+FROM vote_events ve
+JOIN users u ON u.id = ve.user_id
+WHERE ve.user_id = ? AND ve.event_type = 'vote_toggle' AND u.guest_room = ''
+GROUP BY ve.movie_id, ve.movie_name
 HAVING net_count > 0
 ORDER BY net_count DESC;
+
+-- name: DeleteVoteEventsByUserID :exec
+DELETE FROM vote_events
+WHERE user_id = ?;
@@ -0,0 +1,26 @@
+-- name: ListWatchlistMovies :many
+SELECT movies.* FROM watchlist_items
+JOIN movies ON movies.id = watchlist_items.movie_id
+WHERE watchlist_items.user_id = ?
+ORDER BY watchlist_items.created_at DESC, watchlist_items.rowid DESC;
+
+-- Rooms know their players by name, not ID
+-- name: ListWatchlistMoviesByUsername :many
+SELECT movies.* FROM watchlist_items
+JOIN users ON users.id = watchlist_items.user_id
+JOIN movies ON movies.id = watchlist_items.movie_id
+WHERE users.username = ?
+ORDER BY watchlist_items.created_at DESC, watchlist_items.rowid DESC;
+
+-- name: AddWatchlistItem :exec
+INSERT INTO watchlist_items (user_id, movie_id)
+VALUES (?, ?)
+ON CONFLICT (user_id, movie_id) DO NOTHING;
+
+-- name: DeleteWatchlistItem :execrows
+DELETE FROM watchlist_items
+WHERE user_id = ? AND movie_id = ?;
+
+-- name: DeleteWatchlistItemsByUserID :exec
+DELETE FROM watchlist_items
+WHERE user_id = ?;
@@ -74,6 +74,16 @@ func (q *Queries) CreateGameResult(ctx context.Context, arg CreateGameResultPara
 	return i, err
 }
 
+const deleteGameParticipantsByUserID = `-- name: DeleteGameParticipantsByUserID :exec
+DELETE FROM game_participants
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteGameParticipantsByUserID(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteGameParticipantsByUserID, userID)
+	return err
+}
+
 const getGameResultsByUser = `-- name: GetGameResultsByUser :many
 SELECT gr.id, gr.room_name, gr.winning_movie_id, gr.winning_movie_name, gr.winning_vote_count, gr.total_players, gr.completed_at FROM game_results gr
 JOIN game_participants gp ON gr.id = gp.game_id
@@ -5,9 +5,16 @@
 package sqlcgen
 
 import (
+	"database/sql"
 	"time"
 )
 
+type AppSetting struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type GameParticipant struct {
 	ID      int64       `json:"id"`
 	GameID  int64       `json:"game_id"`
@@ -25,20 +32,156 @@ type GameResult struct {
 	CompletedAt      time.Time `json:"completed_at"`
 }
 
-type RefreshToken struct {
-	ID        int64     `json:"id"`
+type HostPersona struct {
+	ID                  int64     `json:"id"`
+	Name                string    `json:"name"`
+	SystemPrompt        string    `json:"system_prompt"`
+	LobbyArrivalPrompt  string    `json:"lobby_arrival_prompt"`
+	DraftOverlapPrompt  string    `json:"draft_overlap_prompt"`
+	VotingHotTakePrompt string    `json:"voting_hot_take_prompt"`
+	TiePrompt           string    `json:"tie_prompt"`
+	IsActive            bool      `json:"is_active"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type InviteCode struct {
+	ID        int64        `json:"id"`
+	Code      string       `json:"code"`
+	CreatedBy int64        `json:"created_by"`
+	MaxUses   int64        `json:"max_uses"`
+	Uses      int64        `json:"uses"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type InviteRedemption struct {
+	ID           int64     `json:"id"`
+	InviteCodeID int64     `json:"invite_code_id"`
+	UserID       int64     `json:"user_id"`
+	RedeemedAt   time.Time `json:"redeemed_at"`
+}
+
+type JellyfinAccount struct {
+	UserID         int64     `json:"user_id"`
+	JellyfinUserID string    `json:"jellyfin_user_id"`
+	AccessToken    string    `json:"access_token"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type LibrarySync struct {
+	Provider     string    `json:"provider"`
+	LastSyncedAt time.Time `json:"last_synced_at"`
+}
+
+type LlmUsage struct {
+	ID               int64     `json:"id"`
+	RoomName         string    `json:"room_name"`
+	Purpose          string    `json:"purpose"`
+	Backend          string    `json:"backend"`
+	Model            string    `json:"model"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	CostUsd          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type Movie struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	CommunityRating float64 `json:"community_rating"`
+	CriticRating    int64   `json:"critic_rating"`
+	// JSON array of genre names
+	Genres          string    `json:"genres"`
+	OfficialRating  string    `json:"official_rating"`
+	PremiereDate    string    `json:"premiere_date"`
+	PrimaryImageTag string    `json:"primary_image_tag"`
+	ProductionYear  int64     `json:"production_year"`
+	LastSeenAt      time.Time `json:"last_seen_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Overview        string    `json:"overview"`
+	// Runtime in minutes, 0 when unknown. Rooms can limit their draft to movies under a
+	// length.
+	RuntimeMinutes int64 `json:"runtime_minutes"`
+}
+
+type MovieEmbedding struct {
+	MovieID     string    `json:"movie_id"`
+	Model       string    `json:"model"`
+	ContentHash string    `json:"content_hash"`
+	Vector      []byte    `json:"vector"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type MovieList struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+	// 'collection' or 'playlist'
+	Kind string `json:"kind"`
+	// The list's ID on the provider, importing again updates it
+	SourceID  string    `json:"source_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MovieListItem struct {
+	ListID  int64  `json:"list_id"`
+	MovieID string `json:"movie_id"`
+	// Order of the movie in the provider's list
+	Position int64 `json:"position"`
+}
+
+type OidcAccount struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
 	UserID    int64     `json:"user_id"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type RefreshToken struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Token      string    `json:"token"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+type RoomPreset struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	Name          string    `json:"name"`
+	MaxDraftCount int64     `json:"max_draft_count"`
+	MaxPlayers    int64     `json:"max_players"`
+	Visibility    string    `json:"visibility"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// JSON array of genre names
+	Genres    string `json:"genres"`
+	MaxRating string `json:"max_rating"`
+	MinYear   int64  `json:"min_year"`
+	MaxYear   int64  `json:"max_year"`
+	// Minutes
+	MaxRuntime int64 `json:"max_runtime"`
+	// '', 'watchlists' or 'list'
+	Source string `json:"source"`
+	// movie_lists.id when source is 'list'
+	SourceListID int64 `json:"source_list_id"`
+}
+
 type User struct {
-	ID           int64     `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"password_hash"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             int64        `json:"id"`
+	Username       string       `json:"username"`
+	PasswordHash   string       `json:"password_hash"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Role           string       `json:"role"`
+	GuestRoom      string       `json:"guest_room"`
+	GuestExpiresAt sql.NullTime `json:"guest_expires_at"`
 }
 
 type VoteEvent struct {
@@ -50,3 +193,9 @@ type VoteEvent struct {
 	MovieName string    `json:"movie_name"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+type WatchlistItem struct {
+	UserID    int64     `json:"user_id"`
+	MovieID   string    `json:"movie_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -6,24 +6,120 @@ package sqlcgen
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	AddMovieListItem(ctx context.Context, arg AddMovieListItemParams) error
+	AddWatchlistItem(ctx context.Context, arg AddWatchlistItemParams) error
+	ConvertGuestUser(ctx context.Context, arg ConvertGuestUserParams) error
+	CountAdmins(ctx context.Context) (int64, error)
+	CountMovies(ctx context.Context) (int64, error)
 	CreateGameParticipant(ctx context.Context, arg CreateGameParticipantParams) (GameParticipant, error)
 	CreateGameResult(ctx context.Context, arg CreateGameResultParams) (GameResult, error)
+	CreateGuestUser(ctx context.Context, arg CreateGuestUserParams) (User, error)
+	CreateHostPersona(ctx context.Context, arg CreateHostPersonaParams) (HostPersona, error)
+	CreateInviteCode(ctx context.Context, arg CreateInviteCodeParams) (InviteCode, error)
+	CreateInviteRedemption(ctx context.Context, arg CreateInviteRedemptionParams) error
+	CreateLLMUsage(ctx context.Context, arg CreateLLMUsageParams) error
+	CreateOIDCAccount(ctx context.Context, arg CreateOIDCAccountParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateVoteEvent(ctx context.Context, arg CreateVoteEventParams) (VoteEvent, error)
+	DeleteAppSetting(ctx context.Context, key string) error
+	DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteGameParticipantsByUserID(ctx context.Context, userID int64) error
+	DeleteHostPersona(ctx context.Context, id int64) error
+	DeleteInviteCode(ctx context.Context, id int64) error
+	DeleteInviteRedemptionsByCodeID(ctx context.Context, inviteCodeID int64) error
+	DeleteInviteRedemptionsByUserID(ctx context.Context, userID int64) error
+	DeleteJellyfinAccount(ctx context.Context, userID int64) error
+	DeleteMovieList(ctx context.Context, arg DeleteMovieListParams) error
+	DeleteMovieListItems(ctx context.Context, listID int64) error
+	DeleteMovieListItemsByUserID(ctx context.Context, userID int64) error
+	DeleteMovieListsByUserID(ctx context.Context, userID int64) error
+	DeleteMoviesNotSeenSince(ctx context.Context, lastSeenAt time.Time) (int64, error)
+	DeleteOIDCAccountsByUserID(ctx context.Context, userID int64) error
+	DeleteOrphanedMovieEmbeddings(ctx context.Context) (int64, error)
+	// The movies table only holds one provider's library, so another provider's sync state
+	// is stale once this one has synced
+	DeleteOtherLibrarySyncs(ctx context.Context, provider string) error
+	DeleteRoomPreset(ctx context.Context, arg DeleteRoomPresetParams) error
+	DeleteRoomPresetsByUserID(ctx context.Context, userID int64) error
 	DeleteSession(ctx context.Context, token string) error
+	DeleteSessionsByUserID(ctx context.Context, userID int64) error
+	DeleteUser(ctx context.Context, id int64) error
+	DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) error
+	DeleteVoteEventsByUserID(ctx context.Context, userID int64) error
+	DeleteWatchlistItem(ctx context.Context, arg DeleteWatchlistItemParams) (int64, error)
+	DeleteWatchlistItemsByUserID(ctx context.Context, userID int64) error
+	// Only ever brings the expiry forward, a guest's deadline never moves back
+	ExpireRoomGuests(ctx context.Context, arg ExpireRoomGuestsParams) error
+	GetActiveHostPersona(ctx context.Context) (HostPersona, error)
+	GetAppSetting(ctx context.Context, key string) (string, error)
 	GetGameResultsByUser(ctx context.Context, userID int64) ([]GameResult, error)
+	GetHostPersona(ctx context.Context, id int64) (HostPersona, error)
+	GetInviteCodeByCode(ctx context.Context, code string) (InviteCode, error)
+	GetJellyfinAccountByJellyfinUserID(ctx context.Context, jellyfinUserID string) (JellyfinAccount, error)
+	GetJellyfinAccountByUsername(ctx context.Context, username string) (JellyfinAccount, error)
+	GetLLMCostThisMonth(ctx context.Context) (float64, error)
+	GetLLMUsageByMonth(ctx context.Context, limit int64) ([]GetLLMUsageByMonthRow, error)
+	GetLLMUsageByPurposeThisMonth(ctx context.Context) ([]GetLLMUsageByPurposeThisMonthRow, error)
+	GetLibrarySync(ctx context.Context, provider string) (LibrarySync, error)
 	GetMostPopularWinningMovies(ctx context.Context, limit int64) ([]GetMostPopularWinningMoviesRow, error)
+	GetMovieImageTag(ctx context.Context, id string) (string, error)
+	GetMovieList(ctx context.Context, arg GetMovieListParams) (MovieList, error)
+	GetOIDCAccount(ctx context.Context, arg GetOIDCAccountParams) (OidcAccount, error)
+	GetRoomPreset(ctx context.Context, arg GetRoomPresetParams) (RoomPreset, error)
 	GetUserByID(ctx context.Context, id int64) (User, error)
-	GetUserBySessionToken(ctx context.Context, token string) (User, error)
+	GetUserBySessionToken(ctx context.Context, arg GetUserBySessionTokenParams) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
 	GetUserIDByToken(ctx context.Context, token string) (int64, error)
+	// Guests' picks only count once they convert to a full account
 	GetUserMovieDraftCounts(ctx context.Context, userID int64) ([]GetUserMovieDraftCountsRow, error)
+	// Guests' picks only count once they convert to a full account
 	GetUserMovieVoteCounts(ctx context.Context, userID int64) ([]GetUserMovieVoteCountsRow, error)
 	GetVoteEventsByUser(ctx context.Context, userID int64) ([]VoteEvent, error)
+	ListExpiredGuestIDs(ctx context.Context, now time.Time) ([]int64, error)
+	ListHostPersonas(ctx context.Context) ([]HostPersona, error)
+	ListInviteCodes(ctx context.Context) ([]ListInviteCodesRow, error)
+	ListInviteRedemptions(ctx context.Context) ([]ListInviteRedemptionsRow, error)
+	ListMovieEmbeddings(ctx context.Context) ([]MovieEmbedding, error)
+	ListMovieListMovies(ctx context.Context, listID int64) ([]Movie, error)
+	// Movies that left the library aren't counted
+	ListMovieLists(ctx context.Context, userID int64) ([]ListMovieListsRow, error)
+	ListMovies(ctx context.Context) ([]Movie, error)
+	ListRecentLLMUsage(ctx context.Context, limit int64) ([]LlmUsage, error)
+	ListRoomPresets(ctx context.Context, userID int64) ([]RoomPreset, error)
+	ListSessionsByUserID(ctx context.Context, arg ListSessionsByUserIDParams) ([]RefreshToken, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	ListWatchlistMovies(ctx context.Context, userID int64) ([]Movie, error)
+	// Rooms know their players by name, not ID
+	ListWatchlistMoviesByUsername(ctx context.Context, username string) ([]Movie, error)
+	// A guest follows their room into a rematch, with a fresh lifetime for the new game
+	MoveGuest(ctx context.Context, arg MoveGuestParams) error
+	// Only counts the use if the code still has uses left and hasn't expired, so two
+	// people racing for the last use can't both get in
+	RedeemInviteCode(ctx context.Context, arg RedeemInviteCodeParams) (int64, error)
+	// Slides the session's expiry forward. Only writes when the session hasn't been
+	// renewed since stale_before, so not every request costs a write.
+	RenewSession(ctx context.Context, arg RenewSessionParams) error
+	SearchMovies(ctx context.Context, arg SearchMoviesParams) ([]Movie, error)
+	SetActiveHostPersona(ctx context.Context, id int64) error
+	SetAppSetting(ctx context.Context, arg SetAppSettingParams) error
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) error
+	TouchMovie(ctx context.Context, arg TouchMovieParams) error
+	UpdateHostPersona(ctx context.Context, arg UpdateHostPersonaParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpsertJellyfinAccount(ctx context.Context, arg UpsertJellyfinAccountParams) error
+	UpsertLibrarySync(ctx context.Context, arg UpsertLibrarySyncParams) error
+	UpsertMovie(ctx context.Context, arg UpsertMovieParams) error
+	UpsertMovieEmbedding(ctx context.Context, arg UpsertMovieEmbeddingParams) error
+	// Importing a list the user already has renames it in place, so rooms and presets
+	// drafting from it keep working
+	UpsertMovieList(ctx context.Context, arg UpsertMovieListParams) (MovieList, error)
+	// Saving under a name the user already has overwrites that preset
+	UpsertRoomPreset(ctx context.Context, arg UpsertRoomPresetParams) (RoomPreset, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -11,21 +11,40 @@ import (
 )
 
 const createSession = `-- name: CreateSession :exec
-INSERT INTO refresh_tokens (user_id, token, expires_at, created_at)
-VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+INSERT INTO refresh_tokens (user_id, token, expires_at, user_agent, created_at, last_seen_at)
+VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 `
 
 type CreateSessionParams struct {
 	UserID    int64     `json:"user_id"`
 	Token     string    `json:"token"`
 	ExpiresAt time.Time `json:"expires_at"`
+	UserAgent string    `json:"user_agent"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
-	_, err := q.db.ExecContext(ctx, createSession, arg.UserID, arg.Token, arg.ExpiresAt)
+	_, err := q.db.ExecContext(ctx, createSession,
+		arg.UserID,
+		arg.Token,
+		arg.ExpiresAt,
+		arg.UserAgent,
+	)
 	return err
 }
 
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredSessions, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteSession = `-- name: DeleteSession :exec
 DELETE FROM refresh_tokens
 WHERE token = ?
@@ -36,6 +55,31 @@ func (q *Queries) DeleteSession(ctx context.Context, token string) error {
 	return err
 }
 
+const deleteSessionsByUserID = `-- name: DeleteSessionsByUserID :exec
+DELETE FROM refresh_tokens
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteSessionsByUserID(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSessionsByUserID, userID)
+	return err
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :exec
+DELETE FROM refresh_tokens
+WHERE id = ? AND user_id = ?
+`
+
+type DeleteUserSessionParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSession, arg.ID, arg.UserID)
+	return err
+}
+
 const getUserIDByToken = `-- name: GetUserIDByToken :one
 SELECT user_id FROM refresh_tokens
 WHERE token = ?
@@ -48,3 +92,70 @@ func (q *Queries) GetUserIDByToken(ctx context.Context, token string) (int64, er
 	err := row.Scan(&user_id)
 	return user_id, err
 }
+
+const listSessionsByUserID = `-- name: ListSessionsByUserID :many
+SELECT id, user_id, token, expires_at, created_at, last_seen_at, user_agent FROM refresh_tokens
+WHERE user_id = ?1 AND expires_at > ?2
+ORDER BY last_seen_at DESC
+`
+
+type ListSessionsByUserIDParams struct {
+	UserID int64     `json:"user_id"`
+	Now    time.Time `json:"now"`
+}
+
+func (q *Queries) ListSessionsByUserID(ctx context.Context, arg ListSessionsByUserIDParams) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionsByUserID, arg.UserID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Token,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.UserAgent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renewSession = `-- name: RenewSession :exec
+UPDATE refresh_tokens
+SET last_seen_at = ?1, expires_at = ?2
+WHERE token = ?3 AND last_seen_at < ?4
+`
+
+type RenewSessionParams struct {
+	Now         time.Time `json:"now"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Token       string    `json:"token"`
+	StaleBefore time.Time `json:"stale_before"`
+}
+
+// Slides the session's expiry forward. Only writes when the session hasn't been
+// renewed since stale_before, so not every request costs a write.
+func (q *Queries) RenewSession(ctx context.Context, arg RenewSessionParams) error {
+	_, err := q.db.ExecContext(ctx, renewSession,
+		arg.Now,
+		arg.ExpiresAt,
+		arg.Token,
+		arg.StaleBefore,
+	)
+	return err
+}
@@ -7,12 +7,70 @@ package sqlcgen
 
 import (
 	"context"
+	"time"
 )
 
+const convertGuestUser = `-- name: ConvertGuestUser :exec
+UPDATE users
+SET username = ?, password_hash = ?, guest_room = '', guest_expires_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND guest_room != ''
+`
+
+type ConvertGuestUserParams struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+	ID           int64  `json:"id"`
+}
+
+func (q *Queries) ConvertGuestUser(ctx context.Context, arg ConvertGuestUserParams) error {
+	_, err := q.db.ExecContext(ctx, convertGuestUser, arg.Username, arg.PasswordHash, arg.ID)
+	return err
+}
+
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*) FROM users
+WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createGuestUser = `-- name: CreateGuestUser :one
+INSERT INTO users (username, password_hash, guest_room, guest_expires_at)
+VALUES (?1, '', ?2, ?3)
+RETURNING id, username, password_hash, created_at, updated_at, role, guest_room, guest_expires_at
+`
+
+type CreateGuestUserParams struct {
+	Username  string    `json:"username"`
+	GuestRoom string    `json:"guest_room"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateGuestUser(ctx context.Context, arg CreateGuestUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createGuestUser, arg.Username, arg.GuestRoom, arg.ExpiresAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.GuestRoom,
+		&i.GuestExpiresAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (username, password_hash)
 VALUES (?, ?)
-RETURNING id, username, password_hash, created_at, updated_at
+RETURNING id, username, password_hash, created_at, updated_at, role, guest_room, guest_expires_at
 `
 
 type CreateUserParams struct {
@@ -29,12 +87,42 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.GuestRoom,
+		&i.GuestExpiresAt,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const expireRoomGuests = `-- name: ExpireRoomGuests :exec
+UPDATE users
+SET guest_expires_at = ?1
+WHERE guest_room = ?2 AND guest_expires_at > ?1
+`
+
+type ExpireRoomGuestsParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Room      string    `json:"room"`
+}
+
+// Only ever brings the expiry forward, a guest's deadline never moves back
+func (q *Queries) ExpireRoomGuests(ctx context.Context, arg ExpireRoomGuestsParams) error {
+	_, err := q.db.ExecContext(ctx, expireRoomGuests, arg.ExpiresAt, arg.Room)
+	return err
+}
+
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, password_hash, created_at, updated_at FROM users
+SELECT id, username, password_hash, created_at, updated_at, role, guest_room, guest_expires_at FROM users
 WHERE id = ?
 LIMIT 1
 `
@@ -48,19 +136,28 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.GuestRoom,
+		&i.GuestExpiresAt,
 	)
 	return i, err
 }
 
 const getUserBySessionToken = `-- name: GetUserBySessionToken :one
-SELECT u.id, u.username, u.password_hash, u.created_at, u.updated_at FROM users u
+SELECT u.id, u.username, u.password_hash, u.created_at, u.updated_at, u.role, u.guest_room, u.guest_expires_at FROM users u
 INNER JOIN refresh_tokens rt ON u.id = rt.user_id
-WHERE rt.token = ?
+WHERE rt.token = ?1 AND rt.expires_at > ?2
+  AND (u.guest_expires_at IS NULL OR u.guest_expires_at > ?2)
 LIMIT 1
 `
 
-func (q *Queries) GetUserBySessionToken(ctx context.Context, token string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserBySessionToken, token)
+type GetUserBySessionTokenParams struct {
+	Token string    `json:"token"`
+	Now   time.Time `json:"now"`
+}
+
+func (q *Queries) GetUserBySessionToken(ctx context.Context, arg GetUserBySessionTokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserBySessionToken, arg.Token, arg.Now)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -68,12 +165,15 @@ func (q *Queries) GetUserBySessionToken(ctx context.Context, token string) (User
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.GuestRoom,
+		&i.GuestExpiresAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password_hash, created_at, updated_at FROM users
+SELECT id, username, password_hash, created_at, updated_at, role, guest_room, guest_expires_at FROM users
 WHERE username = ?
 LIMIT 1
 `
@@ -87,6 +187,124 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.GuestRoom,
+		&i.GuestExpiresAt,
 	)
 	return i, err
 }
+
+const listExpiredGuestIDs = `-- name: ListExpiredGuestIDs :many
+SELECT id FROM users
+WHERE guest_room != '' AND guest_expires_at <= ?1
+`
+
+func (q *Queries) ListExpiredGuestIDs(ctx context.Context, now time.Time) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredGuestIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, password_hash, created_at, updated_at, role, guest_room, guest_expires_at FROM users
+ORDER BY username
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Role,
+			&i.GuestRoom,
+			&i.GuestExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveGuest = `-- name: MoveGuest :exec
+UPDATE users
+SET guest_room = ?1, guest_expires_at = ?2
+WHERE id = ?3 AND guest_room != ''
+`
+
+type MoveGuestParams struct {
+	GuestRoom string    `json:"guest_room"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ID        int64     `json:"id"`
+}
+
+// A guest follows their room into a rematch, with a fresh lifetime for the new game
+func (q *Queries) MoveGuest(ctx context.Context, arg MoveGuestParams) error {
+	_, err := q.db.ExecContext(ctx, moveGuest, arg.GuestRoom, arg.ExpiresAt, arg.ID)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetUserRoleParams struct {
+	Role string `json:"role"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.Role, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateUserPasswordParams struct {
+	PasswordHash string `json:"password_hash"`
+	ID           int64  `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.PasswordHash, arg.ID)
+	return err
+}
@@ -49,14 +49,25 @@ func (q *Queries) CreateVoteEvent(ctx context.Context, arg CreateVoteEventParams
 	return i, err
 }
 
+const deleteVoteEventsByUserID = `-- name: DeleteVoteEventsByUserID :exec
+DELETE FROM vote_events
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteVoteEventsByUserID(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteVoteEventsByUserID, userID)
+	return err
+}
+
 const getUserMovieDraftCounts = `-- name: GetUserMovieDraftCounts :many
 SELECT
-  movie_id,
-  movie_name,
-  COALESCE(SUM(CASE WHEN action = 'selected' THEN 1 ELSE -1 END),0) as net_count
-FROM vote_events
-WHERE user_id = ? AND event_type = 'draft_toggle'
-GROUP BY movie_id, movie_name
+  ve.movie_id,
+  ve.movie_name,
+  COALESCE(SUM(CASE WHEN ve.action = 'selected' THEN 1 ELSE -1 END),0) as net_count
+FROM vote_events ve
+JOIN users u ON u.id = ve.user_id
+WHERE ve.user_id = ? AND ve.event_type = 'draft_toggle' AND u.guest_room = ''
+GROUP BY ve.movie_id, ve.movie_name
 HAVING net_count > 0
 ORDER BY net_count DESC
 `
@@ -67,6 +78,7 @@ type GetUserMovieDraftCountsRow struct {
 	NetCount  interface{} `json:"net_count"`
 }
 
+// Guests' picks only count once they convert to a full account
 func (q *Queries) GetUserMovieDraftCounts(ctx context.Context, userID int64) ([]GetUserMovieDraftCountsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getUserMovieDraftCounts, userID)
 	if err != nil {
@@ -92,12 +104,13 @@ func (q *Queries) GetUserMovieDraftCounts(ctx context.Context, userID int64) ([]
 
 const getUserMovieVoteCounts = `-- name: GetUserMovieVoteCounts :many
 SELECT
-  movie_id,
-  movie_name,
-  COALESCE(SUM(CASE WHEN action = 'selected' THEN 1 ELSE -1 END),0) as net_count
-FROM vote_events
-WHERE user_id = ? AND event_type = 'vote_toggle'
-GROUP BY movie_id, movie_name
+  ve.movie_id,
+  ve.movie_name,
+  COALESCE(SUM(CASE WHEN ve.action = 'selected' THEN 1 ELSE -1 END),0) as net_count
+FROM vote_events ve
+JOIN users u ON u.id = ve.user_id
+WHERE ve.user_id = ? AND ve.event_type = 'vote_toggle' AND u.guest_room = ''
+GROUP BY ve.movie_id, ve.movie_name
 HAVING net_count > 0
 ORDER BY net_count DESC
 `
@@ -108,6 +121,7 @@ type GetUserMovieVoteCountsRow struct {
 	NetCount  interface{} `json:"net_count"`
 }
 
+// Guests' picks only count once they convert to a full account
 func (q *Queries) GetUserMovieVoteCounts(ctx context.Context, userID int64) ([]GetUserMovieVoteCountsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getUserMovieVoteCounts, userID)
 	if err != nil {
@@ -0,0 +1,83 @@
+package announcement
+
+import (
+	"fmt"
+	"strings"
+
+	"watchma/pkg/movie"
+)
+
+const (
+	announcer = "Announcer"
+	critic    = "Critic"
+)
+
+// moodByGenre picks the fallback scene's mood, the first genre with an entry wins
+var moodByGenre = map[string]string{
+	"Horror":          "spooky",
+	"Comedy":          "funny",
+	"Animation":       "funny",
+	"Family":          "funny",
+	"Romance":         "romantic",
+	"Action":          "epic",
+	"Adventure":       "epic",
+	"Science Fiction": "epic",
+	"War":             "epic",
+	"Thriller":        "tense",
+	"Mystery":         "tense",
+	"Crime":           "tense",
+}
+
+// Fallback builds a scene from the movie's metadata, for when the LLM is unavailable
+// or keeps answering with something unusable. Like the LLM's scenes, it never says
+// the title.
+func Fallback(m movie.Movie) Script {
+	script := Script{
+		Mood:       "dramatic",
+		Characters: []string{announcer, critic},
+	}
+	for _, g := range m.Genres {
+		if mood, ok := moodByGenre[g]; ok {
+			script.Mood = mood
+			break
+		}
+	}
+
+	script.Lines = append(script.Lines, Line{Character: announcer, Text: describe(m)})
+
+	switch {
+	case m.CommunityRating > 0 && m.OfficialRating != "":
+		script.Lines = append(script.Lines, Line{Character: critic, Text: fmt.Sprintf(
+			"Rated %s, and %.1f out of 10 from the crowd. I would have voted for it too.", m.OfficialRating, m.CommunityRating)})
+	case m.CommunityRating > 0:
+		script.Lines = append(script.Lines, Line{Character: critic, Text: fmt.Sprintf(
+			"%.1f out of 10 from the crowd. I would have voted for it too.", m.CommunityRating)})
+	default:
+		script.Lines = append(script.Lines, Line{Character: critic, Text: "I have no notes. The people have spoken."})
+	}
+
+	script.Lines = append(script.Lines, Line{Character: announcer, Text: "No more hints. Dim the lights."})
+	return script
+}
+
+// describe is the announcer's opening line, as specific as the metadata allows
+func describe(m movie.Movie) string {
+	genres := strings.ToLower(joinWithAnd(m.Genres[:min(len(m.Genres), 2)]))
+	switch {
+	case m.ProductionYear > 0 && genres != "":
+		return fmt.Sprintf("Straight out of %d, this %s pick has won the room over.", m.ProductionYear, genres)
+	case m.ProductionYear > 0:
+		return fmt.Sprintf("Straight out of %d, tonight's pick has won the room over.", m.ProductionYear)
+	case genres != "":
+		return fmt.Sprintf("This %s pick has won the room over.", genres)
+	default:
+		return "The votes are counted and one movie has won the room over."
+	}
+}
+
+func joinWithAnd(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
@@ -0,0 +1,95 @@
+package announcement
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"watchma/pkg/llm"
+	"watchma/pkg/movie"
+)
+
+// Writer has the LLM write reveal scenes, repairing or replacing the ones it gets wrong
+type Writer struct {
+	llmProvider llm.Provider
+	logger      *slog.Logger
+}
+
+func NewWriter(llmProvider llm.Provider, logger *slog.Logger) *Writer {
+	return &Writer{
+		llmProvider: llmProvider,
+		logger:      logger,
+	}
+}
+
+// Write streams a scene for m in roomName, calling onPartial with the lines written so far. If the
+// response can't be used, the LLM is asked once to repair it. If that fails too the
+// metadata fallback is returned, so a scene is always played unless ctx is cancelled.
+func (w *Writer) Write(ctx context.Context, roomName string, m movie.Movie, onPartial func([]Line)) (Script, error) {
+	req := llm.Request{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: prompt(m.Name)}},
+		Schema:   &llm.JSONSchema{Name: "reveal_scene", Schema: schema},
+		Purpose:  llm.PurposeAnnouncement,
+		Room:     roomName,
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		var text strings.Builder
+		resp, err := w.llmProvider.Stream(ctx, req, func(delta string) {
+			text.WriteString(delta)
+			onPartial(ParsePartialLines(text.String()))
+		})
+		if ctx.Err() != nil {
+			return Script{}, ctx.Err()
+		}
+		if err != nil {
+			w.logger.Error("AI request failed", "backend", w.llmProvider.Name(), "attempt", attempt, "error", err)
+			continue
+		}
+
+		script, err := ParseScript(resp.Content, m.Name)
+		if err == nil {
+			return script, nil
+		}
+		w.logger.Warn("AI scene was invalid", "backend", w.llmProvider.Name(), "attempt", attempt, "error", err)
+
+		// Show the model what it wrote and what was wrong with it
+		req.Messages = append(req.Messages,
+			llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+			llm.Message{Role: llm.RoleUser, Content: repairPrompt(err)},
+		)
+	}
+
+	w.logger.Warn("Using the fallback scene", "movie", m.Name)
+	return Fallback(m), nil
+}
+
+func prompt(movieName string) string {
+	return fmt.Sprintf(`You are writing a reveal scene for: %s
+
+Reply with a JSON object with these fields:
+- "mood": the scene's mood, one of %s
+- "characters": the characters in the scene
+- "lines": the scene's dialogue, each line an object with "character" and "text"
+
+RULES:
+1. Use %d-%d characters from the movie
+2. Each character speaks 1-2 times, %d-%d lines in total
+3. Include character catchphrases naturally
+4. Build suspense without saying the movie title
+5. NO actor names, NO spoilers, NO narration, NO movie title
+6. Match the movie's genre/tone
+7. Keep your responses TERSE
+
+EXAMPLE (for a different movie):
+{"mood": "tense", "characters": ["Morpheus", "Trinity"], "lines": [
+  {"character": "Morpheus", "text": "What if I told you... we're the ones they chose?"},
+  {"character": "Trinity", "text": "The question isn't how, it's why."}
+]}`, movieName, strings.Join(Moods, ", "), minCharacters, maxCharacters, minLines, maxLines)
+}
+
+func repairPrompt(err error) string {
+	return fmt.Sprintf(`That scene can't be used: %s.
+Fix it and reply with only the corrected JSON object, following all the rules.`, err)
+}
@@ -0,0 +1,111 @@
+package announcement
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"watchma/pkg/llm"
+	"watchma/pkg/movie"
+)
+
+const validScene = `{"mood": "tense", "characters": ["Cobb", "Arthur"], "lines": [
+  {"character": "Cobb", "text": "An idea is like a virus."},
+  {"character": "Arthur", "text": "You mean we go deeper?"}
+]}`
+
+var inception = movie.Movie{Id: "movie-2", Name: "Inception", Genres: []string{"Action", "Sci-Fi"}, ProductionYear: 2010}
+
+func newTestWriter(fake *llm.Fake) *Writer {
+	return NewWriter(fake, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestWriteStreamsScene(t *testing.T) {
+	fake := llm.NewFake(validScene)
+	w := newTestWriter(fake)
+
+	var partials [][]Line
+	script, err := w.Write(context.Background(), "movienight", inception, func(lines []Line) {
+		partials = append(partials, lines)
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if script.Mood != "tense" || len(script.Lines) != 2 {
+		t.Errorf("script = %+v, want the tense two line scene", script)
+	}
+
+	if len(partials) == 0 {
+		t.Fatal("onPartial was never called")
+	}
+	if last := partials[len(partials)-1]; len(last) != 2 || last[1].Text != "You mean we go deeper?" {
+		t.Errorf("last partial = %+v, want both lines", last)
+	}
+
+	if len(fake.Requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(fake.Requests))
+	}
+	req := fake.Requests[0]
+	if req.Schema == nil || req.Purpose != llm.PurposeAnnouncement || req.Room != "movienight" {
+		t.Errorf("request = %+v, want a schema, the announcement purpose and the room", req)
+	}
+}
+
+func TestWriteRepairsInvalidScene(t *testing.T) {
+	spoiler := strings.Replace(validScene, "An idea is like a virus.", "Welcome to Inception.", 1)
+	fake := llm.NewFake(spoiler, validScene)
+	w := newTestWriter(fake)
+
+	script, err := w.Write(context.Background(), "movienight", inception, func([]Line) {})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if script.Lines[0].Text != "An idea is like a virus." {
+		t.Errorf("script = %+v, want the repaired scene", script)
+	}
+
+	if len(fake.Requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(fake.Requests))
+	}
+	repair := fake.Requests[1].Messages
+	if len(repair) != 3 {
+		t.Fatalf("repair request has %d messages, want the prompt, the bad scene and the repair prompt", len(repair))
+	}
+	if repair[1].Role != llm.RoleAssistant || repair[1].Content != spoiler {
+		t.Errorf("second message = %+v, want the invalid scene from the assistant", repair[1])
+	}
+	if !strings.Contains(repair[2].Content, "line 1 says the movie title") {
+		t.Errorf("repair prompt = %q, want it to say what was wrong", repair[2].Content)
+	}
+}
+
+func TestWriteFallsBackAfterRepairFails(t *testing.T) {
+	fake := llm.NewFake("Sorry, I can't help with that.")
+	w := newTestWriter(fake)
+
+	script, err := w.Write(context.Background(), "movienight", inception, func([]Line) {})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(fake.Requests) != 2 {
+		t.Errorf("requests = %d, want 2", len(fake.Requests))
+	}
+	fallback := Fallback(inception)
+	if script.Mood != fallback.Mood || len(script.Lines) != len(fallback.Lines) {
+		t.Errorf("script = %+v, want the fallback %+v", script, fallback)
+	}
+}
+
+func TestWriteStopsWhenCancelled(t *testing.T) {
+	fake := llm.NewFake(validScene)
+	w := newTestWriter(fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.Write(ctx, "movienight", inception, func([]Line) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,162 @@
+package announcement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Moods a scene can be written in
+var Moods = []string{"dramatic", "epic", "funny", "romantic", "spooky", "tense"}
+
+const (
+	minCharacters = 2
+	maxCharacters = 4
+	minLines      = 2
+	maxLines      = 8
+	maxLineLength = 300
+)
+
+// Script is the reveal scene played before the winner is shown
+type Script struct {
+	Mood       string   `json:"mood"`
+	Characters []string `json:"characters"`
+	Lines      []Line   `json:"lines"`
+}
+
+type Line struct {
+	Character string `json:"character"`
+	Text      string `json:"text"`
+}
+
+// schema is what the LLM is asked to answer with. Strict structured output needs every
+// property required and no additional properties.
+var schema = json.RawMessage(fmt.Sprintf(`{
+  "type": "object",
+  "properties": {
+    "mood": {"type": "string", "enum": %s},
+    "characters": {"type": "array", "items": {"type": "string"}},
+    "lines": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "character": {"type": "string"},
+          "text": {"type": "string"}
+        },
+        "required": ["character", "text"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["mood", "characters", "lines"],
+  "additionalProperties": false
+}`, mustMarshal(Moods)))
+
+func mustMarshal(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// ParseScript decodes the LLM's answer and checks it is a scene that can be played.
+// Models that ignore the schema often wrap the JSON in a markdown code fence, so
+// anything around the outermost object is dropped.
+func ParseScript(text, movieName string) (Script, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return Script{}, errors.New("response is not a JSON object")
+	}
+
+	var script Script
+	if err := json.Unmarshal([]byte(text[start:end+1]), &script); err != nil {
+		return Script{}, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if err := script.Validate(movieName); err != nil {
+		return Script{}, err
+	}
+	return script, nil
+}
+
+// Validate checks the scene follows the rules given in the prompt
+func (s Script) Validate(movieName string) error {
+	if !slices.Contains(Moods, s.Mood) {
+		return fmt.Errorf("mood %q must be one of %s", s.Mood, strings.Join(Moods, ", "))
+	}
+
+	if len(s.Characters) < minCharacters || len(s.Characters) > maxCharacters {
+		return fmt.Errorf("there must be %d to %d characters, got %d", minCharacters, maxCharacters, len(s.Characters))
+	}
+	for i, c := range s.Characters {
+		if strings.TrimSpace(c) == "" {
+			return errors.New("character names must not be empty")
+		}
+		if slices.Contains(s.Characters[:i], c) {
+			return fmt.Errorf("character %q is listed twice", c)
+		}
+	}
+
+	if len(s.Lines) < minLines || len(s.Lines) > maxLines {
+		return fmt.Errorf("there must be %d to %d lines, got %d", minLines, maxLines, len(s.Lines))
+	}
+	for i, l := range s.Lines {
+		if !slices.Contains(s.Characters, l.Character) {
+			return fmt.Errorf("line %d is spoken by %q, who is not in characters", i+1, l.Character)
+		}
+		if strings.TrimSpace(l.Text) == "" {
+			return fmt.Errorf("line %d is empty", i+1)
+		}
+		if len(l.Text) > maxLineLength {
+			return fmt.Errorf("line %d is longer than %d characters", i+1, maxLineLength)
+		}
+		if revealsTitle(l.Text, movieName) {
+			return fmt.Errorf("line %d says the movie title", i+1)
+		}
+	}
+	return nil
+}
+
+// revealsTitle reports whether text gives the movie away. Very short titles are
+// skipped, "Up" or "It" would match half of any scene.
+func revealsTitle(text, movieName string) bool {
+	if len(movieName) < 4 {
+		return false
+	}
+	return strings.Contains(strings.ToLower(text), strings.ToLower(movieName))
+}
+
+// partialLineRegex matches a line object in JSON that may still be being written, the
+// text may not be closed yet
+var partialLineRegex = regexp.MustCompile(`\{\s*"character"\s*:\s*"((?:[^"\\]|\\.)*)"\s*,\s*"text"\s*:\s*"((?:[^"\\]|\\.)*)`)
+
+// ParsePartialLines pulls the lines out of JSON that is still being streamed, so
+// players can watch the scene being written. Nothing is validated.
+func ParsePartialLines(text string) []Line {
+	var lines []Line
+	for _, match := range partialLineRegex.FindAllStringSubmatch(text, -1) {
+		lines = append(lines, Line{
+			Character: unescape(match[1]),
+			Text:      unescape(match[2]),
+		})
+	}
+	return lines
+}
+
+// unescape decodes a JSON string body that may have been cut off part way through an
+// escape sequence
+func unescape(s string) string {
+	for s != "" {
+		var out string
+		if err := json.Unmarshal([]byte(`"`+s+`"`), &out); err == nil {
+			return out
+		}
+		s = s[:len(s)-1]
+	}
+	return ""
+}
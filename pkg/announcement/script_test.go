@@ -0,0 +1,52 @@
+package announcement
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{"valid", validScene, ""},
+		{"code fence", "```json\n" + validScene + "\n```", ""},
+		{"not json", "Here is your scene!", "not a JSON object"},
+		{"bad mood", strings.Replace(validScene, "tense", "sleepy", 1), "mood"},
+		{"unknown character", strings.Replace(validScene, `"character": "Arthur"`, `"character": "Mal"`, 1), "not in characters"},
+		{"says title", strings.Replace(validScene, "go deeper", "go into inception", 1), "says the movie title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScript(tt.text, inception.Name)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseScript: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePartialLines(t *testing.T) {
+	partial := `{"mood": "tense", "characters": ["Cobb", "Arthur"], "lines": [
+  {"character": "Cobb", "text": "An idea is \"like\" a virus."},
+  {"character": "Arthur", "text": "You mean we`
+
+	lines := ParsePartialLines(partial)
+	if len(lines) != 2 {
+		t.Fatalf("lines = %+v, want 2", lines)
+	}
+	if lines[0].Text != `An idea is "like" a virus.` {
+		t.Errorf("first line = %q, want the escaped quotes decoded", lines[0].Text)
+	}
+	if lines[1].Character != "Arthur" || lines[1].Text != "You mean we" {
+		t.Errorf("second line = %+v, want the unfinished line so far", lines[1])
+	}
+}
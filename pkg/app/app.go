@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,10 +12,17 @@ import (
 	"watchma/db/sqlcgen"
 	"watchma/pkg/auth"
 	"watchma/pkg/buildinfo"
+	"watchma/pkg/embedding"
+	"watchma/pkg/host"
+	"watchma/pkg/images"
 	"watchma/pkg/jellyfin"
+	"watchma/pkg/llm"
 	"watchma/pkg/movie"
-	"watchma/pkg/openai"
+	"watchma/pkg/oidc"
+	"watchma/pkg/ratelimit"
+	"watchma/pkg/recommend"
 	"watchma/pkg/room"
+	"watchma/pkg/usage"
 	"watchma/web/router"
 
 	"github.com/go-chi/chi/v5"
@@ -71,42 +79,144 @@ func (a *App) Initialize() error {
 	a.DB = db
 	queries := sqlcgen.New(db.DB)
 
-	var openAiProvider *openai.Provider
-	if a.Settings.OpenAIApiKey != "" {
-		openAiProvider = openai.NewProvider(
-			a.Settings.OpenAIApiKey,
-			a.Logger,
-		)
+	// Every AI call goes through the tracker so it is counted against the budget
+	var usageTracker *usage.Tracker
+	if a.Settings.LLMBackend != "" || a.Settings.EmbeddingBackend != "" {
+		usageTracker = usage.NewTracker(queries, usage.Prices{
+			Prompt:     a.Settings.LLMPromptPrice,
+			Completion: a.Settings.LLMCompletionPrice,
+			Embedding:  a.Settings.EmbeddingPrice,
+		}, a.Settings.LLMMonthlyBudget, a.Logger)
+	}
+
+	var llmProvider llm.Provider
+	if a.Settings.LLMBackend != "" {
+		provider, err := llm.New(llm.Config{
+			Backend:     a.Settings.LLMBackend,
+			BaseURL:     a.Settings.LLMBaseURL,
+			APIKey:      a.Settings.LLMApiKey,
+			Model:       a.Settings.LLMModel,
+			Temperature: a.Settings.LLMTemperature,
+			MaxTokens:   a.Settings.LLMMaxTokens,
+			Timeout:     a.Settings.LLMTimeout,
+		}, a.Logger)
+		if err != nil {
+			return fmt.Errorf("initialize llm: %w", err)
+		}
+		llmProvider = usageTracker.Provider(provider)
 	}
 
 	var movieProvider movie.Provider
+	var imageSource images.Source
+	var jellyfinClient *jellyfin.Client
 	if a.Settings.JellyfinApiKey != "" {
-		movieProvider = movie.NewCachingProvider(
-			jellyfin.NewJellyfinMovieProvider(
-				a.Settings.JellyfinApiKey,
-				a.Settings.JellyfinBaseURL,
-				a.Logger),
-			time.Minute)
+		jellyfinClient = jellyfin.NewClient(
+			a.Settings.JellyfinBaseURL,
+			a.Settings.JellyfinApiKey,
+			a.Settings.JellyfinTimeout,
+			a.Logger)
+		jellyfinProvider := jellyfin.NewJellyfinMovieProvider(jellyfinClient, a.Logger)
+		movieProvider = jellyfinProvider
+		imageSource = jellyfinProvider
 	} else {
 		movieProvider = movie.NewDummyProvider()
+		imageSource = images.NewPlaceholderSource("./public/watchma.png")
+	}
+
+	imageCache, err := images.NewCache("./data/images", a.Settings.ImageCacheBytes, imageSource, a.Logger)
+	if err != nil {
+		return fmt.Errorf("initialize image cache: %w", err)
 	}
 
 	eventPublisher := room.NewEventPublisher(a.NATS, a.Logger)
-	authService := auth.NewAuthService(queries, a.Logger, a.Settings.IsDev)
-	movieService := movie.NewService(movieProvider, a.Logger)
+	authService := auth.NewAuthService(queries, db.DB, jellyfinClient, a.Logger, a.Settings.IsDev, a.Settings.JellyfinLoginOnly, a.Settings.AdminUsernames, a.Settings.RegistrationMode)
+	if a.Settings.OIDCIssuerURL != "" {
+		authService.UseOIDC(oidc.NewClient(oidc.Config{
+			IssuerURL:     a.Settings.OIDCIssuerURL,
+			ClientID:      a.Settings.OIDCClientID,
+			ClientSecret:  a.Settings.OIDCClientSecret,
+			RedirectURL:   a.Settings.OIDCRedirectURL,
+			Scopes:        a.Settings.OIDCScopes,
+			UsernameClaim: a.Settings.OIDCUsernameClaim,
+		}, a.Logger), a.Settings.OIDCProviderName)
+	}
+	// Already checked by validate
+	trustedProxies, _ := auth.ParseTrustedProxies(a.Settings.TrustedProxyCIDRs)
+	if a.Settings.TrustedProxyHeader != "" {
+		authService.UseProxyAuth(a.Settings.TrustedProxyHeader, trustedProxies)
+	}
+	if a.Settings.GuestPlayers {
+		authService.UseGuests()
+	}
+	if err := authService.BootstrapAdmins(context.Background()); err != nil {
+		return fmt.Errorf("bootstrap admins: %w", err)
+	}
+	movieService := movie.NewService(movieProvider, db.DB, a.Logger)
 	roomService := room.NewService(queries, eventPublisher, a.Logger)
+	// Guests stay a while after their game to see the results, and go with their room
+	roomService.OnGameFinished(func(ctx context.Context, roomName string) error {
+		return authService.ExpireRoomGuests(ctx, roomName, auth.GuestGracePeriod)
+	})
+	roomService.OnRoomDeleted(func(ctx context.Context, roomName string) error {
+		return authService.ExpireRoomGuests(ctx, roomName, 0)
+	})
+	recommender := recommend.NewService(queries, llmProvider, a.Logger)
+	hostService := host.NewService(queries, llmProvider, roomService, a.Settings.HostCommentInterval, a.Logger)
+
+	var vibeIndex *embedding.Index
+	if a.Settings.EmbeddingBackend != "" {
+		embedder, err := embedding.New(embedding.Config{
+			Backend: a.Settings.EmbeddingBackend,
+			BaseURL: a.Settings.EmbeddingBaseURL,
+			APIKey:  a.Settings.EmbeddingApiKey,
+			Model:   a.Settings.EmbeddingModel,
+			Timeout: a.Settings.EmbeddingTimeout,
+		}, a.Logger)
+		if err != nil {
+			return fmt.Errorf("initialize embeddings: %w", err)
+		}
+
+		// New and changed movies are embedded after each library sync
+		vibeIndex = embedding.NewIndex(usageTracker.Embedder(embedder), queries, a.Logger)
+		movieService.OnSync(vibeIndex.Sync)
+	}
+
+	// Expired sessions and guests are already refused, this only keeps the tables small
+	go authService.StartSessionCleanup(context.Background(), time.Hour)
+
+	// Library sync runs in the background, until it finishes the movie service
+	// reads straight from the provider
+	go movieService.StartSync(context.Background(), a.Settings.LibrarySyncInterval)
+
+	// Lockouts cap at an hour, so a forgotten password never locks someone out for days
+	loginLockout := ratelimit.NewLockout(a.Settings.LoginMaxAttempts, a.Settings.LoginLockout, time.Hour)
+	var chatLimiter, roomLimiter *ratelimit.Limiter
+	if a.Settings.ChatMessagesPerMinute > 0 {
+		chatLimiter = ratelimit.NewLimiter(a.Settings.ChatMessagesPerMinute, time.Minute)
+	}
+	if a.Settings.RoomsPerHour > 0 {
+		roomLimiter = ratelimit.NewLimiter(a.Settings.RoomsPerHour, time.Hour)
+	}
 
 	webHandler := router.NewWebHandler(
-		a.Settings.JellyfinBaseURL,
-		a.Settings.JellyfinApiKey,
 		a.Logger,
 		a.NATS,
 		queries,
 		&router.WebHandlerServices{
 			MovieService:   movieService,
 			RoomService:    roomService,
+			HostService:    hostService,
+			Recommender:    recommender,
+			VibeIndex:      vibeIndex,
 			AuthService:    authService,
-			OpenAiProvider: openAiProvider,
+			LLMProvider:    llmProvider,
+			UsageTracker:   usageTracker,
+			ImageCache:     imageCache,
+			JellyfinClient: jellyfinClient,
+			LoginLockout:   loginLockout,
+			ChatLimiter:    chatLimiter,
+			RoomLimiter:    roomLimiter,
+			TrustedProxies: trustedProxies,
 		},
 	)
 
@@ -153,17 +263,67 @@ func (a *App) Run() error {
 func (a *App) logConfig() {
 	fmt.Println("~~~~~Environment~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~")
 	a.Logger.Info("JELLYFIN_URL", "url", a.Settings.JellyfinBaseURL)
+	a.Logger.Info("JELLYFIN_TIMEOUT_SECONDS", "timeout", a.Settings.JellyfinTimeout)
+	a.Logger.Info("JELLYFIN_LOGIN_ONLY", "jellyfinLoginOnly", a.Settings.JellyfinLoginOnly)
 	if a.Settings.JellyfinApiKey != "" {
 		a.Logger.Info("JELLYFIN_API_KEY", "status", "loaded")
 	} else {
 		a.Logger.Warn("JELLYFIN_API_KEY", "status", "NOT FOUND -- Loading test data")
 	}
 
-	if a.Settings.OpenAIApiKey != "" {
-		a.Logger.Info("OPENAI_API_KEY", "status", "loaded")
+	if a.Settings.LLMBackend != "" {
+		a.Logger.Info("LLM_BACKEND", "backend", a.Settings.LLMBackend)
+		a.Logger.Info("LLM_BASE_URL", "url", a.Settings.LLMBaseURL)
+		a.Logger.Info("LLM_MODEL", "model", a.Settings.LLMModel)
+		a.Logger.Info("LLM_TEMPERATURE", "temperature", a.Settings.LLMTemperature)
+		a.Logger.Info("LLM_MAX_TOKENS", "maxTokens", a.Settings.LLMMaxTokens)
+		a.Logger.Info("LLM_TIMEOUT_SECONDS", "timeout", a.Settings.LLMTimeout)
+		a.Logger.Info("LLM_PRICES_PER_MILLION", "prompt", a.Settings.LLMPromptPrice, "completion", a.Settings.LLMCompletionPrice)
+		a.Logger.Info("LLM_MONTHLY_BUDGET_USD", "budget", a.Settings.LLMMonthlyBudget)
+		if a.Settings.LLMApiKey != "" {
+			a.Logger.Info("LLM_API_KEY", "status", "loaded")
+		}
+	} else {
+		a.Logger.Warn("LLM_BACKEND", "status", "NOT SET -- AI features disabled")
+	}
+	if a.Settings.EmbeddingBackend != "" {
+		a.Logger.Info("EMBEDDING_BACKEND", "backend", a.Settings.EmbeddingBackend)
+		a.Logger.Info("EMBEDDING_BASE_URL", "url", a.Settings.EmbeddingBaseURL)
+		a.Logger.Info("EMBEDDING_MODEL", "model", a.Settings.EmbeddingModel)
+		a.Logger.Info("EMBEDDING_TIMEOUT_SECONDS", "timeout", a.Settings.EmbeddingTimeout)
+		a.Logger.Info("EMBEDDING_PRICE_PER_MILLION", "price", a.Settings.EmbeddingPrice)
+	} else {
+		a.Logger.Info("EMBEDDING_BACKEND", "status", "NOT SET -- vibe search disabled")
+	}
+	a.Logger.Info("HOST_COMMENT_INTERVAL_SECONDS", "interval", a.Settings.HostCommentInterval)
+	a.Logger.Info("ADMIN_USERNAMES", "admins", a.Settings.AdminUsernames)
+	a.Logger.Info("REGISTRATION_MODE", "mode", a.Settings.RegistrationMode)
+	a.Logger.Info("GUEST_PLAYERS", "guestPlayers", a.Settings.GuestPlayers)
+	a.Logger.Info("LOGIN_MAX_ATTEMPTS", "attempts", a.Settings.LoginMaxAttempts)
+	a.Logger.Info("LOGIN_LOCKOUT_SECONDS", "lockout", a.Settings.LoginLockout)
+	a.Logger.Info("CHAT_MESSAGES_PER_MINUTE", "limit", a.Settings.ChatMessagesPerMinute)
+	a.Logger.Info("ROOMS_PER_HOUR", "limit", a.Settings.RoomsPerHour)
+	if a.Settings.OIDCIssuerURL != "" {
+		a.Logger.Info("OIDC_ISSUER_URL", "url", a.Settings.OIDCIssuerURL)
+		a.Logger.Info("OIDC_CLIENT_ID", "clientID", a.Settings.OIDCClientID)
+		a.Logger.Info("OIDC_REDIRECT_URL", "url", a.Settings.OIDCRedirectURL)
+		a.Logger.Info("OIDC_SCOPES", "scopes", a.Settings.OIDCScopes)
+		a.Logger.Info("OIDC_USERNAME_CLAIM", "claim", a.Settings.OIDCUsernameClaim)
+		a.Logger.Info("OIDC_PROVIDER_NAME", "name", a.Settings.OIDCProviderName)
+		if a.Settings.OIDCClientSecret != "" {
+			a.Logger.Info("OIDC_CLIENT_SECRET", "status", "loaded")
+		}
+	} else {
+		a.Logger.Info("OIDC_ISSUER_URL", "status", "NOT SET -- single sign-on disabled")
+	}
+	if a.Settings.TrustedProxyHeader != "" {
+		a.Logger.Info("TRUSTED_PROXY_HEADER", "header", a.Settings.TrustedProxyHeader)
+		a.Logger.Info("TRUSTED_PROXY_CIDRS", "cidrs", a.Settings.TrustedProxyCIDRs)
 	} else {
-		a.Logger.Warn("OPENAI_API_KEY", "status", "NOT FOUND -- AI features disabled")
+		a.Logger.Info("TRUSTED_PROXY_HEADER", "status", "NOT SET -- proxy auth disabled")
 	}
+	a.Logger.Info("LIBRARY_SYNC_MINUTES", "interval", a.Settings.LibrarySyncInterval)
+	a.Logger.Info("IMAGE_CACHE_MB", "bytes", a.Settings.ImageCacheBytes)
 	a.Logger.Info("PORT", "port", a.Settings.Port)
 	a.Logger.Info("LOG_LEVEL", "level", a.Settings.LogLevel)
 	a.Logger.Info("IS_DEV", "isDev", a.Settings.IsDev)
@@ -3,9 +3,17 @@ package app
 import (
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"watchma/pkg/auth"
+	"watchma/pkg/embedding"
+	"watchma/pkg/llm"
+	"watchma/pkg/oidc"
 
 	"github.com/joho/godotenv"
 )
@@ -13,19 +21,116 @@ import (
 const (
 	JELLYFIN_API_KEY  = "JELLYFIN_API_KEY"
 	JELLYFIN_BASE_URL = "JELLYFIN_BASE_URL"
+	JELLYFIN_TIMEOUT  = "JELLYFIN_TIMEOUT_SECONDS"
+	JELLYFIN_LOGIN    = "JELLYFIN_LOGIN_ONLY"
 	OPENAI_API_KEY    = "OPENAI_API_KEY"
-	PORT              = "PORT"
-	LOG_LEVEL         = "LOG_LEVEL"
-	IS_DEV            = "IS_DEV"
+
+	LLM_BACKEND          = "LLM_BACKEND"
+	LLM_BASE_URL         = "LLM_BASE_URL"
+	LLM_API_KEY          = "LLM_API_KEY"
+	LLM_MODEL            = "LLM_MODEL"
+	LLM_TEMPERATURE      = "LLM_TEMPERATURE"
+	LLM_MAX_TOKENS       = "LLM_MAX_TOKENS"
+	LLM_TIMEOUT_SECONDS  = "LLM_TIMEOUT_SECONDS"
+	LLM_PROMPT_PRICE     = "LLM_PROMPT_PRICE_PER_MILLION"
+	LLM_COMPLETION_PRICE = "LLM_COMPLETION_PRICE_PER_MILLION"
+	LLM_MONTHLY_BUDGET   = "LLM_MONTHLY_BUDGET_USD"
+	EMBEDDING_BACKEND    = "EMBEDDING_BACKEND"
+	EMBEDDING_BASE_URL   = "EMBEDDING_BASE_URL"
+	EMBEDDING_API_KEY    = "EMBEDDING_API_KEY"
+	EMBEDDING_MODEL      = "EMBEDDING_MODEL"
+	EMBEDDING_TIMEOUT    = "EMBEDDING_TIMEOUT_SECONDS"
+	EMBEDDING_PRICE      = "EMBEDDING_PRICE_PER_MILLION"
+	PORT                 = "PORT"
+	LOG_LEVEL            = "LOG_LEVEL"
+	IS_DEV               = "IS_DEV"
+
+	LIBRARY_SYNC_MINUTES = "LIBRARY_SYNC_MINUTES"
+	IMAGE_CACHE_MB       = "IMAGE_CACHE_MB"
+
+	HOST_COMMENT_INTERVAL = "HOST_COMMENT_INTERVAL_SECONDS"
+	ADMIN_USERNAMES       = "ADMIN_USERNAMES"
+	REGISTRATION_MODE     = "REGISTRATION_MODE"
+	GUEST_PLAYERS         = "GUEST_PLAYERS"
+
+	LOGIN_MAX_ATTEMPTS       = "LOGIN_MAX_ATTEMPTS"
+	LOGIN_LOCKOUT_SECONDS    = "LOGIN_LOCKOUT_SECONDS"
+	CHAT_MESSAGES_PER_MINUTE = "CHAT_MESSAGES_PER_MINUTE"
+	ROOMS_PER_HOUR           = "ROOMS_PER_HOUR"
+
+	OIDC_ISSUER_URL     = "OIDC_ISSUER_URL"
+	OIDC_CLIENT_ID      = "OIDC_CLIENT_ID"
+	OIDC_CLIENT_SECRET  = "OIDC_CLIENT_SECRET"
+	OIDC_REDIRECT_URL   = "OIDC_REDIRECT_URL"
+	OIDC_SCOPES         = "OIDC_SCOPES"
+	OIDC_USERNAME_CLAIM = "OIDC_USERNAME_CLAIM"
+	OIDC_PROVIDER_NAME  = "OIDC_PROVIDER_NAME"
+
+	TRUSTED_PROXY_HEADER = "TRUSTED_PROXY_HEADER"
+	TRUSTED_PROXY_CIDRS  = "TRUSTED_PROXY_CIDRS"
 )
 
 type Settings struct {
 	// Don't log the api keys
 	JellyfinApiKey  string `json:"-"` // Exclude from JSON Marshalling
 	JellyfinBaseURL string
-	UseDummyData    bool // Use dummy data when Jellyfin credentials not available
+	UseDummyData    bool          // Use dummy data when Jellyfin credentials not available
+	JellyfinTimeout time.Duration // Timeout for each individual request to Jellyfin
+	// Only allow signing in with Jellyfin accounts, local accounts are turned off
+	JellyfinLoginOnly bool
+
+	LibrarySyncInterval time.Duration // How often the local movie library is synced with the provider
+	ImageCacheBytes     int64         // Size cap of the resized poster cache in ./data/images
+
+	// LLM backend for AI features, empty when AI is disabled
+	LLMBackend     string
+	LLMBaseURL     string // Empty uses the backend's default
+	LLMApiKey      string `json:"-"` // Exclude from JSON Marshalling
+	LLMModel       string // Empty uses the backend's default
+	LLMTemperature float64
+	LLMMaxTokens   int
+	LLMTimeout     time.Duration
+	// USD per million tokens, used to estimate what each call cost
+	LLMPromptPrice     float64
+	LLMCompletionPrice float64
+	LLMMonthlyBudget   float64 // USD, AI features fall back to canned content past it. 0 is unlimited
 
-	OpenAIApiKey string `json:"-"` // Exclude from JSON Marshalling
+	// Embedding backend for vibe search in the draft, empty when it is disabled
+	EmbeddingBackend string
+	EmbeddingBaseURL string // Empty uses the backend's default
+	EmbeddingApiKey  string `json:"-"` // Exclude from JSON Marshalling
+	EmbeddingModel   string // Empty uses the backend's default
+	EmbeddingTimeout time.Duration
+	EmbeddingPrice   float64 // USD per million tokens, counted against LLMMonthlyBudget
+
+	HostCommentInterval time.Duration // Minimum gap between host comments in a single room
+	AdminUsernames      []string      // Users made admin at startup, or when they sign up
+	// Who can create an account: open, invite or closed. Admins can change it at runtime
+	RegistrationMode string
+	// Let people join a room from its link as a guest, without an account
+	GuestPlayers bool
+
+	// Failed sign ins allowed per IP and per username before they are locked out. Each
+	// further failure doubles the lockout, starting at LoginLockout
+	LoginMaxAttempts int
+	LoginLockout     time.Duration
+	// Rate limits per user, 0 turns the limit off
+	ChatMessagesPerMinute int
+	RoomsPerHour          int
+
+	// OpenID Connect single sign-on, off when OIDCIssuerURL is empty
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string `json:"-"` // Exclude from JSON Marshalling
+	OIDCRedirectURL   string // This server's /login/oidc/callback
+	OIDCScopes        []string
+	OIDCUsernameClaim string // Claim that becomes the username
+	OIDCProviderName  string // Label on the login button
+
+	// Header a trusted reverse proxy names the signed in user with, empty when proxy auth
+	// is off. Only requests from TrustedProxyCIDRs may use it
+	TrustedProxyHeader string
+	TrustedProxyCIDRs  []string
 
 	Port     int
 	LogLevel slog.Level
@@ -39,17 +144,71 @@ func LoadSettings() *Settings {
 
 	config := &Settings{
 		// Once again, don't log the api keys!
-		JellyfinApiKey:  os.Getenv(JELLYFIN_API_KEY),
-		JellyfinBaseURL: strings.TrimSuffix(os.Getenv(JELLYFIN_BASE_URL), "/"),
-		UseDummyData:    os.Getenv(JELLYFIN_API_KEY) == "" || os.Getenv(JELLYFIN_BASE_URL) == "",
-		LogLevel:        parseLogLevel(os.Getenv(LOG_LEVEL)),
+		JellyfinApiKey:    os.Getenv(JELLYFIN_API_KEY),
+		JellyfinBaseURL:   strings.TrimSuffix(os.Getenv(JELLYFIN_BASE_URL), "/"),
+		UseDummyData:      os.Getenv(JELLYFIN_API_KEY) == "" || os.Getenv(JELLYFIN_BASE_URL) == "",
+		JellyfinTimeout:   time.Duration(getEnvAsInt(JELLYFIN_TIMEOUT, 15)) * time.Second,
+		JellyfinLoginOnly: strings.ToLower(os.Getenv(JELLYFIN_LOGIN)) == "true",
+		LogLevel:          parseLogLevel(os.Getenv(LOG_LEVEL)),
+
+		LibrarySyncInterval: time.Duration(getEnvAsInt(LIBRARY_SYNC_MINUTES, 15)) * time.Minute,
+		ImageCacheBytes:     int64(getEnvAsInt(IMAGE_CACHE_MB, 256)) * 1024 * 1024,
+
+		LLMBackend:       llmBackend(),
+		LLMBaseURL:       os.Getenv(LLM_BASE_URL),
+		LLMApiKey:        getEnvOr(LLM_API_KEY, os.Getenv(OPENAI_API_KEY)),
+		LLMModel:         os.Getenv(LLM_MODEL),
+		LLMTemperature:   getEnvAsFloat(LLM_TEMPERATURE, 1),
+		LLMMaxTokens:     getEnvAsInt(LLM_MAX_TOKENS, 512),
+		LLMTimeout:       time.Duration(getEnvAsInt(LLM_TIMEOUT_SECONDS, 60)) * time.Second,
+		LLMMonthlyBudget: getEnvAsFloat(LLM_MONTHLY_BUDGET, 0),
+
+		EmbeddingBackend: strings.ToLower(os.Getenv(EMBEDDING_BACKEND)),
+		EmbeddingBaseURL: os.Getenv(EMBEDDING_BASE_URL),
+		EmbeddingApiKey:  getEnvOr(EMBEDDING_API_KEY, getEnvOr(LLM_API_KEY, os.Getenv(OPENAI_API_KEY))),
+		EmbeddingModel:   os.Getenv(EMBEDDING_MODEL),
+		EmbeddingTimeout: time.Duration(getEnvAsInt(EMBEDDING_TIMEOUT, 30)) * time.Second,
+
+		HostCommentInterval: time.Duration(getEnvAsInt(HOST_COMMENT_INTERVAL, 15)) * time.Second,
+		AdminUsernames:      getEnvAsList(ADMIN_USERNAMES),
+		RegistrationMode:    strings.ToLower(getEnvOr(REGISTRATION_MODE, auth.RegistrationOpen)),
+		GuestPlayers:        strings.ToLower(os.Getenv(GUEST_PLAYERS)) != "false",
 
-		OpenAIApiKey: os.Getenv(OPENAI_API_KEY),
+		LoginMaxAttempts:      getEnvAsInt(LOGIN_MAX_ATTEMPTS, 5),
+		LoginLockout:          time.Duration(getEnvAsInt(LOGIN_LOCKOUT_SECONDS, 30)) * time.Second,
+		ChatMessagesPerMinute: getEnvAsInt(CHAT_MESSAGES_PER_MINUTE, 30),
+		RoomsPerHour:          getEnvAsInt(ROOMS_PER_HOUR, 10),
+
+		OIDCIssuerURL:     strings.TrimSuffix(os.Getenv(OIDC_ISSUER_URL), "/"),
+		OIDCClientID:      os.Getenv(OIDC_CLIENT_ID),
+		OIDCClientSecret:  os.Getenv(OIDC_CLIENT_SECRET),
+		OIDCRedirectURL:   os.Getenv(OIDC_REDIRECT_URL),
+		OIDCScopes:        getEnvAsList(OIDC_SCOPES),
+		OIDCUsernameClaim: getEnvOr(OIDC_USERNAME_CLAIM, oidc.DefaultUsernameClaim),
+		OIDCProviderName:  getEnvOr(OIDC_PROVIDER_NAME, "SSO"),
+
+		TrustedProxyHeader: os.Getenv(TRUSTED_PROXY_HEADER),
+		TrustedProxyCIDRs:  getEnvAsList(TRUSTED_PROXY_CIDRS),
 
 		Port:  getEnvAsInt(PORT, 58008),
 		IsDev: strings.ToLower(os.Getenv(IS_DEV)) == "true",
 	}
 
+	// Default to gpt-4o-mini's prices on OpenAI, local backends are free
+	if config.LLMBackend == llm.BackendOpenAI {
+		config.LLMPromptPrice = getEnvAsFloat(LLM_PROMPT_PRICE, 0.15)
+		config.LLMCompletionPrice = getEnvAsFloat(LLM_COMPLETION_PRICE, 0.60)
+	} else {
+		config.LLMPromptPrice = getEnvAsFloat(LLM_PROMPT_PRICE, 0)
+		config.LLMCompletionPrice = getEnvAsFloat(LLM_COMPLETION_PRICE, 0)
+	}
+	// text-embedding-3-small's price
+	if config.EmbeddingBackend == embedding.BackendOpenAI {
+		config.EmbeddingPrice = getEnvAsFloat(EMBEDDING_PRICE, 0.02)
+	} else {
+		config.EmbeddingPrice = getEnvAsFloat(EMBEDDING_PRICE, 0)
+	}
+
 	if err := config.validate(); err != nil {
 		slog.Error("Configuration validation failed", "error", err)
 		os.Exit(1)
@@ -69,12 +228,123 @@ func (a *Settings) validate() error {
 			return fmt.Errorf("required environment variable %s is not set", JELLYFIN_BASE_URL)
 		}
 	}
+	if a.JellyfinTimeout < time.Second {
+		return fmt.Errorf("invalid %s: must be at least 1", JELLYFIN_TIMEOUT)
+	}
+	if a.LibrarySyncInterval < time.Minute {
+		return fmt.Errorf("invalid %s: must be at least 1", LIBRARY_SYNC_MINUTES)
+	}
+	if a.ImageCacheBytes < 1 {
+		return fmt.Errorf("invalid %s: must be at least 1", IMAGE_CACHE_MB)
+	}
+	switch a.LLMBackend {
+	case "", llm.BackendOpenAI, llm.BackendOllama, llm.BackendFake:
+	default:
+		return fmt.Errorf("invalid %s %q: must be one of %s, %s, %s", LLM_BACKEND, a.LLMBackend, llm.BackendOpenAI, llm.BackendOllama, llm.BackendFake)
+	}
+	if a.LLMTemperature < 0 || a.LLMTemperature > 2 {
+		return fmt.Errorf("invalid %s: must be between 0 and 2", LLM_TEMPERATURE)
+	}
+	if a.LLMMaxTokens < 1 {
+		return fmt.Errorf("invalid %s: must be at least 1", LLM_MAX_TOKENS)
+	}
+	if a.LLMTimeout < time.Second {
+		return fmt.Errorf("invalid %s: must be at least 1", LLM_TIMEOUT_SECONDS)
+	}
+	if a.LLMPromptPrice < 0 || a.LLMCompletionPrice < 0 {
+		return fmt.Errorf("invalid %s or %s: must not be negative", LLM_PROMPT_PRICE, LLM_COMPLETION_PRICE)
+	}
+	if a.LLMMonthlyBudget < 0 {
+		return fmt.Errorf("invalid %s: must not be negative", LLM_MONTHLY_BUDGET)
+	}
+	switch a.EmbeddingBackend {
+	case "", embedding.BackendOpenAI, embedding.BackendFake:
+	default:
+		return fmt.Errorf("invalid %s %q: must be one of %s, %s", EMBEDDING_BACKEND, a.EmbeddingBackend, embedding.BackendOpenAI, embedding.BackendFake)
+	}
+	if a.EmbeddingTimeout < time.Second {
+		return fmt.Errorf("invalid %s: must be at least 1", EMBEDDING_TIMEOUT)
+	}
+	if a.EmbeddingPrice < 0 {
+		return fmt.Errorf("invalid %s: must not be negative", EMBEDDING_PRICE)
+	}
+	if a.HostCommentInterval < 0 {
+		return fmt.Errorf("invalid %s: must not be negative", HOST_COMMENT_INTERVAL)
+	}
+	if !auth.ValidRegistrationMode(a.RegistrationMode) {
+		return fmt.Errorf("invalid %s %q: must be one of %s, %s, %s", REGISTRATION_MODE, a.RegistrationMode, auth.RegistrationOpen, auth.RegistrationInvite, auth.RegistrationClosed)
+	}
+	if a.LoginMaxAttempts < 1 {
+		return fmt.Errorf("invalid %s: must be at least 1", LOGIN_MAX_ATTEMPTS)
+	}
+	if a.LoginLockout < time.Second {
+		return fmt.Errorf("invalid %s: must be at least 1", LOGIN_LOCKOUT_SECONDS)
+	}
+	if a.ChatMessagesPerMinute < 0 || a.RoomsPerHour < 0 {
+		return fmt.Errorf("invalid %s or %s: must not be negative", CHAT_MESSAGES_PER_MINUTE, ROOMS_PER_HOUR)
+	}
+	if a.OIDCIssuerURL != "" {
+		if a.OIDCClientID == "" {
+			return fmt.Errorf("%s is required when %s is set", OIDC_CLIENT_ID, OIDC_ISSUER_URL)
+		}
+		if u, err := url.Parse(a.OIDCRedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid %s %q: must be the full URL of /login/oidc/callback", OIDC_REDIRECT_URL, a.OIDCRedirectURL)
+		}
+		if len(a.OIDCScopes) > 0 && !slices.Contains(a.OIDCScopes, "openid") {
+			return fmt.Errorf("invalid %s: must include openid", OIDC_SCOPES)
+		}
+	}
+	if a.TrustedProxyHeader != "" && len(a.TrustedProxyCIDRs) == 0 {
+		return fmt.Errorf("%s is required when %s is set", TRUSTED_PROXY_CIDRS, TRUSTED_PROXY_HEADER)
+	}
+	if _, err := auth.ParseTrustedProxies(a.TrustedProxyCIDRs); err != nil {
+		return fmt.Errorf("invalid %s: %w", TRUSTED_PROXY_CIDRS, err)
+	}
 	if a.Port < 1 || a.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", a.Port)
 	}
 	return nil
 }
 
+// llmBackend reads LLM_BACKEND. For older configs without it, an OPENAI_API_KEY on its
+// own still turns on the OpenAI backend.
+func llmBackend() string {
+	if backend := os.Getenv(LLM_BACKEND); backend != "" {
+		return strings.ToLower(backend)
+	}
+	if os.Getenv(OPENAI_API_KEY) != "" || os.Getenv(LLM_API_KEY) != "" {
+		return llm.BackendOpenAI
+	}
+	return ""
+}
+
+func getEnvOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsList splits a comma separated variable, dropping empty entries
+func getEnvAsList(key string) []string {
+	var list []string
+	for item := range strings.SplitSeq(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"watchma/db/sqlcgen"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrWrongPassword = errors.New("current password is incorrect")
+	// ErrNoLocalPassword is returned for accounts that sign in through Jellyfin or OIDC
+	ErrNoLocalPassword = errors.New("this account signs in with Jellyfin or single sign-on")
+	// ErrLastAdmin stops the only admin from locking everyone out of the admin pages
+	ErrLastAdmin   = errors.New("the last admin can't be removed")
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+// ChangePassword replaces the user's password after checking their current one. The
+// new password must already have passed the password rules.
+func (s *AuthService) ChangePassword(ctx context.Context, user *sqlcgen.User, current, next string) error {
+	if user.PasswordHash == "" {
+		return ErrNoLocalPassword
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(current)); err != nil {
+		return ErrWrongPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(next), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	if err := s.queries.UpdateUserPassword(ctx, sqlcgen.UpdateUserPasswordParams{
+		PasswordHash: string(hash),
+		ID:           user.ID,
+	}); err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+
+	s.logger.Info("Password changed", "username", user.Username)
+	return nil
+}
+
+// ListUsers returns every account, for the admin users page
+func (s *AuthService) ListUsers(ctx context.Context) ([]sqlcgen.User, error) {
+	return s.queries.ListUsers(ctx)
+}
+
+// SetRole changes a user's role. The last admin can't be demoted, and guests can't be
+// made admin.
+func (s *AuthService) SetRole(ctx context.Context, userID int64, role string) error {
+	if role != RoleUser && role != RoleAdmin {
+		return ErrInvalidRole
+	}
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if IsGuest(&user) && role == RoleAdmin {
+		return ErrInvalidRole
+	}
+	if user.Role == RoleAdmin && role != RoleAdmin {
+		if err := s.checkNotLastAdmin(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := s.queries.SetUserRole(ctx, sqlcgen.SetUserRoleParams{
+		Role: role,
+		ID:   userID,
+	}); err != nil {
+		return fmt.Errorf("set role: %w", err)
+	}
+
+	s.logger.Info("User role changed", "username", user.Username, "role", role)
+	return nil
+}
+
+// DeleteAccount removes a user along with their sessions, Jellyfin and OIDC links, room
+// presets, watchlist and imported lists, vote history, game participation and invite
+// redemption. Foreign keys aren't enforced in SQLite here, so the cascade is done by
+// hand in one transaction.
+func (s *AuthService) DeleteAccount(ctx context.Context, userID int64) error {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user.Role == RoleAdmin {
+		if err := s.checkNotLastAdmin(ctx); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := s.queries.WithTx(tx)
+	if err := qtx.DeleteVoteEventsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete vote events: %w", err)
+	}
+	if err := qtx.DeleteGameParticipantsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete game participants: %w", err)
+	}
+	if err := qtx.DeleteInviteRedemptionsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete invite redemptions: %w", err)
+	}
+	if err := qtx.DeleteJellyfinAccount(ctx, userID); err != nil {
+		return fmt.Errorf("delete jellyfin account: %w", err)
+	}
+	if err := qtx.DeleteOIDCAccountsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete oidc accounts: %w", err)
+	}
+	if err := qtx.DeleteRoomPresetsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete room presets: %w", err)
+	}
+	if err := qtx.DeleteWatchlistItemsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete watchlist: %w", err)
+	}
+	if err := qtx.DeleteMovieListItemsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete movie list items: %w", err)
+	}
+	if err := qtx.DeleteMovieListsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete movie lists: %w", err)
+	}
+	if err := qtx.DeleteSessionsByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("delete sessions: %w", err)
+	}
+	if err := qtx.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete transaction: %w", err)
+	}
+
+	s.logger.Info("Account deleted", "username", user.Username)
+	return nil
+}
+
+func (s *AuthService) checkNotLastAdmin(ctx context.Context) error {
+	admins, err := s.queries.CountAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("count admins: %w", err)
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"watchma/db/sqlcgen"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// GuestLifetime caps how long a guest lasts, even if their game never finishes
+	GuestLifetime = 12 * time.Hour
+	// GuestGracePeriod is how long guests stay after their game ends, long enough to see
+	// the results and convert to a full account
+	GuestGracePeriod = 30 * time.Minute
+	// GuestSuffix is added to a guest's name, so the lobby shows who is a guest and
+	// guests can't pass for someone with an account
+	GuestSuffix = " (guest)"
+	// Longest name a guest can pick, before the suffix
+	maxGuestNameLength = 24
+)
+
+var (
+	ErrGuestsDisabled   = errors.New("Guests can't join rooms on this server")
+	ErrInvalidGuestName = errors.New("Pick a name of up to 24 letters, numbers or spaces")
+	ErrGuestNameTaken   = errors.New("Someone is already using that name, pick another")
+	ErrUsernameTaken    = errors.New("That username is taken")
+	ErrInvalidUsername  = errors.New("Usernames can't end in (guest)")
+	ErrNotGuest         = errors.New("only guests can convert to a full account")
+)
+
+// checkUsername refuses names for full accounts that would pass for a guest, every way
+// of creating an account goes through it
+func checkUsername(username string) error {
+	if username == "" || strings.HasSuffix(username, GuestSuffix) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+// IsGuest reports whether user joined a single room as a guest
+func IsGuest(user *sqlcgen.User) bool {
+	return user != nil && user.GuestRoom != ""
+}
+
+// UseGuests lets people join a room from its link without creating an account
+func (s *AuthService) UseGuests() {
+	s.guests = true
+}
+
+// GuestsEnabled reports whether rooms can be joined as a guest
+func (s *AuthService) GuestsEnabled() bool {
+	return s.guests
+}
+
+// JoinAsGuest creates a guest who can only play in roomName and starts their session.
+// Guests have no password, so the registration mode doesn't apply, the room's link is
+// the invite.
+func (s *AuthService) JoinAsGuest(ctx context.Context, roomName, name, userAgent string) (*sqlcgen.User, string, error) {
+	if !s.guests {
+		return nil, "", ErrGuestsDisabled
+	}
+	name, ok := validGuestName(name)
+	if !ok {
+		return nil, "", ErrInvalidGuestName
+	}
+
+	username := name + GuestSuffix
+	user, err := s.queries.CreateGuestUser(ctx, sqlcgen.CreateGuestUserParams{
+		Username:  username,
+		GuestRoom: roomName,
+		ExpiresAt: time.Now().UTC().Add(GuestLifetime).Truncate(time.Second),
+	})
+	if err != nil {
+		if _, getErr := s.queries.GetUserByUsername(ctx, username); getErr == nil {
+			return nil, "", ErrGuestNameTaken
+		}
+		return nil, "", fmt.Errorf("create guest: %w", err)
+	}
+
+	token, err := s.createSession(ctx, user.ID, userAgent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("Guest joined", "username", user.Username, "room", roomName)
+	return &user, token, nil
+}
+
+// ConvertGuest turns a guest into a full account with a username and password, keeping
+// their votes and games. Unlike joining as a guest this creates an account, so the
+// registration mode applies. The password must already have passed the password rules.
+func (s *AuthService) ConvertGuest(ctx context.Context, guest *sqlcgen.User, username, password, inviteCode string) error {
+	if !IsGuest(guest) {
+		return ErrNotGuest
+	}
+	username = strings.TrimSpace(username)
+	if err := checkUsername(username); err != nil {
+		return err
+	}
+	if s.JellyfinOnly {
+		return ErrRegistrationClosed
+	}
+
+	mode, err := s.RegistrationMode(ctx)
+	if err != nil {
+		return err
+	}
+	if mode != RegistrationOpen && mode != RegistrationInvite {
+		return ErrRegistrationClosed
+	}
+
+	if _, err := s.queries.GetUserByUsername(ctx, username); err == nil {
+		return ErrUsernameTaken
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin convert transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	var inviteID int64
+	if mode == RegistrationInvite {
+		if inviteID, err = redeemInvite(ctx, qtx, inviteCode); err != nil {
+			return err
+		}
+	}
+
+	if err := qtx.ConvertGuestUser(ctx, sqlcgen.ConvertGuestUserParams{
+		Username:     username,
+		PasswordHash: string(hash),
+		ID:           guest.ID,
+	}); err != nil {
+		return fmt.Errorf("convert guest: %w", err)
+	}
+
+	if inviteID != 0 {
+		if err := qtx.CreateInviteRedemption(ctx, sqlcgen.CreateInviteRedemptionParams{
+			InviteCodeID: inviteID,
+			UserID:       guest.ID,
+		}); err != nil {
+			return fmt.Errorf("record invite redemption: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit convert transaction: %w", err)
+	}
+
+	s.logger.Info("Guest converted to a full account", "guest", guest.Username, "username", username)
+	guest.Username = username
+	guest.PasswordHash = string(hash)
+	guest.GuestRoom = ""
+	guest.GuestExpiresAt = sql.NullTime{}
+	return s.promoteBootstrapAdmin(ctx, guest)
+}
+
+// ExpireRoomGuests ends the guests of roomName after the given delay, or sooner if they
+// were already due to expire. Their sessions stop working at that point.
+func (s *AuthService) ExpireRoomGuests(ctx context.Context, roomName string, after time.Duration) error {
+	if err := s.queries.ExpireRoomGuests(ctx, sqlcgen.ExpireRoomGuestsParams{
+		ExpiresAt: time.Now().UTC().Add(after).Truncate(time.Second),
+		Room:      roomName,
+	}); err != nil {
+		return fmt.Errorf("expire guests of %s: %w", roomName, err)
+	}
+	return nil
+}
+
+// MoveGuest lets a guest play in roomName instead of the room they joined, when their
+// room plays again. Their lifetime starts over, it's a new game.
+func (s *AuthService) MoveGuest(ctx context.Context, guest *sqlcgen.User, roomName string) error {
+	expiresAt := time.Now().UTC().Add(GuestLifetime).Truncate(time.Second)
+	if err := s.queries.MoveGuest(ctx, sqlcgen.MoveGuestParams{
+		GuestRoom: roomName,
+		ExpiresAt: expiresAt,
+		ID:        guest.ID,
+	}); err != nil {
+		return fmt.Errorf("move guest %s to %s: %w", guest.Username, roomName, err)
+	}
+	guest.GuestRoom = roomName
+	guest.GuestExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+	return nil
+}
+
+// deleteExpiredGuests removes expired guests along with everything they did
+func (s *AuthService) deleteExpiredGuests(ctx context.Context) (int, error) {
+	ids, err := s.queries.ListExpiredGuestIDs(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("list expired guests: %w", err)
+	}
+
+	for i, id := range ids {
+		if err := s.DeleteAccount(ctx, id); err != nil {
+			return i, fmt.Errorf("delete guest %d: %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// validGuestName trims name and checks it is short and only letters, numbers, spaces
+// and a little punctuation, so it reads fine in the lobby and chat
+func validGuestName(name string) (string, bool) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || utf8.RuneCountInString(name) > maxGuestNameLength {
+		return "", false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_.'", r) {
+			return "", false
+		}
+	}
+	return name, true
+}
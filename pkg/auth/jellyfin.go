@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"watchma/db/sqlcgen"
+	"watchma/pkg/jellyfin"
+)
+
+var (
+	ErrJellyfinNotConfigured = errors.New("jellyfin sign in is not available")
+	// ErrJellyfinLinkRequired is returned when a Jellyfin user signs in for the first
+	// time with the name of an existing account, its owner has to link it while signed in
+	ErrJellyfinLinkRequired = errors.New("This username already has a Watchma account. Sign in to it, then link Jellyfin from your account page.")
+	// ErrJellyfinLinkedElsewhere is returned when linking a Jellyfin user another user has
+	ErrJellyfinLinkedElsewhere = errors.New("That Jellyfin account is already linked to another user")
+)
+
+// JellyfinEnabled reports whether users can sign in with their Jellyfin account
+func (s *AuthService) JellyfinEnabled() bool {
+	return s.jellyfin != nil
+}
+
+// LoginWithJellyfin checks a username and password with Jellyfin and starts a session
+// for the linked local user
+func (s *AuthService) LoginWithJellyfin(ctx context.Context, username, password, userAgent string) (*sqlcgen.User, string, error) {
+	if s.jellyfin == nil {
+		return nil, "", ErrJellyfinNotConfigured
+	}
+
+	result, err := s.jellyfin.AuthenticateByName(ctx, username, password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.loginJellyfinUser(ctx, result, userAgent)
+}
+
+// StartQuickConnect begins a Quick Connect sign in, the returned code is shown to the user
+func (s *AuthService) StartQuickConnect(ctx context.Context) (jellyfin.QuickConnect, error) {
+	if s.jellyfin == nil {
+		return jellyfin.QuickConnect{}, ErrJellyfinNotConfigured
+	}
+	return s.jellyfin.InitiateQuickConnect(ctx)
+}
+
+// FinishQuickConnect starts a session once the Quick Connect code has been approved. It
+// returns jellyfin.ErrQuickConnectPending while waiting on the user.
+func (s *AuthService) FinishQuickConnect(ctx context.Context, secret, userAgent string) (*sqlcgen.User, string, error) {
+	if s.jellyfin == nil {
+		return nil, "", ErrJellyfinNotConfigured
+	}
+
+	result, err := s.jellyfin.AuthenticateWithQuickConnect(ctx, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.loginJellyfinUser(ctx, result, userAgent)
+}
+
+// loginJellyfinUser finds the local user for a Jellyfin user, stores their fresh access
+// token and starts a session.
+//
+// The first time a Jellyfin user signs in a local user is created for them. Existing
+// users are never linked by username, whoever picks that name in Jellyfin could take
+// them over. Their owners link them with LinkJellyfin instead.
+func (s *AuthService) loginJellyfinUser(ctx context.Context, result jellyfin.AuthResult, userAgent string) (*sqlcgen.User, string, error) {
+	var user sqlcgen.User
+
+	account, err := s.queries.GetJellyfinAccountByJellyfinUserID(ctx, result.UserID)
+	switch {
+	case err == nil:
+		user, err = s.queries.GetUserByID(ctx, account.UserID)
+		if err != nil {
+			return nil, "", fmt.Errorf("get linked user: %w", err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = s.createJellyfinUser(ctx, result)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", fmt.Errorf("get jellyfin account: %w", err)
+	}
+
+	if err := s.queries.UpsertJellyfinAccount(ctx, sqlcgen.UpsertJellyfinAccountParams{
+		UserID:         user.ID,
+		JellyfinUserID: result.UserID,
+		AccessToken:    result.AccessToken,
+	}); err != nil {
+		return nil, "", fmt.Errorf("save jellyfin account: %w", err)
+	}
+
+	token, err := s.createSession(ctx, user.ID, userAgent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("User logged in with Jellyfin", "username", user.Username)
+	return &user, token, nil
+}
+
+// LinkJellyfin checks a Jellyfin username and password and links that Jellyfin user to
+// user, so they can sign in with either from now on. Their password is kept.
+func (s *AuthService) LinkJellyfin(ctx context.Context, user *sqlcgen.User, username, password string) error {
+	if s.jellyfin == nil {
+		return ErrJellyfinNotConfigured
+	}
+
+	result, err := s.jellyfin.AuthenticateByName(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.queries.GetJellyfinAccountByJellyfinUserID(ctx, result.UserID)
+	switch {
+	case err == nil && account.UserID != user.ID:
+		return ErrJellyfinLinkedElsewhere
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("get jellyfin account: %w", err)
+	}
+
+	if err := s.queries.UpsertJellyfinAccount(ctx, sqlcgen.UpsertJellyfinAccountParams{
+		UserID:         user.ID,
+		JellyfinUserID: result.UserID,
+		AccessToken:    result.AccessToken,
+	}); err != nil {
+		return fmt.Errorf("save jellyfin account: %w", err)
+	}
+
+	s.logger.Info("User linked to Jellyfin from their account", "username", user.Username, "jellyfinUsername", result.Username)
+	return nil
+}
+
+// createJellyfinUser creates a local user with the Jellyfin user's name
+func (s *AuthService) createJellyfinUser(ctx context.Context, result jellyfin.AuthResult) (sqlcgen.User, error) {
+	if err := checkUsername(result.Username); err != nil {
+		return sqlcgen.User{}, err
+	}
+
+	_, err := s.queries.GetUserByUsername(ctx, result.Username)
+	if err == nil {
+		s.logger.Warn("Refused to link Jellyfin user to an existing user by username", "username", result.Username)
+		return sqlcgen.User{}, ErrJellyfinLinkRequired
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return sqlcgen.User{}, fmt.Errorf("get user: %w", err)
+	}
+
+	user, err := s.queries.CreateUser(ctx, sqlcgen.CreateUserParams{
+		Username:     result.Username,
+		PasswordHash: "", // Never matches a bcrypt hash, password login is impossible
+	})
+	if err != nil {
+		return sqlcgen.User{}, fmt.Errorf("create user: %w", err)
+	}
+	if err := s.promoteBootstrapAdmin(ctx, &user); err != nil {
+		return sqlcgen.User{}, err
+	}
+	s.logger.Info("New user created from Jellyfin", "username", user.Username)
+	return user, nil
+}
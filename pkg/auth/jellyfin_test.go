@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"watchma/pkg/jellyfin"
+)
+
+// useTestJellyfin points s at a Jellyfin server that lets every user in with password
+// "jellyfin", giving each name its own user ID
+func useTestJellyfin(t *testing.T, s *AuthService) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Username, Pw string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/Users/AuthenticateByName" || body.Pw != "jellyfin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"User":        map[string]string{"Id": "jf-" + body.Username, "Name": body.Username},
+			"AccessToken": "token-" + body.Username,
+		})
+	}))
+	t.Cleanup(server.Close)
+	s.jellyfin = jellyfin.NewClient(server.URL, "key", time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// Whoever picks a taken username in Jellyfin must not get that account
+func TestLoginWithJellyfinRefusesExistingUsers(t *testing.T) {
+	s := newTestService(t, RegistrationOpen)
+	useTestJellyfin(t, s)
+	createTestUser(t, s, "alice", RegistrationOpen)
+
+	if _, _, err := s.LoginWithJellyfin(context.Background(), "alice", "jellyfin", "test"); !errors.Is(err, ErrJellyfinLinkRequired) {
+		t.Fatalf("err = %v, want ErrJellyfinLinkRequired", err)
+	}
+	// The owner's password still works
+	if _, _, err := s.LoginOrCreate("alice", testPassword, "", "test"); err != nil {
+		t.Errorf("alice with her password: %v", err)
+	}
+}
+
+func TestLinkJellyfin(t *testing.T) {
+	s := newTestService(t, RegistrationOpen)
+	useTestJellyfin(t, s)
+	createTestUser(t, s, "alice", RegistrationOpen)
+	createTestUser(t, s, "bob", RegistrationOpen)
+	ctx := context.Background()
+	alice, _ := s.queries.GetUserByUsername(ctx, "alice")
+	bob, _ := s.queries.GetUserByUsername(ctx, "bob")
+
+	if err := s.LinkJellyfin(ctx, &alice, "alice", "wrong"); !errors.Is(err, jellyfin.ErrInvalidCredentials) {
+		t.Errorf("wrong password err = %v, want jellyfin.ErrInvalidCredentials", err)
+	}
+	if err := s.LinkJellyfin(ctx, &alice, "alice", "jellyfin"); err != nil {
+		t.Fatalf("LinkJellyfin: %v", err)
+	}
+	if err := s.LinkJellyfin(ctx, &bob, "alice", "jellyfin"); !errors.Is(err, ErrJellyfinLinkedElsewhere) {
+		t.Errorf("bob taking alice's Jellyfin err = %v, want ErrJellyfinLinkedElsewhere", err)
+	}
+
+	user, _, err := s.LoginWithJellyfin(ctx, "alice", "jellyfin", "test")
+	if err != nil || user.ID != alice.ID {
+		t.Fatalf("Jellyfin sign in = %v, %v, want alice", user, err)
+	}
+	if _, _, err := s.LoginOrCreate("alice", testPassword, "", "test"); err != nil {
+		t.Errorf("alice's password stopped working after linking: %v", err)
+	}
+}
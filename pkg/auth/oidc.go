@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"watchma/db/sqlcgen"
+	"watchma/pkg/oidc"
+)
+
+var (
+	ErrOIDCNotConfigured = errors.New("single sign-on is not available")
+	// ErrOIDCLinkRequired is returned when the issuer names an existing account that
+	// can't be linked by username alone, its owner has to link it while signed in
+	ErrOIDCLinkRequired = errors.New("account must be linked from its account page")
+	// ErrOIDCLinkedElsewhere is returned when linking an issuer account another user has
+	ErrOIDCLinkedElsewhere = errors.New("single sign-on account is linked to another user")
+)
+
+// UseOIDC turns on signing in through an OpenID Connect issuer. name labels the button
+// on the login page, e.g. "Authelia".
+func (s *AuthService) UseOIDC(client *oidc.Client, name string) {
+	s.oidc = client
+	s.OIDCName = name
+}
+
+// OIDCEnabled reports whether users can sign in through the OpenID Connect issuer
+func (s *AuthService) OIDCEnabled() bool {
+	return s.oidc != nil
+}
+
+// OIDCAuthURL is the issuer's sign in page. The caller keeps state, nonce and verifier
+// until the issuer redirects back.
+func (s *AuthService) OIDCAuthURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if s.oidc == nil {
+		return "", ErrOIDCNotConfigured
+	}
+	return s.oidc.AuthCodeURL(ctx, state, nonce, verifier)
+}
+
+// LoginWithOIDC finishes a sign in with the code the issuer redirected back with and
+// starts a session for the linked local user
+func (s *AuthService) LoginWithOIDC(ctx context.Context, code, verifier, nonce, userAgent string) (*sqlcgen.User, string, error) {
+	if s.oidc == nil {
+		return nil, "", ErrOIDCNotConfigured
+	}
+
+	identity, err := s.oidc.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var user sqlcgen.User
+	account, err := s.queries.GetOIDCAccount(ctx, sqlcgen.GetOIDCAccountParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	switch {
+	case err == nil:
+		user, err = s.queries.GetUserByID(ctx, account.UserID)
+		if err != nil {
+			return nil, "", fmt.Errorf("get linked user: %w", err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		user, err = s.linkOIDCUser(ctx, identity)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", fmt.Errorf("get oidc account: %w", err)
+	}
+
+	token, err := s.createSession(ctx, user.ID, userAgent)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("User logged in with OIDC", "username", user.Username)
+	return &user, token, nil
+}
+
+// LinkOIDC links the issuer account the user just signed in with to user, so they can
+// sign in with either from now on
+func (s *AuthService) LinkOIDC(ctx context.Context, user *sqlcgen.User, code, verifier, nonce string) error {
+	if s.oidc == nil {
+		return ErrOIDCNotConfigured
+	}
+
+	identity, err := s.oidc.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.queries.GetOIDCAccount(ctx, sqlcgen.GetOIDCAccountParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	switch {
+	case err == nil && account.UserID == user.ID:
+		return nil
+	case err == nil:
+		return ErrOIDCLinkedElsewhere
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("get oidc account: %w", err)
+	}
+
+	if err := s.queries.CreateOIDCAccount(ctx, sqlcgen.CreateOIDCAccountParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		UserID:  user.ID,
+	}); err != nil {
+		return fmt.Errorf("create oidc account: %w", err)
+	}
+
+	s.logger.Info("User linked to OIDC from their account", "username", user.Username, "oidcUsername", identity.Username)
+	return nil
+}
+
+// linkOIDCUser creates a local user with the mapped username for an issuer account seen
+// for the first time. Like Jellyfin, the issuer already decided
+// who may sign in, so the registration mode doesn't apply. Existing users are never
+// linked by username, anyone who can pick their username at the issuer could take them
+// over, admins included. Their owners link them with LinkOIDC instead.
+func (s *AuthService) linkOIDCUser(ctx context.Context, identity oidc.Identity) (sqlcgen.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlcgen.User{}, fmt.Errorf("begin link transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	if err := checkUsername(identity.Username); err != nil {
+		return sqlcgen.User{}, err
+	}
+	_, err = qtx.GetUserByUsername(ctx, identity.Username)
+	if err == nil {
+		s.logger.Warn("Refused to link OIDC account to an existing user by username", "username", identity.Username, "issuer", identity.Issuer)
+		return sqlcgen.User{}, ErrOIDCLinkRequired
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return sqlcgen.User{}, fmt.Errorf("get user: %w", err)
+	}
+
+	user, err := qtx.CreateUser(ctx, sqlcgen.CreateUserParams{
+		Username:     identity.Username,
+		PasswordHash: "", // Never matches a bcrypt hash, password login is impossible
+	})
+	if err != nil {
+		return sqlcgen.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	if err := qtx.CreateOIDCAccount(ctx, sqlcgen.CreateOIDCAccountParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		UserID:  user.ID,
+	}); err != nil {
+		return sqlcgen.User{}, fmt.Errorf("create oidc account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sqlcgen.User{}, fmt.Errorf("commit link transaction: %w", err)
+	}
+
+	if err := s.promoteBootstrapAdmin(ctx, &user); err != nil {
+		return sqlcgen.User{}, err
+	}
+	s.logger.Info("New user created from OIDC", "username", user.Username)
+	return user, nil
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/oidc"
+)
+
+// Whoever picks a taken username at the issuer must not get that account
+func TestLinkOIDCUserRefusesExistingUsers(t *testing.T) {
+	s := newTestService(t, RegistrationOpen)
+	createTestUser(t, s, "alice", RegistrationOpen)
+
+	_, err := s.linkOIDCUser(context.Background(), oidc.Identity{Issuer: "https://sso.example", Subject: "1", Username: "alice"})
+	if !errors.Is(err, ErrOIDCLinkRequired) {
+		t.Fatalf("err = %v, want ErrOIDCLinkRequired", err)
+	}
+	if _, err := s.queries.GetOIDCAccount(context.Background(), sqlcgen.GetOIDCAccountParams{Issuer: "https://sso.example", Subject: "1"}); err == nil {
+		t.Error("issuer account was linked to alice")
+	}
+}
+
+func TestLinkOIDCUserCreatesNewUsers(t *testing.T) {
+	s := newTestService(t, RegistrationClosed)
+
+	user, err := s.linkOIDCUser(context.Background(), oidc.Identity{Issuer: "https://sso.example", Subject: "1", Username: "bob"})
+	if err != nil {
+		t.Fatalf("linkOIDCUser: %v", err)
+	}
+	account, err := s.queries.GetOIDCAccount(context.Background(), sqlcgen.GetOIDCAccountParams{Issuer: "https://sso.example", Subject: "1"})
+	if err != nil {
+		t.Fatalf("get oidc account: %v", err)
+	}
+	if account.UserID != user.ID || user.PasswordHash != "" {
+		t.Errorf("user = %+v, account = %+v, want a passwordless bob linked to the issuer", user, account)
+	}
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"watchma/db/sqlcgen"
+)
+
+// ParseTrustedProxies parses a list of CIDRs, a bare IP is a single address. A range
+// covering every address is refused, anyone could then claim to be any user.
+func ParseTrustedProxies(ranges []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(ranges))
+	for _, r := range ranges {
+		var prefix netip.Prefix
+		if strings.Contains(r, "/") {
+			p, err := netip.ParsePrefix(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q: %w", r, err)
+			}
+			prefix = p.Masked()
+		} else {
+			addr, err := netip.ParseAddr(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", r, err)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		if prefix.Bits() == 0 {
+			return nil, fmt.Errorf("range %q trusts every address", r)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// UseProxyAuth trusts header to name the signed in user on requests coming from one of
+// the trusted proxies, e.g. Remote-User from Authelia's forward auth
+func (s *AuthService) UseProxyAuth(header string, trusted []netip.Prefix) {
+	s.ProxyHeader = header
+	s.trustedProxies = trusted
+}
+
+// ProxyAuthEnabled reports whether a trusted proxy can sign users in with a header
+func (s *AuthService) ProxyAuthEnabled() bool {
+	return s.ProxyHeader != "" && len(s.trustedProxies) > 0
+}
+
+// IsTrustedProxy reports whether addr is one of the proxies allowed to send ProxyHeader
+func (s *AuthService) IsTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProxyUser returns the user a trusted proxy vouched for, creating them on their
+// first visit. The proxy already decided who gets in, so like Jellyfin and OIDC the
+// registration mode doesn't apply.
+func (s *AuthService) GetProxyUser(ctx context.Context, username string) (*sqlcgen.User, error) {
+	username = strings.TrimSpace(username)
+	if err := checkUsername(username); err != nil {
+		return nil, err
+	}
+
+	user, err := s.queries.GetUserByUsername(ctx, username)
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	user, err = s.queries.CreateUser(ctx, sqlcgen.CreateUserParams{
+		Username:     username,
+		PasswordHash: "", // Never matches a bcrypt hash, password login is impossible
+	})
+	if err != nil {
+		// A page loads several requests at once, another one may have just created them
+		if existing, getErr := s.queries.GetUserByUsername(ctx, username); getErr == nil {
+			return &existing, nil
+		}
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	if err := s.promoteBootstrapAdmin(ctx, &user); err != nil {
+		return nil, err
+	}
+	s.logger.Info("New user created from proxy header", "username", user.Username)
+	return &user, nil
+}
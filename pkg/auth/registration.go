@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"watchma/db/sqlcgen"
+)
+
+// Registration modes decide who can create a local account by signing in with a new
+// username. Jellyfin users are already vetted by the media server, so they can always
+// sign in.
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+// registrationSettingKey is where an admin's registration mode override lives in app_settings
+const registrationSettingKey = "registration_mode"
+
+// Invite codes are this many characters from inviteAlphabet, shown split in two halves
+const inviteCodeLength = 8
+
+// No 0/O or 1/I, codes get read out loud and typed on phones
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var (
+	ErrRegistrationClosed = errors.New("Registration is closed on this server")
+	ErrInviteRequired     = errors.New("An invite code is required to create an account")
+	ErrInvalidInvite      = errors.New("Invite code is invalid, used up or expired")
+	ErrInvalidMode        = errors.New("invalid registration mode")
+)
+
+// ValidRegistrationMode reports whether mode is one of the registration modes
+func ValidRegistrationMode(mode string) bool {
+	switch mode {
+	case RegistrationOpen, RegistrationInvite, RegistrationClosed:
+		return true
+	}
+	return false
+}
+
+// RegistrationMode is the admin's override if one was saved, otherwise REGISTRATION_MODE
+func (s *AuthService) RegistrationMode(ctx context.Context) (string, error) {
+	mode, err := s.queries.GetAppSetting(ctx, registrationSettingKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.defaultRegistrationMode, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get registration mode: %w", err)
+	}
+	return mode, nil
+}
+
+func (s *AuthService) SetRegistrationMode(ctx context.Context, mode string) error {
+	if !ValidRegistrationMode(mode) {
+		return ErrInvalidMode
+	}
+
+	if err := s.queries.SetAppSetting(ctx, sqlcgen.SetAppSettingParams{
+		Key:   registrationSettingKey,
+		Value: mode,
+	}); err != nil {
+		return fmt.Errorf("set registration mode: %w", err)
+	}
+	s.logger.Info("Registration mode changed", "mode", mode)
+	return nil
+}
+
+// CreateInvite makes a new invite code. maxUses of 0 is unlimited and a ttl of 0 never
+// expires.
+func (s *AuthService) CreateInvite(ctx context.Context, createdBy *sqlcgen.User, maxUses int64, ttl time.Duration) (sqlcgen.InviteCode, error) {
+	if maxUses < 0 || ttl < 0 {
+		return sqlcgen.InviteCode{}, errors.New("uses and expiry must not be negative")
+	}
+
+	var expiresAt sql.NullTime
+	if ttl > 0 {
+		// Truncated and in UTC so the stored timestamps compare correctly as text
+		expiresAt = sql.NullTime{Time: time.Now().UTC().Add(ttl).Truncate(time.Second), Valid: true}
+	}
+
+	invite, err := s.queries.CreateInviteCode(ctx, sqlcgen.CreateInviteCodeParams{
+		Code:      generateInviteCode(),
+		CreatedBy: createdBy.ID,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return sqlcgen.InviteCode{}, fmt.Errorf("create invite code: %w", err)
+	}
+
+	s.logger.Info("Invite code created", "by", createdBy.Username, "maxUses", maxUses, "ttl", ttl)
+	return invite, nil
+}
+
+func (s *AuthService) DeleteInvite(ctx context.Context, id int64) error {
+	if err := s.queries.DeleteInviteRedemptionsByCodeID(ctx, id); err != nil {
+		return fmt.Errorf("delete invite redemptions: %w", err)
+	}
+	if err := s.queries.DeleteInviteCode(ctx, id); err != nil {
+		return fmt.Errorf("delete invite code: %w", err)
+	}
+	return nil
+}
+
+// canRegister checks a new local account could be created with inviteCode. Refusals
+// look like a wrong password, so signing in can't be used to find out which usernames
+// exist. Only a bad invite code is called out, and LoginOrCreate answers that the same
+// way for usernames that are taken.
+func (s *AuthService) canRegister(ctx context.Context, inviteCode string) error {
+	mode, err := s.RegistrationMode(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case RegistrationOpen:
+		return nil
+	case RegistrationInvite:
+		if normalizeInviteCode(inviteCode) == "" {
+			return ErrInvalidCredentials
+		}
+		return s.checkInvite(ctx, inviteCode)
+	default:
+		return ErrInvalidCredentials
+	}
+}
+
+// checkInvite reports whether inviteCode could be redeemed now, without using it up
+func (s *AuthService) checkInvite(ctx context.Context, inviteCode string) error {
+	invite, err := s.queries.GetInviteCodeByCode(ctx, normalizeInviteCode(inviteCode))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidInvite
+	}
+	if err != nil {
+		return fmt.Errorf("get invite code: %w", err)
+	}
+
+	if invite.MaxUses > 0 && invite.Uses >= invite.MaxUses {
+		return ErrInvalidInvite
+	}
+	if invite.ExpiresAt.Valid && !invite.ExpiresAt.Time.After(time.Now()) {
+		return ErrInvalidInvite
+	}
+	return nil
+}
+
+// createUser creates a local account, enforcing the registration mode. In invite mode
+// the code is redeemed in the same transaction, so a failed sign up doesn't use it up.
+func (s *AuthService) createUser(ctx context.Context, username, passwordHash, inviteCode string) (sqlcgen.User, error) {
+	mode, err := s.RegistrationMode(ctx)
+	if err != nil {
+		return sqlcgen.User{}, err
+	}
+
+	switch mode {
+	case RegistrationOpen:
+		return s.queries.CreateUser(ctx, sqlcgen.CreateUserParams{
+			Username:     username,
+			PasswordHash: passwordHash,
+		})
+	case RegistrationInvite:
+		return s.createInvitedUser(ctx, username, passwordHash, inviteCode)
+	default:
+		return sqlcgen.User{}, ErrRegistrationClosed
+	}
+}
+
+func (s *AuthService) createInvitedUser(ctx context.Context, username, passwordHash, inviteCode string) (sqlcgen.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlcgen.User{}, fmt.Errorf("begin sign up transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	inviteID, err := redeemInvite(ctx, qtx, inviteCode)
+	if err != nil {
+		return sqlcgen.User{}, err
+	}
+
+	user, err := qtx.CreateUser(ctx, sqlcgen.CreateUserParams{
+		Username:     username,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return sqlcgen.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	if err := qtx.CreateInviteRedemption(ctx, sqlcgen.CreateInviteRedemptionParams{
+		InviteCodeID: inviteID,
+		UserID:       user.ID,
+	}); err != nil {
+		return sqlcgen.User{}, fmt.Errorf("record invite redemption: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return sqlcgen.User{}, fmt.Errorf("commit sign up transaction: %w", err)
+	}
+
+	s.logger.Info("Invite code redeemed", "username", username, "inviteCodeID", inviteID)
+	return user, nil
+}
+
+// redeemInvite uses up one use of the invite code in the caller's transaction and
+// returns the code's ID, for recording who redeemed it
+func redeemInvite(ctx context.Context, qtx *sqlcgen.Queries, inviteCode string) (int64, error) {
+	inviteCode = normalizeInviteCode(inviteCode)
+	if inviteCode == "" {
+		return 0, ErrInviteRequired
+	}
+
+	invite, err := qtx.GetInviteCodeByCode(ctx, inviteCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidInvite
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get invite code: %w", err)
+	}
+
+	redeemed, err := qtx.RedeemInviteCode(ctx, sqlcgen.RedeemInviteCodeParams{
+		ID:  invite.ID,
+		Now: time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("redeem invite code: %w", err)
+	}
+	if redeemed == 0 {
+		return 0, ErrInvalidInvite
+	}
+	return invite.ID, nil
+}
+
+func generateInviteCode() string {
+	b := make([]byte, inviteCodeLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = inviteAlphabet[int(b[i])%len(inviteAlphabet)]
+	}
+	return string(b)
+}
+
+// FormatInviteCode splits a code in two halves so it is easier to read out
+func FormatInviteCode(code string) string {
+	if len(code) != inviteCodeLength {
+		return code
+	}
+	return code[:inviteCodeLength/2] + "-" + code[inviteCodeLength/2:]
+}
+
+// normalizeInviteCode undoes FormatInviteCode and forgives lowercase and stray spaces
+func normalizeInviteCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -6,9 +6,13 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"log/slog"
-	"time"
+	"net/netip"
+	"sync"
 	"watchma/db/sqlcgen"
+	"watchma/pkg/jellyfin"
+	"watchma/pkg/oidc"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -17,52 +21,148 @@ const (
 	SessionCookieName = "watchma_session"
 )
 
+var ErrInvalidCredentials = errors.New("Invalid username or password")
+
+// dummyHash is compared against when there is no account to check the password of
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Roles stored in users.role
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type AuthService struct {
-	queries *sqlcgen.Queries
-	logger  *slog.Logger
-	IsDev   bool
+	queries  *sqlcgen.Queries
+	db       *sql.DB
+	jellyfin *jellyfin.Client // nil when Jellyfin isn't configured
+	oidc     *oidc.Client     // nil when single sign-on isn't configured
+	logger   *slog.Logger
+	IsDev    bool
+	// JellyfinOnly turns off local accounts, everyone signs in through Jellyfin
+	JellyfinOnly bool
+	// OIDCName is what the single sign-on button on the login page is labelled with
+	OIDCName string
+	// ProxyHeader names the signed in user on requests from trustedProxies, empty when
+	// proxy auth is off
+	ProxyHeader    string
+	trustedProxies []netip.Prefix
+	// Whether rooms can be joined as a guest, without an account
+	guests bool
+	// Usernames made admin at startup or when their account is created
+	bootstrapAdmins map[string]bool
+	// Used until an admin picks a registration mode
+	defaultRegistrationMode string
 }
 
-func NewAuthService(queries *sqlcgen.Queries, logger *slog.Logger, isDev bool) *AuthService {
+func NewAuthService(queries *sqlcgen.Queries, db *sql.DB, jellyfinClient *jellyfin.Client, logger *slog.Logger, isDev, jellyfinOnly bool, adminUsernames []string, registrationMode string) *AuthService {
+	admins := make(map[string]bool, len(adminUsernames))
+	for _, username := range adminUsernames {
+		admins[username] = true
+	}
+
 	return &AuthService{
-		queries: queries,
-		logger:  logger,
-		IsDev:   isDev,
+		queries:         queries,
+		db:              db,
+		jellyfin:        jellyfinClient,
+		logger:          logger,
+		IsDev:           isDev,
+		JellyfinOnly:    jellyfinOnly && jellyfinClient != nil,
+		bootstrapAdmins: admins,
+
+		defaultRegistrationMode: registrationMode,
 	}
 }
 
-func (s *AuthService) LoginOrCreate(username, password string) (*sqlcgen.User, string, error) {
+// IsAdmin reports whether the user may manage users and server wide settings
+func (s *AuthService) IsAdmin(user *sqlcgen.User) bool {
+	return user != nil && user.Role == RoleAdmin
+}
+
+// BootstrapAdmins promotes the existing users named in ADMIN_USERNAMES. Users that
+// don't exist yet are promoted when their account is created.
+func (s *AuthService) BootstrapAdmins(ctx context.Context) error {
+	for username := range s.bootstrapAdmins {
+		user, err := s.queries.GetUserByUsername(ctx, username)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("get user %s: %w", username, err)
+		}
+		if err := s.promoteBootstrapAdmin(ctx, &user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promoteBootstrapAdmin makes user an admin if they are named in ADMIN_USERNAMES
+func (s *AuthService) promoteBootstrapAdmin(ctx context.Context, user *sqlcgen.User) error {
+	if !s.bootstrapAdmins[user.Username] || user.Role == RoleAdmin {
+		return nil
+	}
+
+	if err := s.queries.SetUserRole(ctx, sqlcgen.SetUserRoleParams{
+		Role: RoleAdmin,
+		ID:   user.ID,
+	}); err != nil {
+		return fmt.Errorf("promote %s to admin: %w", user.Username, err)
+	}
+	user.Role = RoleAdmin
+	s.logger.Info("User promoted to admin from ADMIN_USERNAMES", "username", user.Username)
+	return nil
+}
+
+// LoginOrCreate signs in an existing user, or creates the account if the registration
+// mode allows it. inviteCode is only checked when creating an account in invite mode.
+// userAgent is kept with the session so users can tell their devices apart.
+func (s *AuthService) LoginOrCreate(username, password, inviteCode, userAgent string) (*sqlcgen.User, string, error) {
 	ctx := context.Background()
+	if err := checkUsername(username); err != nil {
+		return nil, "", err
+	}
 	user, err := s.queries.GetUserByUsername(ctx, username)
 
 	if err == sql.ErrNoRows {
+		if err := s.canRegister(ctx, inviteCode); err != nil {
+			// Take as long as checking a password would, the refusal shouldn't be
+			// told apart from a wrong password by timing either
+			bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+			return nil, "", err
+		}
+
 		// User doesn't exist - create them
 		hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-		user, err = s.queries.CreateUser(ctx, sqlcgen.CreateUserParams{
-			Username:     username,
-			PasswordHash: string(hash),
-		})
+		user, err = s.createUser(ctx, username, string(hash), inviteCode)
 		if err != nil {
 			return nil, "", err
 		}
+		if err := s.promoteBootstrapAdmin(ctx, &user); err != nil {
+			return nil, "", err
+		}
 		s.logger.Info("New user created", "username", username)
 	} else if err != nil {
 		return nil, "", err
 	} else {
-		// User exists - verify password
+		// Jellyfin accounts have no password, the compare fails for them too. The error
+		// is the same either way so it doesn't reveal which usernames exist.
 		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash),
 			[]byte(password)); err != nil {
-			return nil, "", errors.New("Invalid password for existing user: " + user.Username)
+			// A bad invite code gets the same answer as it would for a new username
+			if inviteCode != "" && errors.Is(s.canRegister(ctx, inviteCode), ErrInvalidInvite) {
+				return nil, "", ErrInvalidInvite
+			}
+			return nil, "", ErrInvalidCredentials
 		}
 		s.logger.Info("User logged in", "username", username)
 	}
 
-	token := generateRandomToken()
-	if err := s.queries.CreateSession(ctx, sqlcgen.CreateSessionParams{
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
-	}); err != nil {
+	token, err := s.createSession(ctx, user.ID, userAgent)
+	if err != nil {
 		return nil, "", err
 	}
 
@@ -70,16 +170,6 @@ func (s *AuthService) LoginOrCreate(username, password string) (*sqlcgen.User, s
 
 }
 
-// GetUserBySessionToken fetches a user by their session token
-func (s *AuthService) GetUserBySessionToken(token string) (*sqlcgen.User, error) {
-	ctx := context.Background()
-	user, err := s.queries.GetUserBySessionToken(ctx, token)
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
-}
-
 func generateRandomToken() string {
 	b := make([]byte, 32) // 32 bytes = 256 bits of randomness
 	rand.Read(b)
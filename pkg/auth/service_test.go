@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"watchma/db"
+	"watchma/db/sqlcgen"
+	"watchma/pkg/jellyfin"
+	"watchma/pkg/oidc"
+)
+
+const testPassword = "PopcornPLEASE42"
+
+func newTestService(t *testing.T, registrationMode string) *AuthService {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(filepath.Join(t.TempDir(), "watchma.db"), logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewAuthService(sqlcgen.New(database.DB), database.DB, nil, logger, true, false, nil, registrationMode)
+}
+
+// createTestUser signs up username in open mode, then switches to mode
+func createTestUser(t *testing.T, s *AuthService, username, mode string) {
+	t.Helper()
+	if _, _, err := s.LoginOrCreate(username, testPassword, "", "test"); err != nil {
+		t.Fatalf("create %s: %v", username, err)
+	}
+	if err := s.SetRegistrationMode(context.Background(), mode); err != nil {
+		t.Fatalf("set registration mode: %v", err)
+	}
+}
+
+// The answer for a wrong password must not depend on whether the username exists
+func TestLoginOrCreateDoesNotRevealUsernames(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		invite  string
+		wantErr error
+	}{
+		{"closed", RegistrationClosed, "", ErrInvalidCredentials},
+		{"closed with invite", RegistrationClosed, "ABCDEFGH", ErrInvalidCredentials},
+		{"invite without code", RegistrationInvite, "", ErrInvalidCredentials},
+		{"invite with bad code", RegistrationInvite, "ABCD-EFGH", ErrInvalidInvite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, RegistrationOpen)
+			createTestUser(t, s, "alice", tt.mode)
+
+			_, _, known := s.LoginOrCreate("alice", "WrongPassword1", tt.invite, "test")
+			_, _, unknown := s.LoginOrCreate("mallory", "WrongPassword1", tt.invite, "test")
+			if !errors.Is(known, tt.wantErr) || !errors.Is(unknown, tt.wantErr) {
+				t.Errorf("known user err = %v, unknown user err = %v, want both %v", known, unknown, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginOrCreateWithInvite(t *testing.T) {
+	s := newTestService(t, RegistrationOpen)
+	createTestUser(t, s, "alice", RegistrationInvite)
+	alice, err := s.queries.GetUserByUsername(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("get alice: %v", err)
+	}
+
+	invite, err := s.CreateInvite(context.Background(), &alice, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+
+	// Existing users sign in whatever the code says
+	if _, _, err := s.LoginOrCreate("alice", testPassword, "nonsense", "test"); err != nil {
+		t.Errorf("alice with a bad code: %v", err)
+	}
+
+	if _, _, err := s.LoginOrCreate("bob", testPassword, FormatInviteCode(invite.Code), "test"); err != nil {
+		t.Fatalf("bob with the invite: %v", err)
+	}
+	// The only use is gone
+	if _, _, err := s.LoginOrCreate("carol", testPassword, invite.Code, "test"); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("carol with a used up invite err = %v, want ErrInvalidInvite", err)
+	}
+}
+
+// Only guests get the suffix, whichever way an account is created
+func TestAccountsCantPassForGuests(t *testing.T) {
+	const username = "alice" + GuestSuffix
+	s := newTestService(t, RegistrationOpen)
+	ctx := context.Background()
+
+	if _, _, err := s.LoginOrCreate(username, testPassword, "", "test"); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("LoginOrCreate err = %v, want ErrInvalidUsername", err)
+	}
+	if _, err := s.GetProxyUser(ctx, username); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("GetProxyUser err = %v, want ErrInvalidUsername", err)
+	}
+	if _, err := s.linkOIDCUser(ctx, oidc.Identity{Issuer: "https://sso.example", Subject: "1", Username: username}); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("linkOIDCUser err = %v, want ErrInvalidUsername", err)
+	}
+	if _, err := s.createJellyfinUser(ctx, jellyfin.AuthResult{UserID: "1", Username: username}); !errors.Is(err, ErrInvalidUsername) {
+		t.Errorf("createJellyfinUser err = %v, want ErrInvalidUsername", err)
+	}
+	if _, err := s.queries.GetUserByUsername(ctx, username); err == nil {
+		t.Error("an account was created with the guest suffix")
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"watchma/db/sqlcgen"
+)
+
+const (
+	// SessionLifetime is how long a session lasts without being used. Every use slides
+	// the expiry forward again.
+	SessionLifetime = 30 * 24 * time.Hour
+	// sessionRenewInterval limits renewals to one write per session per interval
+	sessionRenewInterval = time.Hour
+	// Longest user agent kept with a session
+	maxUserAgentLength = 255
+)
+
+// createSession stores a new session for the user and returns its token
+func (s *AuthService) createSession(ctx context.Context, userID int64, userAgent string) (string, error) {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	token := generateRandomToken()
+	if err := s.queries.CreateSession(ctx, sqlcgen.CreateSessionParams{
+		UserID:    userID,
+		Token:     token,
+		ExpiresAt: time.Now().UTC().Add(SessionLifetime),
+		UserAgent: userAgent,
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetUserBySessionToken fetches the user for a session that hasn't expired, and slides
+// the session's expiry forward
+func (s *AuthService) GetUserBySessionToken(token string) (*sqlcgen.User, error) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	user, err := s.queries.GetUserBySessionToken(ctx, sqlcgen.GetUserBySessionTokenParams{
+		Token: token,
+		Now:   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed renewal only shortens the session, don't fail the request over it
+	if err := s.queries.RenewSession(ctx, sqlcgen.RenewSessionParams{
+		Now:         now,
+		ExpiresAt:   now.Add(SessionLifetime),
+		Token:       token,
+		StaleBefore: now.Add(-sessionRenewInterval),
+	}); err != nil {
+		s.logger.Warn("Failed to renew session", "username", user.Username, "error", err)
+	}
+
+	return &user, nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *AuthService) ListSessions(ctx context.Context, userID int64) ([]sqlcgen.RefreshToken, error) {
+	return s.queries.ListSessionsByUserID(ctx, sqlcgen.ListSessionsByUserIDParams{
+		UserID: userID,
+		Now:    time.Now().UTC(),
+	})
+}
+
+// Logout revokes the session, so the token stops working even if the cookie survives
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if err := s.queries.DeleteSession(ctx, token); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession signs one of the user's devices out
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	if err := s.queries.DeleteUserSession(ctx, sqlcgen.DeleteUserSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	}); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+// LogoutEverywhere revokes every session the user has, including the current one
+func (s *AuthService) LogoutEverywhere(ctx context.Context, user *sqlcgen.User) error {
+	if err := s.queries.DeleteSessionsByUserID(ctx, user.ID); err != nil {
+		return fmt.Errorf("delete sessions: %w", err)
+	}
+	s.logger.Info("User logged out everywhere", "username", user.Username)
+	return nil
+}
+
+// StartSessionCleanup deletes expired sessions and guests immediately and then every
+// interval until ctx is cancelled
+func (s *AuthService) StartSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.queries.DeleteExpiredSessions(ctx, time.Now().UTC())
+		if err != nil {
+			s.logger.Error("Failed to delete expired sessions", "error", err)
+		} else if deleted > 0 {
+			s.logger.Info("Expired sessions deleted", "count", deleted)
+		}
+
+		guests, err := s.deleteExpiredGuests(ctx)
+		if err != nil {
+			s.logger.Error("Failed to delete expired guests", "error", err)
+		}
+		if guests > 0 {
+			s.logger.Info("Expired guests deleted", "count", guests)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package context
+
+import (
+	"context"
+	"net/http"
+)
+
+const csrfTokenKey contextKey = "csrfToken"
+
+// GetCSRFToken retrieves the request's CSRF token, templ components read it from ctx
+// Returns "" if the CSRF middleware didn't run
+func GetCSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey).(string)
+	return token
+}
+
+// SetCSRFTokenInRequest stores the CSRF token in request context and returns the updated request
+func SetCSRFTokenInRequest(r *http.Request, token string) *http.Request {
+	ctx := context.WithValue(r.Context(), csrfTokenKey, token)
+	return r.WithContext(ctx)
+}
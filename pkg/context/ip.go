@@ -0,0 +1,21 @@
+package context
+
+import (
+	"context"
+	"net/http"
+)
+
+const clientIPKey contextKey = "clientIP"
+
+// GetClientIP retrieves the client address a trusted proxy forwarded the request for
+// Returns "" if the request didn't come through one
+func GetClientIP(r *http.Request) string {
+	ip, _ := r.Context().Value(clientIPKey).(string)
+	return ip
+}
+
+// SetClientIPInRequest stores the forwarded client address in request context and returns the updated request
+func SetClientIPInRequest(r *http.Request, ip string) *http.Request {
+	ctx := context.WithValue(r.Context(), clientIPKey, ip)
+	return r.WithContext(ctx)
+}
@@ -0,0 +1,45 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Backends that can be selected with EMBEDDING_BACKEND
+const (
+	BackendOpenAI = "openai" // OpenAI or any OpenAI-compatible server (Ollama's /v1, llama.cpp, LM Studio...)
+	BackendFake   = "fake"   // Hashed bag of words, no network
+)
+
+// Embedder turns text into vectors whose cosine similarity reflects how alike the
+// texts are
+type Embedder interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Model identifies the vectors, vectors from different models can't be compared
+	Model() string
+	// Embed returns one vector per text, in the same order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+type Config struct {
+	Backend string
+	BaseURL string
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+}
+
+// New returns the Embedder for cfg.Backend
+func New(cfg Config, logger *slog.Logger) (Embedder, error) {
+	switch cfg.Backend {
+	case BackendOpenAI:
+		return NewOpenAI(cfg, logger), nil
+	case BackendFake:
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,47 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const fakeDimensions = 256
+
+// Fake hashes each word of the text into a bucket, so texts sharing words end up
+// close together. Nowhere near a real model, but enough to run vibe search without one.
+type Fake struct{}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Name() string {
+	return BackendFake
+}
+
+func (f *Fake) Model() string {
+	return "fake-bag-of-words"
+}
+
+func (f *Fake) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vector := make([]float32, fakeDimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[h.Sum32()%fakeDimensions]++
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,238 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/movie"
+)
+
+// How many movies are sent to the embedder in one request
+const batchSize = 64
+
+// Index keeps an embedding of every movie in the library and ranks movies by how
+// close they are to a description. Vectors live in the movie_embeddings table and
+// are cached in memory, the library is small enough to search by brute force.
+type Index struct {
+	embedder Embedder
+	queries  *sqlcgen.Queries
+	logger   *slog.Logger
+
+	mu      sync.RWMutex
+	vectors map[string][]float32 // movie ID -> unit vector, nil until first loaded
+}
+
+func NewIndex(embedder Embedder, queries *sqlcgen.Queries, logger *slog.Logger) *Index {
+	return &Index{
+		embedder: embedder,
+		queries:  queries,
+		logger:   logger,
+	}
+}
+
+// Sync embeds every movie whose title, overview or genres changed since it was last
+// embedded, or that was embedded with a different model
+func (x *Index) Sync(ctx context.Context, movies []movie.Movie) error {
+	rows, err := x.queries.ListMovieEmbeddings(ctx)
+	if err != nil {
+		return fmt.Errorf("list movie embeddings: %w", err)
+	}
+	hashes := make(map[string]string, len(rows))
+	for _, row := range rows {
+		hashes[row.MovieID] = row.ContentHash
+	}
+
+	var stale []movie.Movie
+	for _, m := range movies {
+		if hashes[m.Id] != x.contentHash(m) {
+			stale = append(stale, m)
+		}
+	}
+
+	for start := 0; start < len(stale); start += batchSize {
+		batch := stale[start:min(start+batchSize, len(stale))]
+		if err := x.embedBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	removed, err := x.queries.DeleteOrphanedMovieEmbeddings(ctx)
+	if err != nil {
+		return fmt.Errorf("delete orphaned movie embeddings: %w", err)
+	}
+
+	if err := x.load(ctx); err != nil {
+		return err
+	}
+
+	x.logger.Info("Movie embeddings synced",
+		"backend", x.embedder.Name(),
+		"model", x.embedder.Model(),
+		"embedded", len(stale),
+		"removed", removed,
+	)
+	return nil
+}
+
+func (x *Index) embedBatch(ctx context.Context, batch []movie.Movie) error {
+	texts := make([]string, 0, len(batch))
+	for _, m := range batch {
+		texts = append(texts, content(m))
+	}
+
+	vectors, err := x.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed movies: %w", err)
+	}
+
+	for i, m := range batch {
+		if err := x.queries.UpsertMovieEmbedding(ctx, sqlcgen.UpsertMovieEmbeddingParams{
+			MovieID:     m.Id,
+			Model:       x.embedder.Model(),
+			ContentHash: x.contentHash(m),
+			Vector:      encode(normalize(vectors[i])),
+		}); err != nil {
+			return fmt.Errorf("save embedding for %s: %w", m.Id, err)
+		}
+	}
+	return nil
+}
+
+// load replaces the in-memory vectors with the ones in the database
+func (x *Index) load(ctx context.Context) error {
+	rows, err := x.queries.ListMovieEmbeddings(ctx)
+	if err != nil {
+		return fmt.Errorf("list movie embeddings: %w", err)
+	}
+
+	vectors := make(map[string][]float32, len(rows))
+	for _, row := range rows {
+		if row.Model == x.embedder.Model() {
+			vectors[row.MovieID] = decode(row.Vector)
+		}
+	}
+
+	x.mu.Lock()
+	x.vectors = vectors
+	x.mu.Unlock()
+	return nil
+}
+
+// Rank orders movies by how well they match query, best first. Movies that haven't
+// been embedded yet keep their order at the end.
+func (x *Index) Rank(ctx context.Context, query string, movies []movie.Movie) ([]movie.Movie, error) {
+	x.mu.RLock()
+	loaded := x.vectors != nil
+	x.mu.RUnlock()
+	if !loaded {
+		if err := x.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	queryVectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	queryVector := normalize(queryVectors[0])
+
+	type scored struct {
+		movie movie.Movie
+		score float64
+	}
+	var ranked []scored
+	var unranked []movie.Movie
+
+	x.mu.RLock()
+	for _, m := range movies {
+		vector, ok := x.vectors[m.Id]
+		if !ok || len(vector) != len(queryVector) {
+			unranked = append(unranked, m)
+			continue
+		}
+		ranked = append(ranked, scored{movie: m, score: dot(queryVector, vector)})
+	}
+	x.mu.RUnlock()
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	result := make([]movie.Movie, 0, len(movies))
+	for _, s := range ranked {
+		result = append(result, s.movie)
+	}
+	return append(result, unranked...), nil
+}
+
+// content is the text embedded for a movie
+func content(m movie.Movie) string {
+	var b strings.Builder
+	b.WriteString(m.Name)
+	if m.ProductionYear > 0 {
+		fmt.Fprintf(&b, " (%d)", m.ProductionYear)
+	}
+	if len(m.Genres) > 0 {
+		fmt.Fprintf(&b, "\nGenres: %s", strings.Join(m.Genres, ", "))
+	}
+	if m.Overview != "" {
+		fmt.Fprintf(&b, "\n%s", m.Overview)
+	}
+	return b.String()
+}
+
+func (x *Index) contentHash(m movie.Movie) string {
+	sum := sha256.Sum256([]byte(x.embedder.Model() + "\n" + content(m)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalize scales v to unit length, so cosine similarity is just the dot product
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return v
+	}
+
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = float32(float64(f) / norm)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func encode(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decode(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "text-embedding-3-small"
+)
+
+// OpenAI talks to the embeddings endpoint of OpenAI or any server that copies its API
+type OpenAI struct {
+	cfg        Config
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func NewOpenAI(cfg Config, logger *slog.Logger) *OpenAI {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultOpenAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultOpenAIModel
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &OpenAI{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		logger:     logger,
+	}
+}
+
+func (o *OpenAI) Name() string {
+	return BackendOpenAI
+}
+
+func (o *OpenAI) Model() string {
+	return o.cfg.Model
+}
+
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(embeddingsRequest{Model: o.cfg.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	// Local servers usually don't need a key
+	if o.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errorBody map[string]any
+		json.NewDecoder(resp.Body).Decode(&errorBody)
+		o.logger.Error("Embeddings API returned error",
+			"status_code", resp.StatusCode,
+			"status", resp.Status,
+			"error", errorBody,
+		)
+		return nil, fmt.Errorf("embeddings API returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var result embeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("asked for %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	// The data is usually in order already, but the index is what counts
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,27 @@
+package host
+
+// cannedLines are what the host says when there is no LLM to ask. They are templates
+// over Event, just like persona prompts.
+var cannedLines = map[EventKind][]string{
+	LobbyArrival: {
+		"Give it up for {{.Username}}!",
+		"{{.Username}} has entered the building. Somebody get them some popcorn.",
+		"Welcome, {{.Username}}! Grab a seat, the good ones are going fast.",
+		"Ladies and gentlemen... {{.Username}}!",
+	},
+	DraftOverlap: {
+		"Great minds think alike, more than one of you picked {{join .Movies \", \"}}!",
+		"Well well well, {{join .Movies \", \"}} got drafted twice. Somebody's been talking.",
+		"Looks like {{join .Movies \", \"}} has some fans in this room.",
+	},
+	VotingHotTake: {
+		"Hot take: there are no bad choices here. Okay, maybe one.",
+		"Vote with your heart, not with your friends.",
+		"I've seen every one of these. I'm not telling you which one is my favourite. It's the second one.",
+	},
+	Tie: {
+		"A tie! Between {{join .Movies \" and \"}}! Nobody leaves until we have a winner!",
+		"Deadlocked! Time to change some minds.",
+		"It's neck and neck, let's run that back!",
+	},
+}
@@ -0,0 +1,206 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/llm"
+	"watchma/pkg/room"
+)
+
+type EventKind string
+
+const (
+	LobbyArrival  EventKind = "lobby_arrival"
+	DraftOverlap  EventKind = "draft_overlap"
+	VotingHotTake EventKind = "voting_hot_take"
+	Tie           EventKind = "tie"
+)
+
+// Event is something that happened in a room the host can comment on. Its fields are
+// what prompt templates can use, e.g. {{.Username}} or {{join .Movies ", "}}.
+type Event struct {
+	Kind     EventKind
+	Room     string
+	Username string   // Who it happened to, if anyone
+	Players  []string // Everyone in the room
+	Movies   []string // Movie names the event is about
+}
+
+const (
+	// Used when no persona is active
+	defaultHostName = "Host"
+	// How long the LLM gets before the host falls back to a canned line
+	commentTimeout = 20 * time.Second
+)
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Service is the game show host. It comments on what happens in a room using the
+// active persona, rate limited per room so it doesn't drown out the game.
+type Service struct {
+	queries     *sqlcgen.Queries
+	llmProvider llm.Provider // nil when AI is disabled, only canned lines are used
+	roomService *room.Service
+	interval    time.Duration
+	logger      *slog.Logger
+
+	mu          sync.Mutex
+	lastComment map[string]time.Time // room name -> when the host last spoke
+}
+
+func NewService(queries *sqlcgen.Queries, llmProvider llm.Provider, roomService *room.Service, interval time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		queries:     queries,
+		llmProvider: llmProvider,
+		roomService: roomService,
+		interval:    interval,
+		logger:      logger,
+		lastComment: make(map[string]time.Time),
+	}
+}
+
+// Comment has the host react to ev in the background. It is dropped if the host spoke
+// in the room less than the configured interval ago.
+func (s *Service) Comment(ev Event) {
+	if !s.allow(ev.Room) {
+		s.logger.Debug("Host comment rate limited", "room", ev.Room, "event", ev.Kind)
+		return
+	}
+
+	s.roomService.RunInBackground(ev.Room, "host comment", func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, commentTimeout)
+		defer cancel()
+
+		name, line := s.generate(ctx, ev)
+		if ctx.Err() != nil && line == "" {
+			return
+		}
+		s.roomService.SetHostComment(ev.Room, room.HostComment{Persona: name, Line: line})
+	})
+}
+
+// allow reports whether the host may speak in roomName now, and if so records it
+func (s *Service) allow(roomName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := s.lastComment[roomName]; ok && now.Sub(last) < s.interval {
+		return false
+	}
+	s.lastComment[roomName] = now
+
+	// Forget rooms that have been quiet for a long time, most of them are gone
+	for name, last := range s.lastComment {
+		if now.Sub(last) > time.Hour {
+			delete(s.lastComment, name)
+		}
+	}
+	return true
+}
+
+// generate returns the persona's name and their line for ev. Without an LLM, or if
+// it fails, a canned line is used instead.
+func (s *Service) generate(ctx context.Context, ev Event) (string, string) {
+	persona, err := s.queries.GetActiveHostPersona(ctx)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Failed to get active host persona", "error", err)
+		}
+		return defaultHostName, cannedLine(ev)
+	}
+
+	if s.llmProvider == nil {
+		return persona.Name, cannedLine(ev)
+	}
+
+	prompt, err := RenderPrompt(promptFor(persona, ev.Kind), ev)
+	if err != nil {
+		s.logger.Error("Failed to render host prompt", "persona", persona.Name, "event", ev.Kind, "error", err)
+		return persona.Name, cannedLine(ev)
+	}
+
+	resp, err := s.llmProvider.Complete(ctx, llm.Request{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: persona.SystemPrompt},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Purpose: llm.PurposeHostComment,
+		Room:    ev.Room,
+	})
+	if err != nil {
+		s.logger.Warn("Host comment failed, using a canned line", "backend", s.llmProvider.Name(), "error", err)
+		return persona.Name, cannedLine(ev)
+	}
+
+	line := strings.Trim(strings.TrimSpace(resp.Content), `"`)
+	if line == "" {
+		return persona.Name, cannedLine(ev)
+	}
+	return persona.Name, line
+}
+
+func promptFor(persona sqlcgen.HostPersona, kind EventKind) string {
+	switch kind {
+	case LobbyArrival:
+		return persona.LobbyArrivalPrompt
+	case DraftOverlap:
+		return persona.DraftOverlapPrompt
+	case VotingHotTake:
+		return persona.VotingHotTakePrompt
+	case Tie:
+		return persona.TiePrompt
+	default:
+		return ""
+	}
+}
+
+// RenderPrompt fills in a persona prompt template with the event
+func RenderPrompt(text string, ev Event) (string, error) {
+	tmpl, err := ParsePrompt(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ev); err != nil {
+		return "", fmt.Errorf("execute prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ParsePrompt parses a persona prompt template, used to validate them before saving
+func ParsePrompt(text string) (*template.Template, error) {
+	tmpl, err := template.New("prompt").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt: %w", err)
+	}
+	return tmpl, nil
+}
+
+// cannedLine picks one of the built in lines for the event
+func cannedLine(ev Event) string {
+	lines := cannedLines[ev.Kind]
+	if len(lines) == 0 {
+		return ""
+	}
+
+	line, err := RenderPrompt(lines[rand.Intn(len(lines))], ev)
+	if err != nil {
+		return ""
+	}
+	return line
+}
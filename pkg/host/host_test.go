@@ -0,0 +1,121 @@
+package host
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"watchma/db"
+	"watchma/db/sqlcgen"
+	"watchma/pkg/llm"
+)
+
+var arrival = Event{
+	Kind:     LobbyArrival,
+	Room:     "movienight",
+	Username: "alice",
+	Players:  []string{"alice", "bob"},
+}
+
+// newTestService returns a host using the Gus Showman persona the migrations seed
+func newTestService(t *testing.T, provider llm.Provider) *Service {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(filepath.Join(t.TempDir(), "watchma.db"), logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewService(sqlcgen.New(database.DB), provider, nil, time.Minute, logger)
+}
+
+func TestGenerateUsesPersona(t *testing.T) {
+	fake := llm.NewFake(`  "Welcome, alice, to the greatest show on couch!"  `)
+	s := newTestService(t, fake)
+
+	name, line := s.generate(context.Background(), arrival)
+	if name != "Gus Showman" {
+		t.Errorf("name = %q, want the active persona", name)
+	}
+	if line != "Welcome, alice, to the greatest show on couch!" {
+		t.Errorf("line = %q, want it without the quotes and spaces", line)
+	}
+
+	if len(fake.Requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(fake.Requests))
+	}
+	req := fake.Requests[0]
+	if req.Purpose != llm.PurposeHostComment || req.Room != "movienight" {
+		t.Errorf("request = %+v, want the host comment purpose and the room", req)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != llm.RoleSystem || !strings.Contains(req.Messages[0].Content, "Gus Showman") {
+		t.Fatalf("messages = %+v, want the persona's system prompt first", req.Messages)
+	}
+	if want := `alice just walked into the room "movienight". The players here are: alice, bob.`; !strings.Contains(req.Messages[1].Content, want) {
+		t.Errorf("prompt = %q, want the rendered arrival template", req.Messages[1].Content)
+	}
+}
+
+func TestGenerateFallsBackToCannedLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider llm.Provider
+	}{
+		{"AI disabled", nil},
+		{"empty response", llm.NewFake(`""`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, tt.provider)
+
+			name, line := s.generate(context.Background(), arrival)
+			if name != "Gus Showman" {
+				t.Errorf("name = %q, want the active persona", name)
+			}
+			if !isCanned(line, arrival) {
+				t.Errorf("line = %q, want a canned arrival line", line)
+			}
+		})
+	}
+}
+
+func TestGenerateFallsBackWhenLLMFails(t *testing.T) {
+	s := newTestService(t, llm.NewFake("Hello!"))
+
+	// The fake fails on a cancelled context, like a real backend timing out
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, line := s.generate(ctx, arrival); !isCanned(line, arrival) {
+		t.Errorf("line = %q, want a canned arrival line", line)
+	}
+}
+
+func TestAllowRateLimitsPerRoom(t *testing.T) {
+	s := newTestService(t, nil)
+
+	if !s.allow("movienight") {
+		t.Fatal("first comment in a room was rate limited")
+	}
+	if s.allow("movienight") {
+		t.Error("second comment within the interval was allowed")
+	}
+	if !s.allow("other") {
+		t.Error("a different room was rate limited")
+	}
+}
+
+func isCanned(line string, ev Event) bool {
+	var rendered []string
+	for _, l := range cannedLines[ev.Kind] {
+		r, err := RenderPrompt(l, ev)
+		if err == nil {
+			rendered = append(rendered, r)
+		}
+	}
+	return slices.Contains(rendered, line)
+}
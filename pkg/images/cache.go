@@ -0,0 +1,283 @@
+package images
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid image id or tag")
+
+// Source fetches the original, full size artwork for an item
+type Source interface {
+	FetchImage(ctx context.Context, itemId, tag string) (io.ReadCloser, error)
+}
+
+// Image is a resized poster stored in the cache directory
+type Image struct {
+	Path    string
+	ETag    string
+	ModTime time.Time
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+// Cache keeps resized posters on disk, keyed by item ID, image tag and width. When the
+// directory grows past maxBytes the least recently used files are deleted.
+type Cache struct {
+	dir      string
+	maxBytes int64
+	source   Source
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	lru     *list.List // front is the most recently used
+	entries map[string]*list.Element
+	size    int64
+
+	// One lock per key so concurrent requests for a new poster only fetch it once
+	fills sync.Map
+}
+
+// NewCache creates the cache directory if needed and indexes the files already in it,
+// oldest modification time first
+func NewCache(dir string, maxBytes int64, source Source, logger *slog.Logger) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create image cache directory: %w", err)
+	}
+
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		source:   source,
+		logger:   logger,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read image cache directory: %w", err)
+	}
+
+	type existing struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	found := make([]existing, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".jpg") {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, existing{
+			key:     strings.TrimSuffix(f.Name(), ".jpg"),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].modTime.Before(found[j].modTime)
+	})
+	for _, f := range found {
+		c.entries[f.key] = c.lru.PushFront(&entry{key: f.key, size: f.size})
+		c.size += f.size
+	}
+	c.evict()
+
+	logger.Debug("Image cache loaded", "dir", dir, "files", c.lru.Len(), "bytes", c.size)
+	return c, nil
+}
+
+// ETag is derived from the cache key, it only changes when the artwork's tag does
+func ETag(itemId, tag string, width int) string {
+	return fmt.Sprintf(`"%s"`, cacheKey(itemId, tag, StandardWidth(width)))
+}
+
+// Get returns the poster for itemId at the standard width closest to width, fetching
+// and resizing it from the source on a cache miss
+func (c *Cache) Get(ctx context.Context, itemId, tag string, width int) (*Image, error) {
+	if itemId == "" || !validKeyPart(itemId) || !validKeyPart(tag) {
+		return nil, ErrInvalidKey
+	}
+
+	width = StandardWidth(width)
+	key := cacheKey(itemId, tag, width)
+
+	if img, ok := c.lookup(key); ok {
+		return img, nil
+	}
+
+	lock, _ := c.fills.LoadOrStore(key, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer func() {
+		lock.(*sync.Mutex).Unlock()
+		c.fills.Delete(key)
+	}()
+
+	// Another request may have filled it while we waited
+	if img, ok := c.lookup(key); ok {
+		return img, nil
+	}
+
+	if err := c.fill(ctx, key, itemId, tag, width); err != nil {
+		return nil, err
+	}
+
+	img, ok := c.lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("image %s evicted immediately, cache size is too small", key)
+	}
+	return img, nil
+}
+
+// fill fetches the original image, resizes it and writes it into the cache
+func (c *Cache) fill(ctx context.Context, key, itemId, tag string, width int) error {
+	body, err := c.source.FetchImage(ctx, itemId, tag)
+	if err != nil {
+		return fmt.Errorf("fetch image: %w", err)
+	}
+	defer body.Close()
+
+	original, _, err := image.Decode(body)
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp image: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := jpeg.Encode(tmp, resize(original, width), &jpeg.Options{Quality: 85}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encode image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp image: %w", err)
+	}
+
+	path := c.path(key)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("move image into cache: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat cached image: %w", err)
+	}
+
+	c.mu.Lock()
+	c.entries[key] = c.lru.PushFront(&entry{key: key, size: info.Size()})
+	c.size += info.Size()
+	c.evict()
+	c.mu.Unlock()
+
+	c.logger.Debug("Image cached", "key", key, "bytes", info.Size())
+	return nil
+}
+
+// lookup returns a cached image and marks it as recently used
+func (c *Cache) lookup(key string) (*Image, bool) {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(el)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		// Deleted from under us, forget about it so it gets fetched again
+		c.mu.Lock()
+		c.remove(el)
+		c.mu.Unlock()
+		return nil, false
+	}
+
+	// Keep recency across restarts, the index is rebuilt from modification times
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return &Image{
+		Path:    path,
+		ETag:    `"` + key + `"`,
+		ModTime: info.ModTime(),
+	}, true
+}
+
+// evict deletes least recently used images until the cache fits, c.mu must be held
+func (c *Cache) evict() {
+	for c.size > c.maxBytes && c.lru.Len() > 1 {
+		oldest := c.lru.Back()
+		key := oldest.Value.(*entry).key
+		if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			c.logger.Warn("Failed to evict cached image", "key", key, "error", err)
+		}
+		c.remove(oldest)
+	}
+}
+
+// remove drops an element from the index, c.mu must be held
+func (c *Cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	if _, ok := c.entries[e.key]; !ok {
+		return
+	}
+	c.lru.Remove(el)
+	delete(c.entries, e.key)
+	c.size -= e.size
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".jpg")
+}
+
+func cacheKey(itemId, tag string, width int) string {
+	if tag == "" {
+		tag = "none"
+	}
+	return fmt.Sprintf("%s_%s_%d", itemId, tag, width)
+}
+
+// validKeyPart only allows characters that are safe in a file name, Jellyfin IDs
+// and tags are hex strings
+func validKeyPart(s string) bool {
+	if len(s) > 128 {
+		return false
+	}
+	for _, char := range s {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-') {
+			return false
+		}
+	}
+	return true
+}
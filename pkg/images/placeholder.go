@@ -0,0 +1,94 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+)
+
+const (
+	placeholderWidth  = 800
+	placeholderHeight = 1200
+)
+
+// PlaceholderSource generates posters for movies that have no real artwork, like the
+// ones from movie.DummyProvider. Each movie gets its own colour, derived from its ID,
+// with the bundled Watchma logo in the middle.
+type PlaceholderSource struct {
+	logo image.Image
+}
+
+// NewPlaceholderSource loads the logo drawn on every placeholder. A missing logo is not
+// fatal, the posters are just plain gradients.
+func NewPlaceholderSource(logoPath string) *PlaceholderSource {
+	p := &PlaceholderSource{}
+
+	f, err := os.Open(logoPath)
+	if err != nil {
+		return p
+	}
+	defer f.Close()
+
+	if logo, _, err := image.Decode(f); err == nil {
+		p.logo = logo
+	}
+	return p
+}
+
+func (p *PlaceholderSource) FetchImage(_ context.Context, itemId, tag string) (io.ReadCloser, error) {
+	base := colorFor(itemId)
+	poster := image.NewRGBA(image.Rect(0, 0, placeholderWidth, placeholderHeight))
+
+	// Vertical gradient, fading from the movie's colour to near black
+	for y := 0; y < placeholderHeight; y++ {
+		shade := 1 - 0.8*float64(y)/placeholderHeight
+		c := color.RGBA{
+			R: uint8(float64(base.R) * shade),
+			G: uint8(float64(base.G) * shade),
+			B: uint8(float64(base.B) * shade),
+			A: 255,
+		}
+		draw.Draw(poster, image.Rect(0, y, placeholderWidth, y+1), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	if p.logo != nil {
+		logo := resize(p.logo, placeholderWidth/2)
+		lb := logo.Bounds()
+		offset := image.Pt((placeholderWidth-lb.Dx())/2, (placeholderHeight-lb.Dy())/2)
+		draw.Draw(poster, lb.Sub(lb.Min).Add(offset), logo, lb.Min, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, poster, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+// colorFor picks a saturated colour deterministically from an ID
+func colorFor(id string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	sum := h.Sum32()
+
+	palette := []color.RGBA{
+		{239, 68, 68, 255},  // red
+		{249, 115, 22, 255}, // orange
+		{234, 179, 8, 255},  // yellow
+		{34, 197, 94, 255},  // green
+		{59, 130, 246, 255}, // blue
+		{139, 92, 246, 255}, // violet
+		{236, 72, 153, 255}, // pink
+		{6, 182, 212, 255},  // cyan
+		{16, 185, 129, 255}, // emerald
+		{99, 102, 241, 255}, // indigo
+	}
+	return palette[sum%uint32(len(palette))]
+}
@@ -0,0 +1,72 @@
+package images
+
+import (
+	"image"
+	"image/draw"
+)
+
+// StandardWidths are the only widths posters are resized to, so the cache holds at
+// most a few variants of each poster no matter what width the page asks for
+var StandardWidths = []int{200, 400, 800}
+
+// StandardWidth rounds a requested width up to the nearest standard width
+func StandardWidth(requested int) int {
+	for _, w := range StandardWidths {
+		if requested <= w {
+			return w
+		}
+	}
+	return StandardWidths[len(StandardWidths)-1]
+}
+
+// resize scales src down to width keeping its aspect ratio. Each destination pixel is
+// the average of the source pixels under it, which keeps downscaled posters smooth.
+// Images narrower than width are returned as they are, we never upscale.
+func resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw <= width || sw == 0 || sh == 0 {
+		return src
+	}
+
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+
+	// Work on RGBA pixels directly, calling At() for every pixel is far too slow
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := max((y+1)*sh/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := max((x+1)*sw/width, x0+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = uint8(r / n)
+			d[1] = uint8(g / n)
+			d[2] = uint8(b / n)
+			d[3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
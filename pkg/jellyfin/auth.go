@@ -0,0 +1,134 @@
+package jellyfin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"watchma/pkg/buildinfo"
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("invalid Jellyfin username or password")
+	ErrQuickConnectDisabled = errors.New("quick connect is not enabled on this Jellyfin server")
+	ErrQuickConnectNotFound = errors.New("quick connect request has expired")
+	ErrQuickConnectPending  = errors.New("quick connect request has not been approved yet")
+	clientAuthorization     = fmt.Sprintf(`MediaBrowser Client="Watchma", Device=%q, DeviceId=%q, Version=%q`, buildinfo.Hostname(), "watchma-"+buildinfo.Hostname(), buildinfo.Version)
+)
+
+// AuthResult is who Jellyfin says signed in, and the token to act on their behalf
+type AuthResult struct {
+	UserID      string
+	Username    string
+	AccessToken string
+}
+
+// QuickConnect is a pending Quick Connect request. The user enters Code in a signed
+// in Jellyfin app, the Secret stays on the server and is used to poll for approval.
+type QuickConnect struct {
+	Code   string
+	Secret string
+}
+
+type authenticationResponse struct {
+	User struct {
+		Id   string `json:"Id"`
+		Name string `json:"Name"`
+	} `json:"User"`
+	AccessToken string `json:"AccessToken"`
+}
+
+type quickConnectResponse struct {
+	Authenticated bool   `json:"Authenticated"`
+	Secret        string `json:"Secret"`
+	Code          string `json:"Code"`
+}
+
+// AuthenticateByName signs a user in with their Jellyfin username and password
+func (c *Client) AuthenticateByName(ctx context.Context, username, password string) (AuthResult, error) {
+	var resp authenticationResponse
+	err := c.post(ctx, "/Users/AuthenticateByName", map[string]string{
+		"Username": username,
+		"Pw":       password,
+	}, &resp)
+	if isStatus(err, http.StatusUnauthorized) {
+		return AuthResult{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("authenticate by name: %w", err)
+	}
+
+	return resp.result()
+}
+
+// InitiateQuickConnect starts a Quick Connect request
+func (c *Client) InitiateQuickConnect(ctx context.Context) (QuickConnect, error) {
+	var resp quickConnectResponse
+	err := c.post(ctx, "/QuickConnect/Initiate", nil, &resp)
+	if isStatus(err, http.StatusUnauthorized) || isStatus(err, http.StatusForbidden) {
+		return QuickConnect{}, ErrQuickConnectDisabled
+	}
+	if err != nil {
+		return QuickConnect{}, fmt.Errorf("initiate quick connect: %w", err)
+	}
+
+	return QuickConnect{Code: resp.Code, Secret: resp.Secret}, nil
+}
+
+// AuthenticateWithQuickConnect exchanges an approved Quick Connect secret for a
+// session. It returns ErrQuickConnectPending until the user has entered the code.
+func (c *Client) AuthenticateWithQuickConnect(ctx context.Context, secret string) (AuthResult, error) {
+	var state quickConnectResponse
+	err := c.getJSON(ctx, "/QuickConnect/Connect?secret="+url.QueryEscape(secret), &state)
+	if isStatus(err, http.StatusNotFound) {
+		return AuthResult{}, ErrQuickConnectNotFound
+	}
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("check quick connect: %w", err)
+	}
+	if !state.Authenticated {
+		return AuthResult{}, ErrQuickConnectPending
+	}
+
+	var resp authenticationResponse
+	err = c.post(ctx, "/Users/AuthenticateWithQuickConnect", map[string]string{
+		"Secret": secret,
+	}, &resp)
+	if err != nil {
+		return AuthResult{}, fmt.Errorf("authenticate with quick connect: %w", err)
+	}
+
+	return resp.result()
+}
+
+// getJSON is an unauthenticated GET, decoded into out
+func (c *Client) getJSON(ctx context.Context, pathAndQuery string, out any) error {
+	resp, err := c.GetAs(ctx, "", pathAndQuery)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode jellyfin response: %w", err)
+	}
+	return nil
+}
+
+func (r authenticationResponse) result() (AuthResult, error) {
+	if r.User.Id == "" || r.AccessToken == "" {
+		return AuthResult{}, errors.New("jellyfin did not return a user and access token")
+	}
+	return AuthResult{
+		UserID:      r.User.Id,
+		Username:    r.User.Name,
+		AccessToken: r.AccessToken,
+	}, nil
+}
+
+func isStatus(err error, status int) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == status
+}
@@ -0,0 +1,324 @@
+package jellyfin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("jellyfin circuit breaker is open, server is considered down")
+
+const (
+	defaultTimeout    = 15 * time.Second
+	defaultMaxRetries = 2
+	baseBackoff       = 250 * time.Millisecond
+
+	// The breaker opens after this many calls in a row fail
+	breakerThreshold = 5
+	// and stays open this long before letting a single trial call through
+	breakerCooldown = 30 * time.Second
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// Health is a snapshot of the client's view of the Jellyfin server, shown on the debug page
+type Health struct {
+	BaseURL             string
+	State               BreakerState
+	ConsecutiveFailures int
+	LastError           string
+	LastErrorAt         time.Time
+	LastSuccessAt       time.Time
+	OpenUntil           time.Time
+}
+
+// Client is the single HTTP client every call to Jellyfin goes through. Each attempt
+// has its own timeout, GETs are retried with backoff on network errors and 5xx
+// responses, and a circuit breaker stops hammering a server that is down.
+type Client struct {
+	baseUrl    string
+	apiKey     string
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	health Health
+	// Only one trial call is let through while half-open
+	trialInFlight bool
+}
+
+func NewClient(baseUrl, apiKey string, timeout time.Duration, logger *slog.Logger) *Client {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Client{
+		baseUrl:    baseUrl,
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+		timeout:    timeout,
+		maxRetries: defaultMaxRetries,
+		logger:     logger,
+		health: Health{
+			BaseURL: baseUrl,
+			State:   BreakerClosed,
+		},
+	}
+}
+
+// Health returns a copy of the current health status
+func (c *Client) Health() Health {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.health
+	if h.State == BreakerOpen && time.Now().After(h.OpenUntil) {
+		h.State = BreakerHalfOpen
+	}
+	return h
+}
+
+// Get performs a GET against the Jellyfin API with the server API key. Any non-2xx
+// status is returned as an *HTTPError. The caller must close the response body.
+func (c *Client) Get(ctx context.Context, pathAndQuery string) (*http.Response, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("jellyfin api key has not been set, set JELLYFIN_API_KEY in your environment")
+	}
+	return c.GetAs(ctx, c.apiKey, pathAndQuery)
+}
+
+// GetAs is Get with a user's access token instead of the server API key, so Jellyfin
+// applies that user's library access and parental controls
+func (c *Client) GetAs(ctx context.Context, token, pathAndQuery string) (*http.Response, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.getWithRetries(ctx, token, pathAndQuery)
+	c.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// post sends a JSON body once and decodes the JSON response into out. Posts are not
+// retried since they are not idempotent, but they still count towards the breaker.
+func (c *Client) post(ctx context.Context, pathAndQuery string, body any, out any) error {
+	if err := c.allow(); err != nil {
+		return err
+	}
+
+	resp, err := c.attempt(ctx, http.MethodPost, "", pathAndQuery, body)
+	c.record(ctx, err)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode jellyfin response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) getWithRetries(ctx context.Context, token, pathAndQuery string) (*http.Response, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff with jitter: 250ms, 500ms, ... plus up to 50%
+			backoff := baseBackoff << (attempt - 1)
+			backoff += time.Duration(rand.Int63n(int64(backoff / 2)))
+			c.logger.Debug("Retrying jellyfin request", "path", pathAndQuery, "attempt", attempt, "backoff", backoff, "error", lastErr)
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		resp, err := c.attempt(ctx, http.MethodGet, token, pathAndQuery, nil)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+
+		if !retryable(ctx, err) {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// attempt makes a single request bounded by the client's timeout. The timeout also
+// covers reading the body, so it is only cancelled when the body is closed. Requests
+// without a token identify Watchma as a client, which Jellyfin needs to hand out one.
+func (c *Client) attempt(ctx context.Context, method, token, pathAndQuery string, body any) (*http.Response, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("encode jellyfin request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, method, c.baseUrl+pathAndQuery, reqBody)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Emby-Token", token)
+	} else {
+		req.Header.Set("Authorization", clientAuthorization)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		cancel()
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Message:    fmt.Sprintf("jellyfin returned status %d: %s (check API key and URL)", resp.StatusCode, resp.Status),
+		}
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryable reports whether a failed attempt is worth repeating: network errors and
+// 5xx responses are, client errors and a cancelled caller are not
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	return true
+}
+
+// isServerFailure reports whether an error means the server is unhealthy, as opposed
+// to a bad request we made (like a wrong API key)
+func isServerFailure(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	return true
+}
+
+// allow checks the circuit breaker before a call
+func (c *Client) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.health.State {
+	case BreakerOpen:
+		if time.Now().Before(c.health.OpenUntil) {
+			return ErrCircuitOpen
+		}
+		c.health.State = BreakerHalfOpen
+		c.trialInFlight = true
+		c.logger.Info("Jellyfin circuit breaker half-open, trying a request")
+		return nil
+	case BreakerHalfOpen:
+		if c.trialInFlight {
+			return ErrCircuitOpen
+		}
+		c.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the circuit breaker with the outcome of a call
+func (c *Client) record(ctx context.Context, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.trialInFlight = false
+
+	// The caller giving up says nothing about the server
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	if err == nil || !isServerFailure(err) {
+		if c.health.State != BreakerClosed {
+			c.logger.Info("Jellyfin circuit breaker closed, server is reachable again")
+		}
+		c.health.State = BreakerClosed
+		c.health.ConsecutiveFailures = 0
+		if err != nil {
+			c.health.LastError = err.Error()
+			c.health.LastErrorAt = time.Now()
+		} else {
+			c.health.LastSuccessAt = time.Now()
+		}
+		return
+	}
+
+	c.health.ConsecutiveFailures++
+	c.health.LastError = err.Error()
+	c.health.LastErrorAt = time.Now()
+
+	if c.health.State == BreakerHalfOpen || c.health.ConsecutiveFailures >= breakerThreshold {
+		c.health.State = BreakerOpen
+		c.health.OpenUntil = time.Now().Add(breakerCooldown)
+		c.logger.Warn("Jellyfin circuit breaker opened",
+			"failures", c.health.ConsecutiveFailures,
+			"cooldown", breakerCooldown,
+			"error", err,
+		)
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
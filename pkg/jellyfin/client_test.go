@@ -0,0 +1,300 @@
+package jellyfin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer answers every request with handler and counts the requests it got
+func testServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, n int32)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, requests.Add(1))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func testClient(baseURL string, timeout time.Duration, maxRetries int) *Client {
+	c := NewClient(baseURL, "test-key", timeout, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.maxRetries = maxRetries
+	return c
+}
+
+func get(t *testing.T, c *Client) error {
+	t.Helper()
+	resp, err := c.Get(context.Background(), "/Items")
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func statusCode(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+func TestGetRetriesServerErrors(t *testing.T) {
+	srv, requests := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Items":[]}`))
+	})
+	c := testClient(srv.URL, time.Second, 2)
+
+	if err := get(t, c); err != nil {
+		t.Fatalf("Get after two 503s: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+	if h := c.Health(); h.State != BreakerClosed || h.ConsecutiveFailures != 0 {
+		t.Errorf("health = %s with %d failures, want closed with 0", h.State, h.ConsecutiveFailures)
+	}
+}
+
+func TestGetGivesUpAfterMaxRetries(t *testing.T) {
+	srv, requests := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	c := testClient(srv.URL, time.Second, 2)
+
+	err := get(t, c)
+	if statusCode(err) != http.StatusInternalServerError {
+		t.Fatalf("err = %v, want a 500 HTTPError", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+	// One failed call, however many attempts it took
+	if h := c.Health(); h.ConsecutiveFailures != 1 {
+		t.Errorf("consecutive failures = %d, want 1", h.ConsecutiveFailures)
+	}
+}
+
+func TestGetDoesNotRetryClientErrors(t *testing.T) {
+	srv, requests := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	c := testClient(srv.URL, time.Second, 2)
+
+	err := get(t, c)
+	if statusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want a 401 HTTPError", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestGetRetriesTimeouts(t *testing.T) {
+	srv, requests := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	c := testClient(srv.URL, 50*time.Millisecond, 1)
+
+	err := get(t, c)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a deadline exceeded", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+	if h := c.Health(); h.ConsecutiveFailures != 1 {
+		t.Errorf("consecutive failures = %d, want 1", h.ConsecutiveFailures)
+	}
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	srv, requests := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	c := testClient(srv.URL, time.Second, 0)
+
+	for i := 1; i < breakerThreshold; i++ {
+		get(t, c)
+		if h := c.Health(); h.State != BreakerClosed {
+			t.Fatalf("after %d failures state = %s, want closed", i, h.State)
+		}
+	}
+	get(t, c)
+	h := c.Health()
+	if h.State != BreakerOpen {
+		t.Fatalf("after %d failures state = %s, want open", breakerThreshold, h.State)
+	}
+	if h.OpenUntil.Before(time.Now().Add(breakerCooldown - time.Second)) {
+		t.Errorf("open until %v, want about %v from now", h.OpenUntil, breakerCooldown)
+	}
+
+	if err := get(t, c); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err while open = %v, want ErrCircuitOpen", err)
+	}
+	if got := requests.Load(); got != breakerThreshold {
+		t.Errorf("requests = %d, want %d, an open breaker shouldn't reach the server", got, breakerThreshold)
+	}
+}
+
+func TestClientErrorsDoNotTripBreaker(t *testing.T) {
+	srv, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	c := testClient(srv.URL, time.Second, 0)
+
+	for i := 0; i < breakerThreshold*2; i++ {
+		get(t, c)
+	}
+	h := c.Health()
+	if h.State != BreakerClosed || h.ConsecutiveFailures != 0 {
+		t.Errorf("health = %s with %d failures, want closed with 0", h.State, h.ConsecutiveFailures)
+	}
+	if h.LastError == "" {
+		t.Error("last error wasn't recorded")
+	}
+}
+
+func TestCancelledCallsDoNotTripBreaker(t *testing.T) {
+	srv, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		<-r.Context().Done()
+	})
+	c := testClient(srv.URL, time.Second, 0)
+
+	for i := 0; i < breakerThreshold; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := c.Get(ctx, "/Items")
+		cancel()
+		if err == nil {
+			t.Fatal("Get succeeded, want the cancelled caller's error")
+		}
+	}
+	if h := c.Health(); h.State != BreakerClosed || h.ConsecutiveFailures != 0 {
+		t.Errorf("health = %s with %d failures, want closed with 0", h.State, h.ConsecutiveFailures)
+	}
+}
+
+// openBreaker trips c's breaker and ends the cooldown, so the next call is the trial
+func openBreaker(t *testing.T, c *Client) {
+	t.Helper()
+	for i := 0; i < breakerThreshold; i++ {
+		get(t, c)
+	}
+	if h := c.Health(); h.State != BreakerOpen {
+		t.Fatalf("state = %s, want open", h.State)
+	}
+	c.mu.Lock()
+	c.health.OpenUntil = time.Now().Add(-time.Millisecond)
+	c.mu.Unlock()
+	if h := c.Health(); h.State != BreakerHalfOpen {
+		t.Fatalf("state after cooldown = %s, want half-open", h.State)
+	}
+}
+
+func TestHalfOpenTrialClosesBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	srv, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Items":[]}`))
+	})
+	c := testClient(srv.URL, time.Second, 0)
+	openBreaker(t, c)
+
+	healthy.Store(true)
+	if err := get(t, c); err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	if h := c.Health(); h.State != BreakerClosed || h.ConsecutiveFailures != 0 {
+		t.Errorf("health = %s with %d failures, want closed with 0", h.State, h.ConsecutiveFailures)
+	}
+}
+
+func TestHalfOpenTrialFailureReopensBreaker(t *testing.T) {
+	srv, requests := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c := testClient(srv.URL, time.Second, 0)
+	openBreaker(t, c)
+
+	if err := get(t, c); statusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("trial err = %v, want a 503 HTTPError", err)
+	}
+	if h := c.Health(); h.State != BreakerOpen {
+		t.Fatalf("state after failed trial = %s, want open", h.State)
+	}
+	if err := get(t, c); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err after failed trial = %v, want ErrCircuitOpen", err)
+	}
+	if got := requests.Load(); got != breakerThreshold+1 {
+		t.Errorf("requests = %d, want %d", got, breakerThreshold+1)
+	}
+}
+
+func TestHalfOpenLetsOneTrialThrough(t *testing.T) {
+	var healthy atomic.Bool
+	release := make(chan struct{})
+	srv, _ := testServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-release
+		w.Write([]byte(`{"Items":[]}`))
+	})
+	c := testClient(srv.URL, time.Second, 0)
+	openBreaker(t, c)
+	healthy.Store(true)
+
+	trial := make(chan error)
+	go func() {
+		resp, err := c.Get(context.Background(), "/Items")
+		if err == nil {
+			resp.Body.Close()
+		}
+		trial <- err
+	}()
+
+	// Wait for the trial to take the slot
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		inFlight := c.trialInFlight
+		c.mu.Unlock()
+		if inFlight {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("trial call never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := get(t, c); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second call during trial err = %v, want ErrCircuitOpen", err)
+	}
+
+	close(release)
+	if err := <-trial; err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	if h := c.Health(); h.State != BreakerClosed {
+		t.Errorf("state after trial = %s, want closed", h.State)
+	}
+}
@@ -0,0 +1,23 @@
+package jellyfin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// FetchImage downloads the full size primary image of an item. Jellyfin is asked for a
+// JPEG so the image cache can always decode it.
+func (p *JellyfinMovieProvider) FetchImage(ctx context.Context, itemId, tag string) (io.ReadCloser, error) {
+	pathAndQuery := fmt.Sprintf("/Items/%s/Images/Primary?format=Jpg&tag=%s",
+		url.PathEscape(itemId), url.QueryEscape(tag))
+
+	resp, err := p.client.Get(ctx, pathAndQuery)
+	if err != nil {
+		p.logger.Warn("Failed to fetch jellyfin image", "error", err, "itemId", itemId)
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
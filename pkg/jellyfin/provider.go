@@ -1,13 +1,20 @@
 package jellyfin
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"time"
 	"watchma/pkg/movie"
 )
 
+const moviesQuery = "/Items?IncludeItemTypes=Movie&Recursive=true"
+
+// Jellyfin runtimes are in ticks of 100 nanoseconds
+const ticksPerMinute = int64(time.Minute / 100)
+
 // HTTPError represents an HTTP error with status code
 type HTTPError struct {
 	StatusCode int
@@ -27,6 +34,9 @@ type jellyfinItem struct {
 	CriticRating    int     `json:"CriticRating"`
 	CommunityRating float64 `json:"CommunityRating"`
 	ProductionYear  int     `json:"ProductionYear"`
+	OfficialRating  string  `json:"OfficialRating"`
+	Overview        string  `json:"Overview"`
+	RunTimeTicks    int64   `json:"RunTimeTicks"`
 	ImageTags       struct {
 		Primary string `json:"Primary"`
 	} `json:"ImageTags"`
@@ -38,75 +48,167 @@ type jellyfinResponse struct {
 }
 
 type JellyfinMovieProvider struct {
-	apiKey     string
-	baseUrl    string
-	httpClient *http.Client
-	logger     *slog.Logger
+	client *Client
+	logger *slog.Logger
 }
 
-func NewJellyfinMovieProvider(apiKey string, apiUrl string, logger *slog.Logger) *JellyfinMovieProvider {
+func NewJellyfinMovieProvider(client *Client, logger *slog.Logger) *JellyfinMovieProvider {
 	return &JellyfinMovieProvider{
-		apiKey:     apiKey,
-		baseUrl:    apiUrl,
-		logger:     logger,
-		httpClient: http.DefaultClient,
+		client: client,
+		logger: logger,
 	}
 }
 
-func (p *JellyfinMovieProvider) FetchMovies() ([]movie.Movie, error) {
+func (p *JellyfinMovieProvider) Name() string {
+	return "jellyfin"
+}
+
+func (p *JellyfinMovieProvider) FetchMovies(ctx context.Context) ([]movie.Movie, error) {
 	p.logger.Debug("Fetching Jellyfin movies")
-	req, err := p.makeRequest("GET", "/Items?IncludeItemTypes=Movie&Recursive=true&Fields=Genres")
+	items, err := p.fetchItems(ctx, moviesQuery+"&Fields=Genres,Overview")
 	if err != nil {
-		p.logger.Error("Error parsing jellyfin movie request", "error", err)
 		return nil, err
 	}
 
-	resp, err := p.httpClient.Do(req)
+	movies := make([]movie.Movie, 0, len(items))
+	for _, i := range items {
+		movies = append(movies, toMovie(i))
+	}
+
+	return movies, nil
+}
+
+// FetchMoviesSince only returns movies whose metadata was saved at or after since
+func (p *JellyfinMovieProvider) FetchMoviesSince(ctx context.Context, since time.Time) ([]movie.Movie, error) {
+	p.logger.Debug("Fetching Jellyfin movies since", "since", since)
+	minDate := url.QueryEscape(since.UTC().Format(time.RFC3339))
+	items, err := p.fetchItems(ctx, moviesQuery+"&Fields=Genres,Overview&MinDateLastSaved="+minDate)
 	if err != nil {
-		p.logger.Error("Error fetching jellyfin movies", "error", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		p.logger.Error("Jellyfin returned error status",
-			"status_code", resp.StatusCode,
-			"status", resp.Status,
-			"reqUrl", req.URL,
-		)
-		return nil, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Status:     resp.Status,
-			Message:    fmt.Sprintf("jellyfin returned status %d: %s (check API key and URL)", resp.StatusCode, resp.Status),
-		}
+	movies := make([]movie.Movie, 0, len(items))
+	for _, i := range items {
+		movies = append(movies, toMovie(i))
 	}
 
-	var result jellyfinResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		p.logger.Error("Error decoding jellyfin movies", "error", err, "status_code", resp.StatusCode)
+	return movies, nil
+}
+
+// FetchMovieIDs returns the IDs of every movie in the library, without images or extra fields
+func (p *JellyfinMovieProvider) FetchMovieIDs(ctx context.Context) ([]string, error) {
+	p.logger.Debug("Fetching Jellyfin movie IDs")
+	items, err := p.fetchItems(ctx, moviesQuery+"&EnableImages=false&EnableUserData=false")
+	if err != nil {
 		return nil, err
 	}
 
-	movies := make([]movie.Movie, 0, len(result.Items))
-	for _, i := range result.Items {
-		movies = append(movies, toMovie(i))
+	ids := make([]string, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.Id)
 	}
 
-	return movies, nil
+	return ids, nil
+}
+
+// FetchVisibleMovieIDs returns the IDs of the movies a Jellyfin user is allowed to see,
+// asked for with their own access token
+func (p *JellyfinMovieProvider) FetchVisibleMovieIDs(ctx context.Context, jellyfinUserID, token string) ([]string, error) {
+	p.logger.Debug("Fetching visible Jellyfin movie IDs", "jellyfinUserId", jellyfinUserID)
+	path := "/Users/" + url.PathEscape(jellyfinUserID) + moviesQuery + "&EnableImages=false&EnableUserData=false"
+	items, err := p.fetchItemsAs(ctx, token, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.Id)
+	}
+
+	return ids, nil
 }
 
-func (p *JellyfinMovieProvider) makeRequest(method string, pathAndQuery string) (*http.Request, error) {
-	if p.apiKey == "" {
-		return nil, fmt.Errorf("jellyfin api_key has not been set in settings.json")
+// Jellyfin's item type of each kind of list
+var listItemTypes = map[movie.ListKind]string{
+	movie.Collection: "BoxSet",
+	movie.Playlist:   "Playlist",
+}
+
+// FetchLists returns the collections, and the playlists of jellyfinUserID when one is
+// given. Jellyfin doesn't include a list's movies with it, so they're asked for per list.
+func (p *JellyfinMovieProvider) FetchLists(ctx context.Context, jellyfinUserID, token string) ([]movie.ProviderList, error) {
+	p.logger.Debug("Fetching Jellyfin lists", "jellyfinUserId", jellyfinUserID)
+
+	fetch := p.fetchItems
+	prefix := ""
+	kinds := []movie.ListKind{movie.Collection}
+	if jellyfinUserID != "" {
+		fetch = func(ctx context.Context, pathAndQuery string) ([]jellyfinItem, error) {
+			return p.fetchItemsAs(ctx, token, pathAndQuery)
+		}
+		prefix = "/Users/" + url.PathEscape(jellyfinUserID)
+		kinds = append(kinds, movie.Playlist)
+	}
+
+	var lists []movie.ProviderList
+	for _, kind := range kinds {
+		items, err := fetch(ctx, prefix+"/Items?Recursive=true&EnableImages=false&EnableUserData=false&IncludeItemTypes="+listItemTypes[kind])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			movies, err := fetch(ctx, prefix+moviesQuery+"&EnableImages=false&EnableUserData=false&ParentId="+url.QueryEscape(item.Id))
+			if err != nil {
+				return nil, err
+			}
+
+			ids := make([]string, 0, len(movies))
+			for _, m := range movies {
+				ids = append(ids, m.Id)
+			}
+			lists = append(lists, movie.ProviderList{
+				ID:       item.Id,
+				Name:     item.Name,
+				Kind:     kind,
+				MovieIDs: ids,
+			})
+		}
 	}
 
-	req, err := http.NewRequest(method, p.baseUrl+pathAndQuery, nil)
+	return lists, nil
+}
+
+func (p *JellyfinMovieProvider) fetchItems(ctx context.Context, pathAndQuery string) ([]jellyfinItem, error) {
+	resp, err := p.client.Get(ctx, pathAndQuery)
 	if err != nil {
+		p.logger.Error("Error fetching jellyfin movies", "error", err, "path", pathAndQuery)
+		return nil, err
+	}
+	return p.decodeItems(resp)
+}
+
+// fetchItemsAs is fetchItems with a user's access token
+func (p *JellyfinMovieProvider) fetchItemsAs(ctx context.Context, token, pathAndQuery string) ([]jellyfinItem, error) {
+	resp, err := p.client.GetAs(ctx, token, pathAndQuery)
+	if err != nil {
+		p.logger.Error("Error fetching jellyfin movies as user", "error", err, "path", pathAndQuery)
+		return nil, err
+	}
+	return p.decodeItems(resp)
+}
+
+func (p *JellyfinMovieProvider) decodeItems(resp *http.Response) ([]jellyfinItem, error) {
+	defer resp.Body.Close()
+
+	var result jellyfinResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		p.logger.Error("Error decoding jellyfin movies", "error", err, "status_code", resp.StatusCode)
 		return nil, err
 	}
 
-	req.Header.Set("X-Emby-Token", p.apiKey)
-	return req, nil
+	return result.Items, nil
 }
 
 func toMovie(item jellyfinItem) movie.Movie {
@@ -116,8 +218,11 @@ func toMovie(item jellyfinItem) movie.Movie {
 		Genres:          item.Genres,
 		Id:              item.Id,
 		Name:            item.Name,
+		OfficialRating:  item.OfficialRating,
+		Overview:        item.Overview,
 		PremiereDate:    item.PremiereDate,
 		PrimaryImageTag: item.ImageTags.Primary,
 		ProductionYear:  item.ProductionYear,
+		RuntimeMinutes:  int(item.RunTimeTicks / ticksPerMinute),
 	}
 }
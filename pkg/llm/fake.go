@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// defaultFakeResponse is a one liner, like the host's comments
+	defaultFakeResponse = `Popcorn is popped, the lights are low, and the votes are in.`
+	// defaultFakeJSONResponse is a scene matching the announcement schema, used when a
+	// request asks for structured output
+	defaultFakeJSONResponse = `{"mood": "dramatic", "characters": ["Narrator", "Critic"], "lines": [
+  {"character": "Narrator", "text": "Popcorn is popped, the lights are low."},
+  {"character": "Critic", "text": "I have seen every frame, and I can tell you one thing."},
+  {"character": "Narrator", "text": "The votes are in."}
+]}`
+)
+
+// Fake returns canned responses in order, then repeats the last one. It never touches
+// the network, so the game can be run and tested without a model.
+type Fake struct {
+	mu        sync.Mutex
+	responses []string
+	calls     int
+	// TokenDelay is waited between the words of a streamed response
+	TokenDelay time.Duration
+	// Requests records every request made, for tests to inspect
+	Requests []Request
+}
+
+// NewFake returns a Fake that answers with responses. If none are given it answers
+// with a short line, or an announcement scene when the request has a schema.
+func NewFake(responses ...string) *Fake {
+	return &Fake{responses: responses}
+}
+
+func (f *Fake) Name() string {
+	return BackendFake
+}
+
+func (f *Fake) Complete(ctx context.Context, req Request) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Requests = append(f.Requests, req)
+	f.calls++
+
+	var content string
+	switch {
+	case len(f.responses) > 0:
+		content = f.responses[min(f.calls, len(f.responses))-1]
+	case req.Schema != nil:
+		content = defaultFakeJSONResponse
+	default:
+		content = defaultFakeResponse
+	}
+
+	return Response{
+		Content:          content,
+		Model:            BackendFake,
+		PromptTokens:     countWords(req.Messages),
+		CompletionTokens: len(strings.Fields(content)),
+	}, nil
+}
+
+// Stream sends the next canned response a word at a time, keeping the whitespace
+// so the deltas add up to exactly the content
+func (f *Fake) Stream(ctx context.Context, req Request, onDelta func(delta string)) (Response, error) {
+	resp, err := f.Complete(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	rest := resp.Content
+	for rest != "" {
+		// Cut after the next run of whitespace
+		i := strings.IndexFunc(rest, unicode.IsSpace)
+		if i == -1 {
+			i = len(rest)
+		}
+		for i < len(rest) && unicode.IsSpace(rune(rest[i])) {
+			i++
+		}
+
+		// Checked first, select picks at random when both are ready
+		if err := ctx.Err(); err != nil {
+			return Response{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		case <-time.After(f.TokenDelay):
+		}
+
+		onDelta(rest[:i])
+		rest = rest[i:]
+	}
+
+	return resp, nil
+}
+
+// countWords stands in for a tokenizer, close enough for a fake
+func countWords(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += len(strings.Fields(m.Content))
+	}
+	return n
+}
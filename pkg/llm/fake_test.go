@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFakeRepeatsLastResponse(t *testing.T) {
+	f := NewFake("first", "second")
+
+	var got []string
+	for range 3 {
+		resp, err := f.Complete(context.Background(), UserPrompt("hi"))
+		if err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		got = append(got, resp.Content)
+	}
+	if strings.Join(got, ",") != "first,second,second" {
+		t.Errorf("responses = %v, want first,second,second", got)
+	}
+	if len(f.Requests) != 3 {
+		t.Errorf("recorded %d requests, want 3", len(f.Requests))
+	}
+}
+
+func TestFakeDefaultsToSceneForSchema(t *testing.T) {
+	f := NewFake()
+
+	req := UserPrompt("Announce it")
+	req.Schema = testSchema
+	resp, _ := f.Complete(context.Background(), req)
+	if resp.Content != defaultFakeJSONResponse {
+		t.Errorf("content = %q, want the default scene", resp.Content)
+	}
+
+	resp, _ = f.Complete(context.Background(), UserPrompt("Say hi"))
+	if resp.Content != defaultFakeResponse {
+		t.Errorf("content = %q, want the default line", resp.Content)
+	}
+}
+
+func TestFakeStream(t *testing.T) {
+	content := "Popcorn  is\npopped."
+	f := NewFake(content)
+
+	var deltas []string
+	resp, err := f.Stream(context.Background(), UserPrompt("hi"), func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(deltas) != 3 || strings.Join(deltas, "") != content {
+		t.Errorf("deltas = %q, want three words adding up to the content", deltas)
+	}
+	if resp.Content != content || resp.CompletionTokens != 3 {
+		t.Errorf("response = %+v, want the content and 3 completion tokens", resp)
+	}
+}
+
+func TestFakeStreamCancelled(t *testing.T) {
+	f := NewFake()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := f.Stream(ctx, UserPrompt("hi"), func(string) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
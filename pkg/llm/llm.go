@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Backends that can be selected with LLM_BACKEND
+const (
+	BackendOpenAI = "openai" // OpenAI or any OpenAI-compatible server (llama.cpp, vLLM, LM Studio...)
+	BackendOllama = "ollama" // Ollama's native chat API
+	BackendFake   = "fake"   // Canned responses, no network
+)
+
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role
+	Content string
+}
+
+type Request struct {
+	Messages []Message
+	// Schema asks for a JSON response matching it. Backends without structured output
+	// ignore it, so callers must still validate what comes back.
+	Schema *JSONSchema
+	// Purpose and Room label the call in usage accounting, backends ignore them
+	Purpose string
+	Room    string
+}
+
+// Purposes of the calls Watchma makes, for usage accounting
+const (
+	PurposeAnnouncement = "announcement"
+	PurposeHostComment  = "host_comment"
+	PurposeSuggestions  = "suggestions"
+	PurposeEmbeddings   = "embeddings" // Made with an embedding.Embedder, not a Provider
+)
+
+// JSONSchema is a named JSON schema for structured output
+type JSONSchema struct {
+	Name   string
+	Schema json.RawMessage
+}
+
+type Response struct {
+	Content          string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Provider is a chat completion backend. Everything in Watchma that talks to a
+// language model goes through this interface.
+type Provider interface {
+	// Name identifies the backend in logs
+	Name() string
+	Complete(ctx context.Context, req Request) (Response, error)
+	// Stream is Complete, but calls onDelta with each piece of the response as it is
+	// generated. The returned Response holds the whole content.
+	Stream(ctx context.Context, req Request, onDelta func(delta string)) (Response, error)
+}
+
+// Config is shared by every backend. Backends ignore what they don't support.
+type Config struct {
+	Backend     string
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature float64
+	MaxTokens   int
+	Timeout     time.Duration
+}
+
+// New returns the Provider for cfg.Backend
+func New(cfg Config, logger *slog.Logger) (Provider, error) {
+	switch cfg.Backend {
+	case BackendOpenAI:
+		return NewOpenAI(cfg, logger), nil
+	case BackendOllama:
+		return NewOllama(cfg, logger), nil
+	case BackendFake:
+		fake := NewFake()
+		// Pretend to type so streaming can be seen without a model
+		fake.TokenDelay = 50 * time.Millisecond
+		return fake, nil
+	default:
+		return nil, fmt.Errorf("unknown llm backend %q", cfg.Backend)
+	}
+}
+
+// UserPrompt is a request with a single user message
+func UserPrompt(content string) Request {
+	return Request{Messages: []Message{{Role: RoleUser, Content: content}}}
+}
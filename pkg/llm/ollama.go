@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultOllamaBaseURL = "http://localhost:11434"
+	DefaultOllamaModel   = "llama3.2"
+)
+
+// Ollama talks to Ollama's native /api/chat endpoint
+type Ollama struct {
+	cfg        Config
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options"`
+	// Format takes a JSON schema to constrain the response to
+	Format json.RawMessage `json:"format,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model           string      `json:"model"`
+	Message         chatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+}
+
+func NewOllama(cfg Config, logger *slog.Logger) *Ollama {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultOllamaBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultOllamaModel
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &Ollama{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		logger:     logger,
+	}
+}
+
+func (o *Ollama) Name() string {
+	return BackendOllama
+}
+
+func (o *Ollama) Complete(ctx context.Context, req Request) (Response, error) {
+	resp, err := o.post(ctx, req, false)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	o.logger.Debug("LLM Response",
+		"backend", o.Name(),
+		"model", result.Model,
+		"completion_tokens", result.EvalCount,
+		"prompt_tokens", result.PromptEvalCount,
+	)
+
+	return Response{
+		Content:          result.Message.Content,
+		Model:            result.Model,
+		PromptTokens:     result.PromptEvalCount,
+		CompletionTokens: result.EvalCount,
+	}, nil
+}
+
+// Stream reads Ollama's newline delimited JSON, one object per piece of content with
+// the token counts on the last one
+func (o *Ollama) Stream(ctx context.Context, req Request, onDelta func(delta string)) (Response, error) {
+	resp, err := o.post(ctx, req, true)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var result Response
+	var content strings.Builder
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaChatResponse
+		if err := decoder.Decode(&chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			return Response{}, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			result.Model = chunk.Model
+			result.PromptTokens = chunk.PromptEvalCount
+			result.CompletionTokens = chunk.EvalCount
+			break
+		}
+	}
+
+	result.Content = content.String()
+	o.logger.Debug("LLM Stream finished",
+		"backend", o.Name(),
+		"model", result.Model,
+		"completion_tokens", result.CompletionTokens,
+		"prompt_tokens", result.PromptTokens,
+	)
+
+	return result, nil
+}
+
+// post sends a chat request and returns the response if it was a 200
+func (o *Ollama) post(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	body := ollamaChatRequest{
+		Model:    o.cfg.Model,
+		Messages: toChatMessages(req.Messages),
+		Stream:   stream,
+		Options: ollamaOptions{
+			Temperature: o.cfg.Temperature,
+			NumPredict:  o.cfg.MaxTokens,
+		},
+	}
+	if req.Schema != nil {
+		body.Format = req.Schema.Schema
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/api/chat", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		o.logger.Error("LLM request failed", "backend", o.Name(), "error", err)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var errorBody map[string]any
+		json.NewDecoder(resp.Body).Decode(&errorBody)
+		o.logger.Error("LLM API returned error",
+			"backend", o.Name(),
+			"status_code", resp.StatusCode,
+			"status", resp.Status,
+			"error", errorBody,
+		)
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOllamaComplete(t *testing.T) {
+	srv := chatServer(t, "/api/chat", func(w http.ResponseWriter, body ollamaChatRequest) {
+		if body.Model != "test-model" || body.Stream || body.Options.NumPredict != 64 {
+			t.Errorf("body = %+v, want test-model, not streamed, with num_predict", body)
+		}
+		if string(body.Format) != `{"type":"object"}` {
+			t.Errorf("format = %s, want the schema", body.Format)
+		}
+		w.Write([]byte(`{"model": "test-model", "message": {"role": "assistant", "content": "Lights down."}, "done": true, "prompt_eval_count": 12, "eval_count": 3}`))
+	})
+
+	o := NewOllama(Config{BaseURL: srv.URL, Model: "test-model", MaxTokens: 64}, testLogger)
+	req := UserPrompt("Announce it")
+	req.Schema = testSchema
+	resp, err := o.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	want := Response{Content: "Lights down.", Model: "test-model", PromptTokens: 12, CompletionTokens: 3}
+	if resp != want {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+}
+
+func TestOllamaCompleteErrorStatus(t *testing.T) {
+	srv := chatServer(t, "/api/chat", func(w http.ResponseWriter, body ollamaChatRequest) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "model \"test-model\" not found"}`))
+	})
+
+	o := NewOllama(Config{BaseURL: srv.URL}, testLogger)
+	_, err := o.Complete(context.Background(), UserPrompt("hi"))
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("err = %v, want a 404 error", err)
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	srv := chatServer(t, "/api/chat", func(w http.ResponseWriter, body ollamaChatRequest) {
+		if !body.Stream {
+			t.Errorf("body = %+v, want a stream", body)
+		}
+		w.Write([]byte(`{"model": "test-model", "message": {"role": "assistant", "content": "Lights"}, "done": false}
+{"model": "test-model", "message": {"role": "assistant", "content": " down."}, "done": false}
+{"model": "test-model", "message": {"role": "assistant", "content": ""}, "done": true, "prompt_eval_count": 12, "eval_count": 3}
+`))
+	})
+
+	o := NewOllama(Config{BaseURL: srv.URL}, testLogger)
+	var deltas []string
+	resp, err := o.Stream(context.Background(), UserPrompt("Announce it"), func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Lights| down." {
+		t.Errorf("deltas = %q, want the two content pieces", deltas)
+	}
+	want := Response{Content: "Lights down.", Model: "test-model", PromptTokens: 12, CompletionTokens: 3}
+	if resp != want {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+}
+
+func TestOllamaStreamBadChunk(t *testing.T) {
+	srv := chatServer(t, "/api/chat", func(w http.ResponseWriter, body ollamaChatRequest) {
+		w.Write([]byte("{\"message\": {\"content\": \"Lights\"}, \"done\": false}\n{oops\n"))
+	})
+
+	o := NewOllama(Config{BaseURL: srv.URL}, testLogger)
+	_, err := o.Stream(context.Background(), UserPrompt("hi"), func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "failed to decode stream chunk") {
+		t.Errorf("err = %v, want a stream chunk decode error", err)
+	}
+}
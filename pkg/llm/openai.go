@@ -0,0 +1,264 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAI talks to the chat completions endpoint of OpenAI or any server that copies
+// its API, like llama.cpp's server or Ollama's /v1 compatibility layer
+type OpenAI struct {
+	cfg        Config
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string             `json:"type"`
+	JSONSchema responseJSONSchema `json:"json_schema"`
+}
+
+type responseJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatMessage struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage chatUsage `json:"usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// chatCompletionChunk is one server-sent event of a streamed completion
+type chatCompletionChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta chatMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+func NewOpenAI(cfg Config, logger *slog.Logger) *OpenAI {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultOpenAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultOpenAIModel
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &OpenAI{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		logger:     logger,
+	}
+}
+
+func (o *OpenAI) Name() string {
+	return BackendOpenAI
+}
+
+func (o *OpenAI) Complete(ctx context.Context, req Request) (Response, error) {
+	resp, err := o.post(ctx, chatCompletionRequest{
+		Model:          o.cfg.Model,
+		Messages:       toChatMessages(req.Messages),
+		Temperature:    o.cfg.Temperature,
+		MaxTokens:      o.cfg.MaxTokens,
+		ResponseFormat: toResponseFormat(req.Schema),
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var result chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Choices) == 0 {
+		return Response{}, fmt.Errorf("no choices returned from %s", o.cfg.BaseURL)
+	}
+
+	o.logger.Debug("LLM Response",
+		"backend", o.Name(),
+		"id", result.ID,
+		"model", result.Model,
+		"completion_tokens", result.Usage.CompletionTokens,
+		"prompt_tokens", result.Usage.PromptTokens,
+	)
+
+	return Response{
+		Content:          result.Choices[0].Message.Content,
+		Model:            result.Model,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+	}, nil
+}
+
+// Stream asks for the completion as server-sent events, each "data:" line holds a
+// chunk with the next piece of content, until "data: [DONE]"
+func (o *OpenAI) Stream(ctx context.Context, req Request, onDelta func(delta string)) (Response, error) {
+	resp, err := o.post(ctx, chatCompletionRequest{
+		Model:          o.cfg.Model,
+		Messages:       toChatMessages(req.Messages),
+		Temperature:    o.cfg.Temperature,
+		MaxTokens:      o.cfg.MaxTokens,
+		Stream:         true,
+		StreamOptions:  &streamOptions{IncludeUsage: true},
+		ResponseFormat: toResponseFormat(req.Schema),
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var result Response
+	var content strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return Response{}, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.PromptTokens = chunk.Usage.PromptTokens
+			result.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			content.WriteString(chunk.Choices[0].Delta.Content)
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	result.Content = content.String()
+	o.logger.Debug("LLM Stream finished",
+		"backend", o.Name(),
+		"model", result.Model,
+		"completion_tokens", result.CompletionTokens,
+		"prompt_tokens", result.PromptTokens,
+	)
+
+	return result, nil
+}
+
+// post sends a chat completion request and returns the response if it was a 200
+func (o *OpenAI) post(ctx context.Context, body chatCompletionRequest) (*http.Response, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	// Local servers usually don't need a key
+	if o.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		o.logger.Error("LLM request failed", "backend", o.Name(), "error", err)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var errorBody map[string]any
+		json.NewDecoder(resp.Body).Decode(&errorBody)
+		o.logger.Error("LLM API returned error",
+			"backend", o.Name(),
+			"status_code", resp.StatusCode,
+			"status", resp.Status,
+			"error", errorBody,
+		)
+		return nil, fmt.Errorf("LLM API returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return resp, nil
+}
+
+func toChatMessages(messages []Message) []chatMessage {
+	out := make([]chatMessage, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, chatMessage{Role: m.Role, Content: m.Content})
+	}
+	return out
+}
+
+// toResponseFormat turns on strict structured output when the request has a schema
+func toResponseFormat(schema *JSONSchema) *responseFormat {
+	if schema == nil {
+		return nil
+	}
+	return &responseFormat{
+		Type: "json_schema",
+		JSONSchema: responseJSONSchema{
+			Name:   schema.Name,
+			Schema: schema.Schema,
+			Strict: true,
+		},
+	}
+}
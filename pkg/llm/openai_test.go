@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+var testSchema = &JSONSchema{Name: "scene", Schema: json.RawMessage(`{"type":"object"}`)}
+
+// chatServer serves respond on path, handing it the decoded request body
+func chatServer[T any](t *testing.T, path string, respond func(w http.ResponseWriter, body T)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("request = %s %s, want POST %s", r.Method, r.URL.Path, path)
+			http.NotFound(w, r)
+			return
+		}
+		var body T
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request body: %v", err)
+		}
+		respond(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAIComplete(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var body chatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "test-model" || body.Stream || len(body.Messages) != 1 {
+			t.Errorf("body = %+v, want one message for test-model, not streamed", body)
+		}
+		if body.ResponseFormat == nil || body.ResponseFormat.JSONSchema.Name != "scene" || !body.ResponseFormat.JSONSchema.Strict {
+			t.Errorf("response format = %+v, want the strict scene schema", body.ResponseFormat)
+		}
+		w.Write([]byte(`{"id": "chatcmpl-1", "model": "test-model-0125", "choices": [
+  {"message": {"role": "assistant", "content": "Lights down."}, "finish_reason": "stop"}
+], "usage": {"prompt_tokens": 12, "completion_tokens": 3}}`))
+	}))
+	defer srv.Close()
+
+	o := NewOpenAI(Config{BaseURL: srv.URL + "/", APIKey: "sk-test", Model: "test-model"}, testLogger)
+	req := UserPrompt("Announce it")
+	req.Schema = testSchema
+	resp, err := o.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	want := Response{Content: "Lights down.", Model: "test-model-0125", PromptTokens: 12, CompletionTokens: 3}
+	if resp != want {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+	if auth != "Bearer sk-test" {
+		t.Errorf("Authorization = %q, want the API key", auth)
+	}
+}
+
+func TestOpenAICompleteErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"error status", http.StatusTooManyRequests, `{"error": {"message": "slow down"}}`, "status 429"},
+		{"no choices", http.StatusOK, `{"choices": []}`, "no choices"},
+		{"bad json", http.StatusOK, `not json`, "failed to decode response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			o := NewOpenAI(Config{BaseURL: srv.URL}, testLogger)
+			_, err := o.Complete(context.Background(), UserPrompt("hi"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	srv := chatServer(t, "/chat/completions", func(w http.ResponseWriter, body chatCompletionRequest) {
+		if !body.Stream || body.StreamOptions == nil || !body.StreamOptions.IncludeUsage {
+			t.Errorf("body = %+v, want a stream with usage", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"model": "test-model", "choices": [{"delta": {"role": "assistant"}}]}
+
+data: {"choices": [{"delta": {"content": "Lights"}}]}
+
+: keep-alive
+data: {"choices": [{"delta": {"content": " down."}}]}
+
+data: {"choices": [], "usage": {"prompt_tokens": 12, "completion_tokens": 3}}
+
+data: [DONE]
+
+`))
+	})
+
+	o := NewOpenAI(Config{BaseURL: srv.URL}, testLogger)
+	var deltas []string
+	resp, err := o.Stream(context.Background(), UserPrompt("Announce it"), func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	if strings.Join(deltas, "|") != "Lights| down." {
+		t.Errorf("deltas = %q, want the two content pieces", deltas)
+	}
+	want := Response{Content: "Lights down.", Model: "test-model", PromptTokens: 12, CompletionTokens: 3}
+	if resp != want {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+}
+
+func TestOpenAIStreamBadChunk(t *testing.T) {
+	srv := chatServer(t, "/chat/completions", func(w http.ResponseWriter, body chatCompletionRequest) {
+		w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"Lights\"}}]}\n\ndata: {oops\n\n"))
+	})
+
+	o := NewOpenAI(Config{BaseURL: srv.URL}, testLogger)
+	_, err := o.Stream(context.Background(), UserPrompt("hi"), func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "failed to decode stream chunk") {
+		t.Errorf("err = %v, want a stream chunk decode error", err)
+	}
+}
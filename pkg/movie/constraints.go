@@ -0,0 +1,147 @@
+package movie
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Genres are the genres rooms can filter and limit their draft by
+var Genres = []string{
+	"Action",
+	"Adventure",
+	"Animation",
+	"Comedy",
+	"Crime",
+	"Documentary",
+	"Drama",
+	"Family",
+	"Fantasy",
+	"History",
+	"Horror",
+	"Music",
+	"Mystery",
+	"Romance",
+	"Science Fiction",
+	"Thriller",
+	"TV Movie",
+	"War",
+	"Western",
+}
+
+// Ratings are the official ratings a room can cap its draft at, mildest first
+var Ratings = []string{"G", "PG", "PG-13", "R", "NC-17"}
+
+// ratingLevels places official ratings on the scale of Ratings. TV ratings count as
+// their movie equivalent, anything else is unknown.
+var ratingLevels = map[string]int{
+	"G":     0,
+	"TV-Y":  0,
+	"TV-Y7": 0,
+	"TV-G":  0,
+	"PG":    1,
+	"TV-PG": 1,
+	"PG-13": 2,
+	"TV-14": 2,
+	"R":     3,
+	"TV-MA": 3,
+	"NC-17": 4,
+}
+
+// Constraints limit the movies a room drafts from, the zero value allows every movie.
+// Movies missing what a constraint checks, like an unrated movie when the rating is
+// capped, are left out.
+type Constraints struct {
+	Genres     []string // Movies with any of these genres, empty is any genre
+	MaxRating  string   // Highest of Ratings allowed, empty is any rating
+	MinYear    int      // Released in or after, 0 is no limit
+	MaxYear    int      // Released in or before, 0 is no limit
+	MaxRuntime int      // In minutes, 0 is no limit
+}
+
+// IsZero reports whether c allows every movie
+func (c Constraints) IsZero() bool {
+	return len(c.Genres) == 0 && c.MaxRating == "" && c.MinYear == 0 && c.MaxYear == 0 && c.MaxRuntime == 0
+}
+
+// Validate checks c only uses known genres and ratings and its years are in order
+func (c Constraints) Validate() error {
+	for _, g := range c.Genres {
+		if !slices.Contains(Genres, g) {
+			return fmt.Errorf("unknown genre %q", g)
+		}
+	}
+	if c.MaxRating != "" && !slices.Contains(Ratings, c.MaxRating) {
+		return fmt.Errorf("unknown rating %q", c.MaxRating)
+	}
+	if c.MinYear < 0 || c.MaxYear < 0 || c.MaxRuntime < 0 {
+		return fmt.Errorf("years and runtime can't be negative")
+	}
+	if c.MinYear != 0 && c.MaxYear != 0 && c.MinYear > c.MaxYear {
+		return fmt.Errorf("the earliest year is after the latest")
+	}
+	return nil
+}
+
+// Allows reports whether m is in the pool c describes
+func (c Constraints) Allows(m Movie) bool {
+	if len(c.Genres) > 0 && !slices.ContainsFunc(m.Genres, func(g string) bool { return slices.Contains(c.Genres, g) }) {
+		return false
+	}
+	if c.MaxRating != "" {
+		level, ok := ratingLevels[strings.ToUpper(strings.TrimSpace(m.OfficialRating))]
+		if !ok || level > ratingLevels[c.MaxRating] {
+			return false
+		}
+	}
+	if c.MinYear != 0 && (m.ProductionYear == 0 || m.ProductionYear < c.MinYear) {
+		return false
+	}
+	if c.MaxYear != 0 && (m.ProductionYear == 0 || m.ProductionYear > c.MaxYear) {
+		return false
+	}
+	if c.MaxRuntime != 0 && (m.RuntimeMinutes == 0 || m.RuntimeMinutes > c.MaxRuntime) {
+		return false
+	}
+	return true
+}
+
+// Filter keeps the movies c allows
+func (c Constraints) Filter(movies []Movie) []Movie {
+	if c.IsZero() {
+		return movies
+	}
+	allowed := make([]Movie, 0, len(movies))
+	for _, m := range movies {
+		if c.Allows(m) {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+// String describes the pool for the lobby, like "Horror, up to PG-13, 1980 to 1999"
+func (c Constraints) String() string {
+	if c.IsZero() {
+		return "Every movie"
+	}
+	var parts []string
+	if len(c.Genres) > 0 {
+		parts = append(parts, strings.Join(c.Genres, " or "))
+	}
+	if c.MaxRating != "" {
+		parts = append(parts, "up to "+c.MaxRating)
+	}
+	switch {
+	case c.MinYear != 0 && c.MaxYear != 0:
+		parts = append(parts, fmt.Sprintf("%d to %d", c.MinYear, c.MaxYear))
+	case c.MinYear != 0:
+		parts = append(parts, fmt.Sprintf("%d or later", c.MinYear))
+	case c.MaxYear != 0:
+		parts = append(parts, fmt.Sprintf("%d or earlier", c.MaxYear))
+	}
+	if c.MaxRuntime != 0 {
+		parts = append(parts, fmt.Sprintf("%d min or shorter", c.MaxRuntime))
+	}
+	return strings.Join(parts, ", ")
+}
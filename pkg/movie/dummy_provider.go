@@ -1,102 +1,135 @@
 package movie
 
+import "context"
+
 type DummyProvider struct{}
 
 func NewDummyProvider() *DummyProvider {
 	return &DummyProvider{}
 }
 
-func (p *DummyProvider) FetchMovies() ([]Movie, error) {
+func (p *DummyProvider) Name() string {
+	return "dummy"
+}
+
+func (p *DummyProvider) FetchMovies(_ context.Context) ([]Movie, error) {
 	movies := []Movie{
 		{
 			CommunityRating: 8.7,
 			CriticRating:    88,
-			Genres:          []string{},
+			Genres:          []string{"Action", "Science Fiction"},
 			Id:              "movie-1",
 			Name:            "The Matrix",
 			OfficialRating:  "R",
 			PremiereDate:    "1999-03-31T00:00:00Z",
 			PrimaryImageTag: "matrix-poster",
 			ProductionYear:  1999,
+			RuntimeMinutes:  136,
 		},
 		{
 			CommunityRating: 8.8,
 			CriticRating:    87,
-			Genres:          []string{},
+			Genres:          []string{"Action", "Science Fiction", "Adventure"},
 			Id:              "movie-2",
 			Name:            "Inception",
-			OfficialRating:  "R",
+			OfficialRating:  "PG-13",
 			PremiereDate:    "2010-07-16T00:00:00Z",
 			PrimaryImageTag: "inception-poster",
 			ProductionYear:  2010,
+			RuntimeMinutes:  148,
 		},
 		{
 			CommunityRating: 8.9,
 			CriticRating:    94,
-			Genres:          []string{},
+			Genres:          []string{"Thriller", "Crime"},
 			Id:              "movie-3",
 			Name:            "Pulp Fiction",
 			OfficialRating:  "R",
 			PremiereDate:    "1994-10-14T00:00:00Z",
 			PrimaryImageTag: "pulp-fiction-poster",
 			ProductionYear:  1994,
+			RuntimeMinutes:  154,
 		},
 		{
 			CommunityRating: 9.3,
 			CriticRating:    91,
-			Genres:          []string{},
+			Genres:          []string{"Drama", "Crime"},
 			Id:              "movie-4",
 			Name:            "The Shawshank Redemption",
 			OfficialRating:  "R",
 			PremiereDate:    "1994-09-23T00:00:00Z",
 			PrimaryImageTag: "shawshank-poster",
 			ProductionYear:  1994,
+			RuntimeMinutes:  142,
 		},
 		{
 			CommunityRating: 9.0,
 			CriticRating:    94,
-			Genres:          []string{},
+			Genres:          []string{"Drama", "Action", "Crime", "Thriller"},
 			Id:              "movie-5",
 			Name:            "The Dark Knight",
-			OfficialRating:  "R",
+			OfficialRating:  "PG-13",
 			PremiereDate:    "2008-07-18T00:00:00Z",
 			PrimaryImageTag: "dark-knight-poster",
 			ProductionYear:  2008,
+			RuntimeMinutes:  152,
 		},
 		{
 			CommunityRating: 8.8,
 			CriticRating:    82,
-			Genres:          []string{},
+			Genres:          []string{"Comedy", "Drama", "Romance"},
 			Id:              "movie-6",
 			Name:            "Forrest Gump",
-			OfficialRating:  "R",
+			OfficialRating:  "PG-13",
 			PremiereDate:    "1994-07-06T00:00:00Z",
 			PrimaryImageTag: "forrest-gump-poster",
 			ProductionYear:  1994,
+			RuntimeMinutes:  142,
 		},
 		{
 			CommunityRating: 8.8,
 			CriticRating:    79,
-			Genres:          []string{},
+			Genres:          []string{"Drama"},
 			Id:              "movie-7",
 			Name:            "Fight Club",
 			OfficialRating:  "R",
 			PremiereDate:    "1999-10-15T00:00:00Z",
 			PrimaryImageTag: "fight-club-poster",
 			ProductionYear:  1999,
+			RuntimeMinutes:  139,
 		},
 		{
 			CommunityRating: 8.7,
 			CriticRating:    96,
-			Genres:          []string{},
+			Genres:          []string{"Drama", "Crime"},
 			Id:              "movie-8",
 			Name:            "Goodfellas",
 			OfficialRating:  "R",
 			PremiereDate:    "1990-09-21T00:00:00Z",
 			PrimaryImageTag: "goodfellas-poster",
 			ProductionYear:  1990,
+			RuntimeMinutes:  145,
 		},
 	}
 
 	return movies, nil
 }
+
+// FetchLists returns a collection and a playlist from the dummy library. There are no
+// dummy accounts, so everyone gets the playlist too.
+func (p *DummyProvider) FetchLists(_ context.Context, _, _ string) ([]ProviderList, error) {
+	return []ProviderList{
+		{
+			ID:       "collection-1",
+			Name:     "Christopher Nolan",
+			Kind:     Collection,
+			MovieIDs: []string{"movie-2", "movie-5"},
+		},
+		{
+			ID:       "playlist-1",
+			Name:     "Date Night",
+			Kind:     Playlist,
+			MovieIDs: []string{"movie-6", "movie-4"},
+		},
+	}, nil
+}
@@ -0,0 +1,249 @@
+package movie
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"watchma/db/sqlcgen"
+)
+
+// ListKind is what an imported list was on the provider
+type ListKind string
+
+const (
+	Collection ListKind = "collection"
+	Playlist   ListKind = "playlist"
+)
+
+var ErrListsUnsupported = errors.New("the movie library doesn't have collections or playlists")
+
+// ProviderList is a collection or playlist as the provider has it
+type ProviderList struct {
+	ID       string
+	Name     string
+	Kind     ListKind
+	MovieIDs []string
+}
+
+// List is a collection or playlist a user imported
+type List struct {
+	ID   int64
+	Name string
+	Kind ListKind
+	// Only movies still in the library are counted
+	MovieCount int
+}
+
+// Watchlist returns the movies userID wants to watch, most recently added first
+func (s *Service) Watchlist(ctx context.Context, userID int64) ([]Movie, error) {
+	rows, err := s.queries.ListWatchlistMovies(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list watchlist: %w", err)
+	}
+	return fromRows(rows), nil
+}
+
+// ToggleWatchlist puts movieID on userID's watchlist, or takes it off if it's already
+// there. It reports whether the movie is on the watchlist now.
+func (s *Service) ToggleWatchlist(ctx context.Context, userID int64, movieID string) (bool, error) {
+	removed, err := s.queries.DeleteWatchlistItem(ctx, sqlcgen.DeleteWatchlistItemParams{
+		UserID:  userID,
+		MovieID: movieID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("remove %s from watchlist: %w", movieID, err)
+	}
+	if removed > 0 {
+		return false, nil
+	}
+
+	if err := s.queries.AddWatchlistItem(ctx, sqlcgen.AddWatchlistItemParams{
+		UserID:  userID,
+		MovieID: movieID,
+	}); err != nil {
+		return false, fmt.Errorf("add %s to watchlist: %w", movieID, err)
+	}
+	return true, nil
+}
+
+// WatchlistsOf returns every movie on any of usernames' watchlists, each only once
+func (s *Service) WatchlistsOf(ctx context.Context, usernames []string) ([]Movie, error) {
+	seen := make(map[string]struct{})
+	var movies []Movie
+	for _, username := range usernames {
+		rows, err := s.queries.ListWatchlistMoviesByUsername(ctx, username)
+		if err != nil {
+			return nil, fmt.Errorf("list watchlist of %s: %w", username, err)
+		}
+		for _, m := range fromRows(rows) {
+			if _, ok := seen[m.Id]; ok {
+				continue
+			}
+			seen[m.Id] = struct{}{}
+			movies = append(movies, m)
+		}
+	}
+	return movies, nil
+}
+
+// Lists returns the lists userID imported, by name
+func (s *Service) Lists(ctx context.Context, userID int64) ([]List, error) {
+	rows, err := s.queries.ListMovieLists(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list movie lists: %w", err)
+	}
+	lists := make([]List, 0, len(rows))
+	for _, row := range rows {
+		lists = append(lists, List{
+			ID:         row.ID,
+			Name:       row.Name,
+			Kind:       ListKind(row.Kind),
+			MovieCount: int(row.MovieCount),
+		})
+	}
+	return lists, nil
+}
+
+// List gets one of userID's lists with its movies in the provider's order. Other
+// users' lists aren't found.
+func (s *Service) List(ctx context.Context, userID, id int64) (List, []Movie, error) {
+	row, err := s.queries.GetMovieList(ctx, sqlcgen.GetMovieListParams{ID: id, UserID: userID})
+	if err != nil {
+		return List{}, nil, fmt.Errorf("get movie list %d: %w", id, err)
+	}
+	movies, err := s.listMovies(ctx, id)
+	if err != nil {
+		return List{}, nil, err
+	}
+	return List{
+		ID:         row.ID,
+		Name:       row.Name,
+		Kind:       ListKind(row.Kind),
+		MovieCount: len(movies),
+	}, movies, nil
+}
+
+func (s *Service) listMovies(ctx context.Context, id int64) ([]Movie, error) {
+	rows, err := s.queries.ListMovieListMovies(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list movies of list %d: %w", id, err)
+	}
+	return fromRows(rows), nil
+}
+
+func (s *Service) DeleteList(ctx context.Context, userID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete list transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := s.queries.WithTx(tx)
+
+	// Only the owner's list loses its movies
+	if _, err := qtx.GetMovieList(ctx, sqlcgen.GetMovieListParams{ID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("get movie list %d: %w", id, err)
+	}
+	if err := qtx.DeleteMovieListItems(ctx, id); err != nil {
+		return fmt.Errorf("delete movies of list %d: %w", id, err)
+	}
+	if err := qtx.DeleteMovieList(ctx, sqlcgen.DeleteMovieListParams{ID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("delete movie list %d: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete list: %w", err)
+	}
+	return nil
+}
+
+// ImportLists imports the collections and playlists username can see on the provider
+// as lists of userID. Lists imported before are updated and the ones that are gone from
+// the provider are removed. It returns how many lists userID has now.
+func (s *Service) ImportLists(ctx context.Context, userID int64, username string) (int, error) {
+	listProvider, ok := s.provider.(ListProvider)
+	if !ok {
+		return 0, ErrListsUnsupported
+	}
+
+	// Without a linked account only the collections everyone can see are imported
+	var providerUserID, token string
+	account, err := s.queries.GetJellyfinAccountByUsername(ctx, username)
+	switch {
+	case err == nil:
+		providerUserID, token = account.JellyfinUserID, account.AccessToken
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, fmt.Errorf("get linked account: %w", err)
+	}
+
+	fetched, err := listProvider.FetchLists(ctx, providerUserID, token)
+	if err != nil {
+		return 0, fmt.Errorf("fetch lists for %s: %w", username, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := s.queries.WithTx(tx)
+
+	existing, err := qtx.ListMovieLists(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("list movie lists: %w", err)
+	}
+	gone := make(map[string]int64, len(existing))
+	for _, l := range existing {
+		gone[l.SourceID] = l.ID
+	}
+
+	for _, l := range fetched {
+		row, err := qtx.UpsertMovieList(ctx, sqlcgen.UpsertMovieListParams{
+			UserID:   userID,
+			Kind:     string(l.Kind),
+			SourceID: l.ID,
+			Name:     l.Name,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("save list %q: %w", l.Name, err)
+		}
+		delete(gone, l.ID)
+
+		if err := qtx.DeleteMovieListItems(ctx, row.ID); err != nil {
+			return 0, fmt.Errorf("clear list %q: %w", l.Name, err)
+		}
+		for i, movieID := range l.MovieIDs {
+			if err := qtx.AddMovieListItem(ctx, sqlcgen.AddMovieListItemParams{
+				ListID:   row.ID,
+				MovieID:  movieID,
+				Position: int64(i),
+			}); err != nil {
+				return 0, fmt.Errorf("add %s to list %q: %w", movieID, l.Name, err)
+			}
+		}
+	}
+
+	for _, id := range gone {
+		if err := qtx.DeleteMovieListItems(ctx, id); err != nil {
+			return 0, fmt.Errorf("delete movies of list %d: %w", id, err)
+		}
+		if err := qtx.DeleteMovieList(ctx, sqlcgen.DeleteMovieListParams{ID: id, UserID: userID}); err != nil {
+			return 0, fmt.Errorf("delete movie list %d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit import: %w", err)
+	}
+
+	s.logger.Info("Movie lists imported", "username", username, "lists", len(fetched), "removed", len(gone))
+	return len(fetched), nil
+}
+
+// HasLists reports whether the provider has collections or playlists to import
+func (s *Service) HasLists() bool {
+	_, ok := s.provider.(ListProvider)
+	return ok
+}
@@ -1,5 +1,11 @@
 package movie
 
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
 // Movie is our internal representation of a movie that contains metadata expected on
 // any movie, whether it comes from Jellyfin or Plex, etc.
 type Movie struct {
@@ -9,9 +15,11 @@ type Movie struct {
 	Id              string
 	Name            string
 	OfficialRating  string
+	Overview        string
 	PremiereDate    string
 	PrimaryImageTag string
 	ProductionYear  int
+	RuntimeMinutes  int // 0 when unknown
 }
 
 type SortField string
@@ -30,6 +38,42 @@ type Query struct {
 	Search     string
 }
 
+// Apply filters and sorts movies in memory the way the SearchMovies query does, for
+// when the library hasn't been synced yet
+func (q Query) Apply(movies []Movie) []Movie {
+	search := strings.ToLower(q.Search)
+	matched := make([]Movie, 0, len(movies))
+	for _, m := range movies {
+		if q.Genre != "" && !slices.Contains(m.Genres, q.Genre) {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(m.Name), search) {
+			continue
+		}
+		matched = append(matched, m)
+	}
+
+	var compare func(a, b Movie) int
+	switch q.SortBy {
+	case SortByName:
+		compare = func(a, b Movie) int { return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) }
+	case SortByYear:
+		compare = func(a, b Movie) int { return cmp.Compare(a.ProductionYear, b.ProductionYear) }
+	case SortByCriticRating:
+		compare = func(a, b Movie) int { return cmp.Compare(a.CriticRating, b.CriticRating) }
+	case SortByCommunityRating:
+		compare = func(a, b Movie) int { return cmp.Compare(a.CommunityRating, b.CommunityRating) }
+	default:
+		return matched
+	}
+	if q.Descending {
+		asc := compare
+		compare = func(a, b Movie) int { return asc(b, a) }
+	}
+	slices.SortStableFunc(matched, compare)
+	return matched
+}
+
 // MovieRequest represents a request containing movie IDs
 type Request struct {
 	Movies []string `json:"movies"`
@@ -41,6 +85,12 @@ type Vote struct {
 	Votes int
 }
 
+// Suggestion is a movie recommended to a group, with why it was picked
+type Suggestion struct {
+	Movie  Movie
+	Reason string
+}
+
 // CopySlice creates a deep copy of a movie slice to avoid shared references
 func CopySlice(movies []Movie) []Movie {
 	if movies == nil {
@@ -60,9 +110,11 @@ func CopySlice(movies []Movie) []Movie {
 			Id:              m.Id,
 			Name:            m.Name,
 			OfficialRating:  m.OfficialRating,
+			Overview:        m.Overview,
 			PremiereDate:    m.PremiereDate,
 			PrimaryImageTag: m.PrimaryImageTag,
 			ProductionYear:  m.ProductionYear,
+			RuntimeMinutes:  m.RuntimeMinutes,
 		}
 	}
 	return copied
@@ -0,0 +1,59 @@
+package movie
+
+import (
+	"context"
+	"fmt"
+)
+
+// SourceKind is where a room's draft pool comes from
+type SourceKind string
+
+const (
+	FromLibrary    SourceKind = ""
+	FromWatchlists SourceKind = "watchlists"
+	FromList       SourceKind = "list"
+)
+
+// ParseSourceKind reads a source kind from a form or the database
+func ParseSourceKind(s string) (SourceKind, bool) {
+	switch k := SourceKind(s); k {
+	case FromLibrary, FromWatchlists, FromList:
+		return k, true
+	}
+	return FromLibrary, false
+}
+
+// Source is where a room drafts from, before its Constraints narrow it down
+type Source struct {
+	Kind SourceKind
+	// ListID is one of the host's lists, when drafting from a list
+	ListID int64
+	// ListName is shown in the room, the list can be renamed or deleted while it's open
+	ListName string
+}
+
+func (src Source) String() string {
+	switch src.Kind {
+	case FromWatchlists:
+		return "Players' watchlists"
+	case FromList:
+		return src.ListName
+	}
+	return "Whole library"
+}
+
+// Pool returns the movies a room drafts from. usernames are the room's players, whose
+// watchlists are pooled when drafting from watchlists.
+func (s *Service) Pool(ctx context.Context, src Source, usernames []string) ([]Movie, error) {
+	switch src.Kind {
+	case FromWatchlists:
+		return s.WatchlistsOf(ctx, usernames)
+	case FromList:
+		return s.listMovies(ctx, src.ListID)
+	}
+	movies, err := s.GetMovies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get movies: %w", err)
+	}
+	return movies, nil
+}
@@ -1,6 +1,43 @@
 package movie
 
+import (
+	"context"
+	"time"
+)
+
 // Provider is the interface that movie providers must implement
 type Provider interface {
-	FetchMovies() ([]Movie, error)
+	// Name identifies the provider, used to key its library sync state
+	Name() string
+	FetchMovies(ctx context.Context) ([]Movie, error)
+}
+
+// IncrementalProvider is implemented by providers that can report only what changed
+// since the last sync, so the whole library doesn't need to be downloaded every time
+type IncrementalProvider interface {
+	Provider
+	// FetchMoviesSince returns movies added or modified at or after since
+	FetchMoviesSince(ctx context.Context, since time.Time) ([]Movie, error)
+	// FetchMovieIDs returns the IDs of every movie the provider currently has, used
+	// to detect movies that were removed
+	FetchMovieIDs(ctx context.Context) ([]string, error)
+}
+
+// UserLibraryProvider is implemented by providers with per-user libraries, so users
+// who signed in through the provider only see the movies they have access to
+type UserLibraryProvider interface {
+	Provider
+	// FetchVisibleMovieIDs returns the IDs of the movies providerUserID can see,
+	// asked for with that user's own token
+	FetchVisibleMovieIDs(ctx context.Context, providerUserID, token string) ([]string, error)
+}
+
+// ListProvider is implemented by providers with curated lists of movies, like Jellyfin's
+// collections and playlists, so users can import them as lists to draft from
+type ListProvider interface {
+	Provider
+	// FetchLists returns the collections and playlists providerUserID can see, asked
+	// for with that user's own token. Without a user only the collections are returned,
+	// playlists always belong to someone.
+	FetchLists(ctx context.Context, providerUserID, token string) ([]ProviderList, error)
 }
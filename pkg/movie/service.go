@@ -1,36 +1,65 @@
 package movie
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
-	"slices"
-	"sort"
-	"strings"
+	"sync"
 	"time"
+
+	"watchma/db/sqlcgen"
 )
 
+var ErrMovieNotFound = errors.New("movie not found")
+
 type Service struct {
 	provider Provider
+	db       *sql.DB
+	queries  *sqlcgen.Queries
 	logger   *slog.Logger
+	syncMu   sync.Mutex
+	// Run after every successful library sync
+	syncHooks []SyncHook
+	// Movie IDs each linked provider user can see, keyed by provider user ID
+	visible   map[string]visibleIDs
+	visibleMu sync.Mutex
 	// Movie of the day cache
 	movieOfTheDay Movie
 	cachedDay     time.Time
 }
 
-func NewService(provider Provider, logger *slog.Logger) *Service {
+func NewService(provider Provider, db *sql.DB, logger *slog.Logger) *Service {
 	return &Service{
 		provider: provider,
+		db:       db,
+		queries:  sqlcgen.New(db),
 		logger:   logger,
+		visible:  make(map[string]visibleIDs),
 	}
 }
 
-func (s *Service) GetMovies() ([]Movie, error) {
-	return s.provider.FetchMovies()
+// GetMovies returns the whole library from the local movies table. Until the first
+// sync has finished it falls back to asking the provider directly.
+func (s *Service) GetMovies(ctx context.Context) ([]Movie, error) {
+	rows, err := s.queries.ListMovies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list movies: %w", err)
+	}
+
+	if len(rows) == 0 {
+		s.logger.Debug("Movie library is empty, fetching from provider")
+		return s.provider.FetchMovies(ctx)
+	}
+
+	return fromRows(rows), nil
 }
 
-func (s *Service) GetShuffledMovies() ([]Movie, error) {
-	movies, err := s.GetMovies()
+func (s *Service) GetShuffledMovies(ctx context.Context) ([]Movie, error) {
+	movies, err := s.GetMovies(ctx)
 	if err != nil {
 		return movies, err
 	}
@@ -42,74 +71,50 @@ func (s *Service) GetShuffledMovies() ([]Movie, error) {
 	return movies, nil
 }
 
-func (s *Service) GetMoviesWithQuery(q Query) ([]Movie, error) {
-
-	movies, err := s.GetMovies()
+// GetMoviesWithQuery filters, searches and sorts the library in SQLite. Until the first
+// sync has finished it does the same in memory over the provider's movies.
+func (s *Service) GetMoviesWithQuery(ctx context.Context, q Query) ([]Movie, error) {
+	rows, err := s.queries.SearchMovies(ctx, sqlcgen.SearchMoviesParams{
+		Genre:      q.Genre,
+		Search:     q.Search,
+		SortBy:     string(q.SortBy),
+		Descending: q.Descending,
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("search movies: %w", err)
+	}
+	if len(rows) > 0 {
+		return fromRows(rows), nil
 	}
 
-	// Make a deep copy to avoid mutating the cached movies
-	moviesCopy := CopySlice(movies)
-
-	filteredMovies := filterByGenre(moviesCopy, q.Genre)
-	searchMovies := searchByName(filteredMovies, q.Search)
-	sortMovies(searchMovies, q.SortBy, q.Descending)
-
-	return searchMovies, nil
-}
-
-func filterByGenre(movies []Movie, genre string) []Movie {
-	if genre == "" {
-		return movies
+	// No matches, or no library yet
+	count, err := s.queries.CountMovies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count movies: %w", err)
 	}
-	filtered := make([]Movie, 0)
-	for _, m := range movies {
-		if slices.Contains(m.Genres, genre) {
-			filtered = append(filtered, m)
-		}
+	if count > 0 {
+		return []Movie{}, nil
 	}
-	return filtered
-}
 
-func searchByName(movies []Movie, query string) []Movie {
-	if query == "" {
-		return movies
-	}
-	query = strings.ToLower(query)
-	filtered := make([]Movie, 0)
-	for _, m := range movies {
-		if strings.Contains(strings.ToLower(m.Name), query) {
-			filtered = append(filtered, m)
-		}
+	s.logger.Debug("Movie library is empty, querying the provider")
+	movies, err := s.provider.FetchMovies(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return filtered
+	return q.Apply(movies), nil
 }
 
-func sortMovies(movies []Movie, sortBy SortField, descending bool) {
-	if sortBy == "" {
-		return
-	}
-	sort.Slice(movies, func(i, j int) bool {
-		var less bool
-		switch sortBy {
-		case SortByName:
-			less = strings.ToLower(movies[i].Name) < strings.ToLower(movies[j].Name)
-		case SortByYear:
-			less = movies[i].ProductionYear < movies[j].ProductionYear
-		case SortByCriticRating:
-			less = movies[i].CriticRating < movies[j].CriticRating
-		case SortByCommunityRating:
-			less = movies[i].CommunityRating < movies[j].CommunityRating
-		default:
-			// fallback to name if unknown
-			less = strings.ToLower(movies[i].Name) < strings.ToLower(movies[j].Name)
-		}
-		if descending {
-			return !less
-		}
-		return less
-	})
+// ImageTag returns the poster tag stored for a movie, so posters are only ever fetched
+// for movies in the library at their current artwork
+func (s *Service) ImageTag(ctx context.Context, id string) (string, error) {
+	tag, err := s.queries.GetMovieImageTag(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrMovieNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get image tag of %s: %w", id, err)
+	}
+	return tag, nil
 }
 
 // GetMovieOfTheDay returns a unique movie for the current UTC day.
@@ -120,7 +125,7 @@ func sortMovies(movies []Movie, sortBy SortField, descending bool) {
 // caches it, and returns it.
 //
 // Returns an error if fetching movies fails or if no movies are available.
-func (s *Service) GetMovieOfTheDay() (Movie, error) {
+func (s *Service) GetMovieOfTheDay(ctx context.Context) (Movie, error) {
 	now := time.Now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 
@@ -128,7 +133,7 @@ func (s *Service) GetMovieOfTheDay() (Movie, error) {
 		return s.movieOfTheDay, nil
 	}
 
-	movies, err := s.GetMovies()
+	movies, err := s.GetMovies(ctx)
 	if err != nil {
 		return Movie{}, fmt.Errorf("failed to fetch movies: %w", err)
 	}
@@ -137,12 +142,72 @@ func (s *Service) GetMovieOfTheDay() (Movie, error) {
 		return Movie{}, fmt.Errorf("no movies available")
 	}
 
-	// Pick a new movie deterministically with seeded RNG based on today's date
-	rng := rand.New(rand.NewSource(today.Unix()))
-	index := rng.Intn(len(movies))
-
-	s.movieOfTheDay = movies[index]
+	s.movieOfTheDay = pickOfTheDay(movies, today)
 	s.cachedDay = today
 
 	return s.movieOfTheDay, nil
 }
+
+// GetMovieOfTheDayFor is GetMovieOfTheDay for a user who may not be allowed to see
+// every movie. If today's movie is hidden from them, one is picked from theirs instead.
+func (s *Service) GetMovieOfTheDayFor(ctx context.Context, username string) (Movie, error) {
+	movieOfTheDay, err := s.GetMovieOfTheDay(ctx)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	visible, err := s.VisibleTo(ctx, username, []Movie{movieOfTheDay})
+	if err != nil {
+		return Movie{}, err
+	}
+	if len(visible) == 1 {
+		return movieOfTheDay, nil
+	}
+
+	movies, err := s.GetMovies(ctx)
+	if err != nil {
+		return Movie{}, fmt.Errorf("failed to fetch movies: %w", err)
+	}
+	visible, err = s.VisibleTo(ctx, username, movies)
+	if err != nil {
+		return Movie{}, err
+	}
+	if len(visible) == 0 {
+		return Movie{}, fmt.Errorf("no movies available")
+	}
+
+	now := time.Now()
+	return pickOfTheDay(visible, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)), nil
+}
+
+// pickOfTheDay picks a movie deterministically with seeded RNG based on the date
+func pickOfTheDay(movies []Movie, today time.Time) Movie {
+	rng := rand.New(rand.NewSource(today.Unix()))
+	return movies[rng.Intn(len(movies))]
+}
+
+// fromRows converts rows of the movies table into Movies
+func fromRows(rows []sqlcgen.Movie) []Movie {
+	movies := make([]Movie, 0, len(rows))
+	for _, row := range rows {
+		genres := []string{}
+		if err := json.Unmarshal([]byte(row.Genres), &genres); err != nil {
+			genres = []string{}
+		}
+
+		movies = append(movies, Movie{
+			CommunityRating: row.CommunityRating,
+			CriticRating:    int(row.CriticRating),
+			Genres:          genres,
+			Id:              row.ID,
+			Name:            row.Name,
+			OfficialRating:  row.OfficialRating,
+			Overview:        row.Overview,
+			PremiereDate:    row.PremiereDate,
+			PrimaryImageTag: row.PrimaryImageTag,
+			ProductionYear:  int(row.ProductionYear),
+			RuntimeMinutes:  int(row.RuntimeMinutes),
+		})
+	}
+	return movies
+}
@@ -0,0 +1,103 @@
+package movie
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"watchma/db"
+)
+
+// countingProvider serves a fixed library and counts how often it was asked
+type countingProvider struct {
+	movies []Movie
+	calls  int
+}
+
+func (p *countingProvider) Name() string { return "test" }
+
+func (p *countingProvider) FetchMovies(context.Context) ([]Movie, error) {
+	p.calls++
+	return CopySlice(p.movies), nil
+}
+
+func newTestService(t *testing.T) (*Service, *countingProvider) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(filepath.Join(t.TempDir(), "watchma.db"), logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	provider := &countingProvider{movies: []Movie{
+		{Id: "1", Name: "The Matrix", Genres: []string{"Action", "Science Fiction"}, ProductionYear: 1999},
+		{Id: "2", Name: "Heat", Genres: []string{"Crime", "Action"}, ProductionYear: 1995},
+		{Id: "3", Name: "the thing", Genres: []string{"Horror"}, ProductionYear: 1982},
+		{Id: "4", Name: "The Dark Knight", Genres: []string{"Action", "Crime"}, ProductionYear: 2008},
+	}}
+	return NewService(provider, database.DB, logger), provider
+}
+
+func ids(movies []Movie) []string {
+	out := make([]string, 0, len(movies))
+	for _, m := range movies {
+		out = append(out, m.Id)
+	}
+	return out
+}
+
+func TestGetMoviesWithQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"everything", Query{}, []string{"1", "2", "3", "4"}},
+		{"genre", Query{Genre: "Crime"}, []string{"2", "4"}},
+		{"search ignores case", Query{Search: "THE"}, []string{"1", "3", "4"}},
+		{"name", Query{SortBy: SortByName}, []string{"2", "4", "1", "3"}},
+		{"newest first", Query{Genre: "Action", SortBy: SortByYear, Descending: true}, []string{"4", "1", "2"}},
+		{"no matches", Query{Search: "paddington"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Before the first sync the provider answers, after it the table does, and
+			// both must agree
+			s, _ := newTestService(t)
+			before, err := s.GetMoviesWithQuery(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("before sync: %v", err)
+			}
+			if err := s.SyncLibrary(context.Background()); err != nil {
+				t.Fatalf("SyncLibrary: %v", err)
+			}
+			after, err := s.GetMoviesWithQuery(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("after sync: %v", err)
+			}
+
+			if !slices.Equal(ids(before), tt.want) || !slices.Equal(ids(after), tt.want) {
+				t.Errorf("before sync = %v, after sync = %v, want %v", ids(before), ids(after), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMoviesWithQueryOnlyAsksProviderWhenEmpty(t *testing.T) {
+	s, provider := newTestService(t)
+	if err := s.SyncLibrary(context.Background()); err != nil {
+		t.Fatalf("SyncLibrary: %v", err)
+	}
+	synced := provider.calls
+
+	if _, err := s.GetMoviesWithQuery(context.Background(), Query{Search: "paddington"}); err != nil {
+		t.Fatalf("GetMoviesWithQuery: %v", err)
+	}
+	if provider.calls != synced {
+		t.Errorf("provider asked %d times after the sync, want 0", provider.calls-synced)
+	}
+}
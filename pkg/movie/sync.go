@@ -0,0 +1,197 @@
+package movie
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"watchma/db/sqlcgen"
+)
+
+// syncOverlap is subtracted from the last sync time when asking for changes, so clock
+// drift between us and the media server can't cause a change to be skipped
+const syncOverlap = 5 * time.Minute
+
+// SyncHook is run with the whole library after every successful sync
+type SyncHook func(ctx context.Context, movies []Movie) error
+
+// OnSync registers hook to run after every successful sync. Register hooks before
+// calling StartSync.
+func (s *Service) OnSync(hook SyncHook) {
+	s.syncHooks = append(s.syncHooks, hook)
+}
+
+// StartSync syncs the library immediately and then every interval until ctx is cancelled
+func (s *Service) StartSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SyncLibrary(ctx); err != nil {
+			s.logger.Error("Library sync failed", "provider", s.provider.Name(), "error", err)
+		} else {
+			s.runSyncHooks(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) runSyncHooks(ctx context.Context) {
+	if len(s.syncHooks) == 0 {
+		return
+	}
+
+	movies, err := s.GetMovies(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load library for sync hooks", "error", err)
+		return
+	}
+
+	for _, hook := range s.syncHooks {
+		if err := hook(ctx, movies); err != nil {
+			s.logger.Error("Library sync hook failed", "error", err)
+		}
+	}
+}
+
+// SyncLibrary mirrors the provider's catalog into the movies table.
+//
+// Providers implementing IncrementalProvider only send what changed since the last
+// sync, plus the list of IDs still present so removals can be detected. Everything
+// else gets a full sync, as does a provider that hasn't synced since another one did or
+// whose movies went missing from the table.
+func (s *Service) SyncLibrary(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	began := time.Now()
+	// Truncated and in UTC so the stored timestamps compare correctly as text
+	startedAt := began.UTC().Truncate(time.Second)
+	providerName := s.provider.Name()
+
+	var changed []Movie
+	var ids []string
+	var err error
+
+	lastSync, syncErr := s.queries.GetLibrarySync(ctx, providerName)
+	if syncErr != nil && !errors.Is(syncErr, sql.ErrNoRows) {
+		return fmt.Errorf("get library sync: %w", syncErr)
+	}
+
+	incremental, ok := s.provider.(IncrementalProvider)
+	isIncremental := ok && syncErr == nil
+	if isIncremental {
+		ids, err = incremental.FetchMovieIDs(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch movie ids: %w", err)
+		}
+
+		// Unchanged movies are only touched, never sent again, so any that went missing
+		// from the table would stay missing without a full sync
+		stored, err := s.queries.CountMovies(ctx)
+		if err != nil {
+			return fmt.Errorf("count movies: %w", err)
+		}
+		if stored < int64(len(ids)) {
+			s.logger.Warn("Library is missing movies, doing a full sync", "provider", providerName, "stored", stored, "provider_count", len(ids))
+			isIncremental = false
+			ids = nil
+		}
+	}
+
+	if isIncremental {
+		changed, err = incremental.FetchMoviesSince(ctx, lastSync.LastSyncedAt.Add(-syncOverlap))
+		if err != nil {
+			return fmt.Errorf("fetch changed movies: %w", err)
+		}
+	} else {
+		changed, err = s.provider.FetchMovies(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch movies: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin sync transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := s.queries.WithTx(tx)
+
+	for _, m := range changed {
+		genres, err := json.Marshal(m.Genres)
+		if err != nil {
+			return fmt.Errorf("marshal genres for %s: %w", m.Id, err)
+		}
+		if genres == nil || string(genres) == "null" {
+			genres = []byte("[]")
+		}
+
+		if err := qtx.UpsertMovie(ctx, sqlcgen.UpsertMovieParams{
+			ID:              m.Id,
+			Name:            m.Name,
+			CommunityRating: m.CommunityRating,
+			CriticRating:    int64(m.CriticRating),
+			Genres:          string(genres),
+			OfficialRating:  m.OfficialRating,
+			PremiereDate:    m.PremiereDate,
+			PrimaryImageTag: m.PrimaryImageTag,
+			ProductionYear:  int64(m.ProductionYear),
+			RuntimeMinutes:  int64(m.RuntimeMinutes),
+			Overview:        m.Overview,
+			LastSeenAt:      startedAt,
+		}); err != nil {
+			return fmt.Errorf("upsert movie %s: %w", m.Id, err)
+		}
+	}
+
+	for _, id := range ids {
+		if err := qtx.TouchMovie(ctx, sqlcgen.TouchMovieParams{
+			LastSeenAt: startedAt,
+			ID:         id,
+		}); err != nil {
+			return fmt.Errorf("touch movie %s: %w", id, err)
+		}
+	}
+
+	// Anything the provider didn't mention this round has been removed
+	removed, err := qtx.DeleteMoviesNotSeenSince(ctx, startedAt)
+	if err != nil {
+		return fmt.Errorf("delete removed movies: %w", err)
+	}
+
+	if err := qtx.UpsertLibrarySync(ctx, sqlcgen.UpsertLibrarySyncParams{
+		Provider:     providerName,
+		LastSyncedAt: startedAt,
+	}); err != nil {
+		return fmt.Errorf("save library sync: %w", err)
+	}
+	// Switching back to a provider later has to start over with a full sync, its movies
+	// were removed while another provider was in use
+	if err := qtx.DeleteOtherLibrarySyncs(ctx, providerName); err != nil {
+		return fmt.Errorf("clear other library syncs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit sync transaction: %w", err)
+	}
+
+	s.logger.Info("Library synced",
+		"provider", providerName,
+		"incremental", isIncremental,
+		"changed", len(changed),
+		"removed", removed,
+		"duration", time.Since(began),
+	)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package movie
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// How long a user's visible movie IDs are reused before asking the provider again
+const visibilityTTL = 5 * time.Minute
+
+type visibleIDs struct {
+	ids       map[string]struct{}
+	fetchedAt time.Time
+}
+
+// VisibleTo filters movies down to the ones username is allowed to see. Users without
+// a linked provider account, and providers without per-user libraries, see everything.
+// If the provider can't be asked, an error is returned rather than showing movies the
+// user might not be allowed to see.
+func (s *Service) VisibleTo(ctx context.Context, username string, movies []Movie) ([]Movie, error) {
+	userLibrary, ok := s.provider.(UserLibraryProvider)
+	if !ok {
+		return movies, nil
+	}
+
+	account, err := s.queries.GetJellyfinAccountByUsername(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return movies, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get linked account: %w", err)
+	}
+
+	s.visibleMu.Lock()
+	cached, ok := s.visible[account.JellyfinUserID]
+	s.visibleMu.Unlock()
+
+	if !ok || time.Since(cached.fetchedAt) > visibilityTTL {
+		ids, err := userLibrary.FetchVisibleMovieIDs(ctx, account.JellyfinUserID, account.AccessToken)
+		if err != nil {
+			return nil, fmt.Errorf("fetch visible movies for %s: %w", username, err)
+		}
+
+		cached = visibleIDs{
+			ids:       make(map[string]struct{}, len(ids)),
+			fetchedAt: time.Now(),
+		}
+		for _, id := range ids {
+			cached.ids[id] = struct{}{}
+		}
+
+		s.visibleMu.Lock()
+		s.visible[account.JellyfinUserID] = cached
+		s.visibleMu.Unlock()
+	}
+
+	visible := make([]Movie, 0, len(movies))
+	for _, m := range movies {
+		if _, ok := cached.ids[m.Id]; ok {
+			visible = append(visible, m)
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,282 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoUsername means neither the ID token nor userinfo had the username claim
+	ErrNoUsername   = errors.New("identity provider did not send a username")
+	ErrInvalidToken = errors.New("invalid id token")
+)
+
+const (
+	defaultTimeout       = 15 * time.Second
+	DefaultUsernameClaim = "preferred_username"
+	// The discovery document is fetched again after this long, in case the issuer moved
+	// an endpoint
+	discoveryTTL = time.Hour
+)
+
+var DefaultScopes = []string{"openid", "profile", "email"}
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // Empty for public clients
+	// RedirectURL is this server's /login/oidc/callback, as registered with the issuer
+	RedirectURL string
+	Scopes      []string
+	// UsernameClaim names the claim that becomes the watchma username
+	UsernameClaim string
+	Timeout       time.Duration
+}
+
+// Identity is a verified sign in from the issuer
+type Identity struct {
+	Issuer   string
+	Subject  string
+	Username string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client signs users in with an OpenID Connect issuer using the authorization code flow
+// with PKCE. The discovery document and signing keys are fetched on first use, so the
+// issuer being down at startup doesn't stop watchma starting.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	mu           sync.Mutex
+	discovery    *discovery
+	discoveredAt time.Time
+	keys         *keySet
+}
+
+func NewClient(config Config, logger *slog.Logger) *Client {
+	config.IssuerURL = strings.TrimSuffix(config.IssuerURL, "/")
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = DefaultUsernameClaim
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	return &Client{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		logger:     logger,
+	}
+}
+
+// AuthCodeURL is where the browser is sent to sign in. state and nonce are checked when
+// the issuer redirects back, verifier must be kept for Exchange.
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := c.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(c.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades the code from the callback for tokens, verifies the ID token and maps
+// its claims to an Identity. Claims missing from the ID token are looked up in userinfo,
+// some issuers only put the profile there.
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error) {
+	d, err := c.getDiscovery(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	tokens, err := c.exchangeCode(ctx, d, code, verifier)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	claims, err := c.verifyIDToken(ctx, d, tokens.IDToken, nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return Identity{}, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+
+	username := stringClaim(claims, c.config.UsernameClaim)
+	if username == "" && d.UserinfoEndpoint != "" && tokens.AccessToken != "" {
+		info, err := c.userinfo(ctx, d, tokens.AccessToken)
+		if err != nil {
+			return Identity{}, err
+		}
+		// Userinfo has to be about the same person as the ID token
+		if info["sub"] == subject {
+			username = stringClaim(info, c.config.UsernameClaim)
+		}
+	}
+	if username == "" {
+		return Identity{}, fmt.Errorf("%w: claim %q is empty", ErrNoUsername, c.config.UsernameClaim)
+	}
+
+	return Identity{
+		Issuer:   d.Issuer,
+		Subject:  subject,
+		Username: username,
+	}, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+func (c *Client) exchangeCode(ctx context.Context, d *discovery, code, verifier string) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	// Public clients have no secret and only identify themselves, PKCE protects the code
+	if c.config.ClientSecret == "" {
+		form.Set("client_id", c.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		// client_secret_basic, the one method every issuer has to support
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	var tokens tokenResponse
+	if err := c.do(req, &tokens); err != nil {
+		return tokenResponse{}, fmt.Errorf("exchange code: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return tokenResponse{}, fmt.Errorf("%w: token response has no id_token", ErrInvalidToken)
+	}
+	return tokens, nil
+}
+
+func (c *Client) userinfo(ctx context.Context, d *discovery, accessToken string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims map[string]any
+	if err := c.do(req, &claims); err != nil {
+		return nil, fmt.Errorf("get userinfo: %w", err)
+	}
+	return claims, nil
+}
+
+func (c *Client) getDiscovery(ctx context.Context) (*discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil && time.Since(c.discoveredAt) < discoveryTTL {
+		return c.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create discovery request: %w", err)
+	}
+
+	var d discovery
+	if err := c.do(req, &d); err != nil {
+		// A stale document beats no sign in at all
+		if c.discovery != nil {
+			c.logger.Warn("OIDC discovery failed, using the last document", "error", err)
+			return c.discovery, nil
+		}
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	// The spec requires the issuer to match exactly, this stops a compromised discovery
+	// document pointing us at someone else's tokens
+	if strings.TrimSuffix(d.Issuer, "/") != c.config.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", d.Issuer, c.config.IssuerURL)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: document is missing endpoints")
+	}
+
+	if c.keys == nil || c.keys.uri != d.JWKSURI {
+		c.keys = newKeySet(d.JWKSURI)
+	}
+	c.discovery = &d
+	c.discoveredAt = time.Now()
+	return c.discovery, nil
+}
+
+func (c *Client) do(req *http.Request, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func stringClaim(claims map[string]any, name string) string {
+	value, _ := claims[name].(string)
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,239 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const mockKeyID = "mock"
+
+// MockIssuer is a tiny OpenID Connect issuer for development and the e2e tests. Anyone
+// can sign in as any username, so never point a real deployment at it.
+type MockIssuer struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	tokens map[string]string // access token -> username
+}
+
+type mockGrant struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	username    string
+	expiresAt   time.Time
+}
+
+func NewMockIssuer(issuer, clientID, clientSecret string) (*MockIssuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generate signing key: %w", err)
+	}
+
+	return &MockIssuer{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]mockGrant),
+		tokens:       make(map[string]string),
+	}, nil
+}
+
+func (m *MockIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, map[string]string{
+			"issuer":                 m.issuer,
+			"authorization_endpoint": m.issuer + "/authorize",
+			"token_endpoint":         m.issuer + "/token",
+			"userinfo_endpoint":      m.issuer + "/userinfo",
+			"jwks_uri":               m.issuer + "/jwks",
+		})
+	case "/jwks":
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kid": mockKeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	case "/authorize":
+		m.authorize(w, r)
+	case "/token":
+		m.token(w, r)
+	case "/userinfo":
+		m.userinfo(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+var mockLoginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<title>Mock OIDC</title>
+<h1>Mock OIDC sign in</h1>
+<form method="POST">
+	<label>Username <input name="username" required autofocus></label>
+	<button type="submit">Sign in</button>
+</form>`))
+
+// authorize shows a form asking for a username, submitting it approves the sign in. The
+// form posts back to the same URL, so the request's parameters come along in the query.
+func (m *MockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("client_id") != m.clientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("code_challenge_method") != "S256" || r.Form.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(r.Form.Get("redirect_uri"))
+	if err != nil || redirectURI.Host == "" {
+		http.Error(w, "bad redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mockLoginPage.Execute(w, nil)
+		return
+	}
+
+	code := RandomString()
+	m.mu.Lock()
+	m.codes[code] = mockGrant{
+		redirectURI: redirectURI.String(),
+		challenge:   r.Form.Get("code_challenge"),
+		nonce:       r.Form.Get("nonce"),
+		username:    r.Form.Get("username"),
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	m.mu.Unlock()
+
+	query := redirectURI.Query()
+	query.Set("code", code)
+	query.Set("state", r.Form.Get("state"))
+	redirectURI.RawQuery = query.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (m *MockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	clientID, clientSecret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID != m.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(m.clientSecret)) != 1 {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code")) // Codes are single use
+	m.mu.Unlock()
+
+	if !ok || time.Now().After(grant.expiresAt) || grant.redirectURI != r.Form.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	if codeChallenge(r.Form.Get("code_verifier")) != grant.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	idToken, err := m.sign(map[string]any{
+		"iss":                m.issuer,
+		"sub":                "mock-" + grant.username,
+		"aud":                m.clientID,
+		"exp":                time.Now().Add(5 * time.Minute).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              grant.nonce,
+		"preferred_username": grant.username,
+		"email":              grant.username + "@example.com",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	accessToken := RandomString()
+	m.mu.Lock()
+	m.tokens[accessToken] = grant.username
+	m.mu.Unlock()
+
+	writeJSON(w, map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (m *MockIssuer) userinfo(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	username, ok := m.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	m.mu.Unlock()
+	if !ok {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, map[string]string{
+		"sub":                "mock-" + username,
+		"preferred_username": username,
+		"email":              username + "@example.com",
+	})
+}
+
+func (m *MockIssuer) sign(claims map[string]any) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": mockKeyID})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign id token: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
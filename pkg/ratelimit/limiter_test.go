@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	const key = "user:alice"
+
+	tests := []struct {
+		name string
+		// run takes tokens and waits on a limiter of 3 a minute, one back every 20s
+		run       func(l *Limiter, clock *fakeClock)
+		wantOK    bool
+		wantRetry time.Duration
+	}{
+		{
+			name:   "burst",
+			run:    func(l *Limiter, _ *fakeClock) { take(l, 2, key) },
+			wantOK: true,
+		},
+		{
+			name:      "over the burst",
+			run:       func(l *Limiter, _ *fakeClock) { take(l, 3, key) },
+			wantOK:    false,
+			wantRetry: 20 * time.Second,
+		},
+		{
+			name: "retry counts down",
+			run: func(l *Limiter, clock *fakeClock) {
+				take(l, 3, key)
+				clock.advance(5 * time.Second)
+			},
+			wantOK:    false,
+			wantRetry: 15 * time.Second,
+		},
+		{
+			name: "refills one at a time",
+			run: func(l *Limiter, clock *fakeClock) {
+				take(l, 3, key)
+				clock.advance(20 * time.Second)
+			},
+			wantOK: true,
+		},
+		{
+			name: "refill doesn't go past the burst",
+			run: func(l *Limiter, clock *fakeClock) {
+				take(l, 3, key)
+				clock.advance(time.Hour)
+				take(l, 3, key)
+			},
+			wantOK:    false,
+			wantRetry: 20 * time.Second,
+		},
+		{
+			name:   "keys are separate",
+			run:    func(l *Limiter, _ *fakeClock) { take(l, 3, "ip:203.0.113.7") },
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := NewLimiter(3, time.Minute)
+			l.now = clock.now
+
+			tt.run(l, clock)
+			ok, retry := l.Allow(key)
+			if ok != tt.wantOK || retry != tt.wantRetry {
+				t.Errorf("Allow = %v, %s, want %v, %s", ok, retry, tt.wantOK, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func take(l *Limiter, times int, key string) {
+	for range times {
+		l.Allow(key)
+	}
+}
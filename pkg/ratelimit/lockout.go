@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Failures are forgotten after this long without another one
+const forgetFailuresAfter = 24 * time.Hour
+
+// Lockout locks keys out after repeated failures, like wrong passwords. Once a key has
+// failed maxAttempts times, every further failure locks it for twice as long as the
+// last, starting at base and capped at max.
+type Lockout struct {
+	maxAttempts int
+	base        time.Duration
+	max         time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*lockoutEntry
+	lastPrune time.Time
+	// now is time.Now, tests move it along instead of sleeping
+	now func() time.Time
+}
+
+type lockoutEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewLockout(maxAttempts int, base, max time.Duration) *Lockout {
+	return &Lockout{
+		maxAttempts: maxAttempts,
+		base:        base,
+		max:         max,
+		entries:     make(map[string]*lockoutEntry),
+		lastPrune:   time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Check returns how long until every one of keys is unlocked, 0 when none are locked
+func (l *Lockout) Check(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	for _, key := range keys {
+		if e, ok := l.entries[key]; ok {
+			wait = max(wait, e.lockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// Fail records a failure for each key, locking the ones that ran out of attempts
+func (l *Lockout) Fail(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	for _, key := range keys {
+		e, ok := l.entries[key]
+		if !ok || now.Sub(e.lastFailure) > forgetFailuresAfter {
+			e = &lockoutEntry{}
+			l.entries[key] = e
+		}
+		e.failures++
+		e.lastFailure = now
+
+		if over := e.failures - l.maxAttempts; over >= 0 {
+			lock := l.max
+			// Past 30 doublings the shift overflows, max has long been reached anyway
+			if over < 30 {
+				lock = min(l.base<<over, l.max)
+			}
+			e.lockedUntil = now.Add(lock)
+		}
+	}
+}
+
+// Reset forgets the failures for each key, e.g. after a successful sign in
+func (l *Lockout) Reset(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		delete(l.entries, key)
+	}
+}
+
+// prune forgets keys that haven't failed in a while. Callers must hold mu.
+func (l *Lockout) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Hour {
+		return
+	}
+	for key, e := range l.entries {
+		if now.Sub(e.lastFailure) > forgetFailuresAfter && now.After(e.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock stands in for time.Now, tests move it along with advance
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestLockout(t *testing.T) {
+	const ip, user = "ip:203.0.113.7", "user:alice"
+
+	tests := []struct {
+		name string
+		// run fails, resets and waits on a lockout of 3 attempts, 1 to 10 minutes
+		run   func(l *Lockout, clock *fakeClock)
+		check []string
+		want  time.Duration
+	}{
+		{
+			name:  "under the threshold",
+			run:   func(l *Lockout, _ *fakeClock) { fail(l, 2, user) },
+			check: []string{user},
+			want:  0,
+		},
+		{
+			name:  "at the threshold",
+			run:   func(l *Lockout, _ *fakeClock) { fail(l, 3, user) },
+			check: []string{user},
+			want:  time.Minute,
+		},
+		{
+			name:  "doubles with every failure after",
+			run:   func(l *Lockout, _ *fakeClock) { fail(l, 5, user) },
+			check: []string{user},
+			want:  4 * time.Minute,
+		},
+		{
+			name:  "capped at max",
+			run:   func(l *Lockout, _ *fakeClock) { fail(l, 50, user) },
+			check: []string{user},
+			want:  10 * time.Minute,
+		},
+		{
+			name: "counts down",
+			run: func(l *Lockout, clock *fakeClock) {
+				fail(l, 3, user)
+				clock.advance(40 * time.Second)
+			},
+			check: []string{user},
+			want:  20 * time.Second,
+		},
+		{
+			name: "unlocks after the window",
+			run: func(l *Lockout, clock *fakeClock) {
+				fail(l, 3, user)
+				clock.advance(time.Minute)
+			},
+			check: []string{user},
+			want:  0,
+		},
+		{
+			name: "reset on success",
+			run: func(l *Lockout, _ *fakeClock) {
+				fail(l, 2, user)
+				l.Reset(user)
+				fail(l, 2, user)
+			},
+			check: []string{user},
+			want:  0,
+		},
+		{
+			name: "reset unlocks",
+			run: func(l *Lockout, _ *fakeClock) {
+				fail(l, 3, user)
+				l.Reset(user)
+			},
+			check: []string{user},
+			want:  0,
+		},
+		{
+			name: "old failures are forgotten",
+			run: func(l *Lockout, clock *fakeClock) {
+				fail(l, 2, user)
+				clock.advance(25 * time.Hour)
+				fail(l, 1, user)
+			},
+			check: []string{user},
+			want:  0,
+		},
+		{
+			name:  "ip and user keys are separate",
+			run:   func(l *Lockout, _ *fakeClock) { fail(l, 3, ip) },
+			check: []string{user},
+			want:  0,
+		},
+		{
+			name:  "either key locks",
+			run:   func(l *Lockout, _ *fakeClock) { fail(l, 3, ip) },
+			check: []string{ip, user},
+			want:  time.Minute,
+		},
+		{
+			name: "longest lock wins",
+			run: func(l *Lockout, _ *fakeClock) {
+				fail(l, 3, ip)
+				fail(l, 4, user)
+			},
+			check: []string{ip, user},
+			want:  2 * time.Minute,
+		},
+		{
+			name: "resetting the user keeps the ip locked",
+			run: func(l *Lockout, _ *fakeClock) {
+				fail(l, 3, ip, user)
+				l.Reset(user)
+			},
+			check: []string{ip, user},
+			want:  time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := NewLockout(3, time.Minute, 10*time.Minute)
+			l.now = clock.now
+
+			tt.run(l, clock)
+			if got := l.Check(tt.check...); got != tt.want {
+				t.Errorf("Check(%v) = %s, want %s", tt.check, got, tt.want)
+			}
+		})
+	}
+}
+
+func fail(l *Lockout, times int, keys ...string) {
+	for range times {
+		l.Fail(keys...)
+	}
+}
@@ -0,0 +1,105 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"watchma/pkg/movie"
+)
+
+// How much each kind of history counts towards a genre. A vote says more than a
+// draft, and a win means the whole group sat through it.
+const (
+	draftWeight = 1
+	voteWeight  = 2
+	watchWeight = 3
+)
+
+type scoredMovie struct {
+	Movie  movie.Movie
+	Score  float64
+	Genres []string // The movie's genres the group likes most, best first
+}
+
+// rankByAffinity scores every candidate by how much the group likes its genres, best
+// first. Movies the group already watched are left out.
+func rankByAffinity(histories []history, candidates []movie.Movie) []scoredMovie {
+	byID := make(map[string]movie.Movie, len(candidates))
+	for _, m := range candidates {
+		byID[m.Id] = m
+	}
+
+	affinity := make(map[string]float64)
+	watched := make(map[string]bool)
+	add := func(id string, weight float64) {
+		for _, g := range byID[id].Genres {
+			affinity[g] += weight
+		}
+	}
+	for _, h := range histories {
+		for _, d := range h.Drafted {
+			add(d.MovieID, draftWeight)
+		}
+		for _, v := range h.Voted {
+			add(v.MovieID, voteWeight)
+		}
+		for _, w := range h.Watched {
+			add(w.WinningMovieID, watchWeight)
+			watched[w.WinningMovieID] = true
+		}
+	}
+
+	ranked := make([]scoredMovie, 0, len(candidates))
+	for _, m := range candidates {
+		if watched[m.Id] {
+			continue
+		}
+
+		sm := scoredMovie{Movie: m}
+		for _, g := range m.Genres {
+			if affinity[g] > 0 {
+				sm.Score += affinity[g]
+				sm.Genres = append(sm.Genres, g)
+			}
+		}
+		// Average over the genres so long genre lists don't win by default, then
+		// break ties with the community rating
+		if len(m.Genres) > 0 {
+			sm.Score /= float64(len(m.Genres))
+		}
+		sm.Score += m.CommunityRating / 10
+		sort.SliceStable(sm.Genres, func(i, j int) bool {
+			return affinity[sm.Genres[i]] > affinity[sm.Genres[j]]
+		})
+		ranked = append(ranked, sm)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// topSuggestions turns the best count ranked movies into suggestions
+func topSuggestions(ranked []scoredMovie, count int) []movie.Suggestion {
+	suggestions := make([]movie.Suggestion, 0, min(len(ranked), count))
+	for _, sm := range ranked[:min(len(ranked), count)] {
+		suggestions = append(suggestions, movie.Suggestion{Movie: sm.Movie, Reason: reason(sm)})
+	}
+	return suggestions
+}
+
+func reason(sm scoredMovie) string {
+	switch len(sm.Genres) {
+	case 0:
+		if sm.Movie.CommunityRating > 0 {
+			return fmt.Sprintf("A crowd pleaser at %.1f out of 10", sm.Movie.CommunityRating)
+		}
+		return "Something new for the group"
+	case 1:
+		return fmt.Sprintf("The group keeps picking %s", sm.Genres[0])
+	default:
+		return fmt.Sprintf("The group keeps picking %s", strings.Join(sm.Genres[:2], " and "))
+	}
+}
@@ -0,0 +1,247 @@
+package recommend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/llm"
+	"watchma/pkg/movie"
+)
+
+const (
+	// How many candidates, best affinity first, the LLM gets to choose from. The whole
+	// library would blow past most context windows.
+	shortlistSize = 60
+	// How much of each player's history goes into the prompt
+	historyPerPlayer = 10
+	suggestTimeout   = 30 * time.Second
+)
+
+// Service suggests movies a group is likely to agree on, based on what its players
+// drafted, voted for and watched in past games
+type Service struct {
+	queries     *sqlcgen.Queries
+	llmProvider llm.Provider // nil when AI is disabled, only genre affinity is used
+	logger      *slog.Logger
+}
+
+func NewService(queries *sqlcgen.Queries, llmProvider llm.Provider, logger *slog.Logger) *Service {
+	return &Service{
+		queries:     queries,
+		llmProvider: llmProvider,
+		logger:      logger,
+	}
+}
+
+// history is one player's past games
+type history struct {
+	Username string
+	Drafted  []sqlcgen.GetUserMovieDraftCountsRow
+	Voted    []sqlcgen.GetUserMovieVoteCountsRow
+	Watched  []sqlcgen.GameResult
+}
+
+// Suggest picks up to count movies from candidates for the players in roomName.
+// The LLM picks from a shortlist ranked by genre affinity, and its picks are checked
+// against candidates. Without an LLM, or if it fails, the top of the shortlist is used.
+func (s *Service) Suggest(ctx context.Context, roomName string, usernames []string, candidates []movie.Movie, count int) ([]movie.Suggestion, error) {
+	histories, err := s.loadHistories(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := rankByAffinity(histories, candidates)
+	if s.llmProvider == nil {
+		return topSuggestions(ranked, count), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, suggestTimeout)
+	defer cancel()
+
+	shortlist := ranked[:min(len(ranked), shortlistSize)]
+	suggestions, err := s.askLLM(ctx, roomName, histories, shortlist, count)
+	if err != nil {
+		s.logger.Warn("AI suggestions failed, using genre affinity", "backend", s.llmProvider.Name(), "error", err)
+		return topSuggestions(ranked, count), nil
+	}
+
+	// Top up with affinity picks if the LLM came back short
+	for _, sug := range topSuggestions(ranked, len(ranked)) {
+		if len(suggestions) >= count {
+			break
+		}
+		if !containsMovie(suggestions, sug.Movie.Id) {
+			suggestions = append(suggestions, sug)
+		}
+	}
+	return suggestions, nil
+}
+
+func (s *Service) loadHistories(ctx context.Context, usernames []string) ([]history, error) {
+	histories := make([]history, 0, len(usernames))
+	for _, username := range usernames {
+		user, err := s.queries.GetUserByUsername(ctx, username)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get user %s: %w", username, err)
+		}
+
+		h := history{Username: username}
+		if h.Drafted, err = s.queries.GetUserMovieDraftCounts(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("get drafts for %s: %w", username, err)
+		}
+		if h.Voted, err = s.queries.GetUserMovieVoteCounts(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("get votes for %s: %w", username, err)
+		}
+		if h.Watched, err = s.queries.GetGameResultsByUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("get game results for %s: %w", username, err)
+		}
+		histories = append(histories, h)
+	}
+	return histories, nil
+}
+
+type llmPicks struct {
+	Picks []struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	} `json:"picks"`
+}
+
+var picksSchema = &llm.JSONSchema{
+	Name: "movie_picks",
+	Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "picks": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "reason": {"type": "string"}
+        },
+        "required": ["id", "reason"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["picks"],
+  "additionalProperties": false
+}`),
+}
+
+// askLLM has the LLM choose from shortlist. Picks that aren't on the shortlist, or
+// are picked twice, are dropped.
+func (s *Service) askLLM(ctx context.Context, roomName string, histories []history, shortlist []scoredMovie, count int) ([]movie.Suggestion, error) {
+	resp, err := s.llmProvider.Complete(ctx, llm.Request{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: prompt(histories, shortlist, count)}},
+		Schema:   picksSchema,
+		Purpose:  llm.PurposeSuggestions,
+		Room:     roomName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Models without structured output like to wrap the JSON in a code fence
+	content := resp.Content
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start != -1 && end > start {
+		content = content[start : end+1]
+	}
+
+	var picks llmPicks
+	if err := json.Unmarshal([]byte(content), &picks); err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+
+	byID := make(map[string]movie.Movie, len(shortlist))
+	for _, sm := range shortlist {
+		byID[sm.Movie.Id] = sm.Movie
+	}
+
+	var suggestions []movie.Suggestion
+	for _, pick := range picks.Picks {
+		m, ok := byID[pick.ID]
+		if !ok {
+			s.logger.Debug("AI suggested a movie that isn't a candidate", "id", pick.ID)
+			continue
+		}
+		if containsMovie(suggestions, m.Id) {
+			continue
+		}
+		suggestions = append(suggestions, movie.Suggestion{Movie: m, Reason: strings.TrimSpace(pick.Reason)})
+		if len(suggestions) == count {
+			break
+		}
+	}
+	if len(suggestions) == 0 {
+		return nil, errors.New("no valid picks in response")
+	}
+	return suggestions, nil
+}
+
+func prompt(histories []history, shortlist []scoredMovie, count int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "A group is drafting movies for movie night. Pick the %d movies from the list below they are most likely to agree on.\n\n", count)
+
+	b.WriteString("What each player liked in past games:\n")
+	if len(histories) == 0 {
+		b.WriteString("- No history yet, pick crowd pleasers\n")
+	}
+	for _, h := range histories {
+		var drafted, voted, watched []string
+		for _, d := range h.Drafted[:min(len(h.Drafted), historyPerPlayer)] {
+			drafted = append(drafted, d.MovieName)
+		}
+		for _, v := range h.Voted[:min(len(h.Voted), historyPerPlayer)] {
+			voted = append(voted, v.MovieName)
+		}
+		for _, w := range h.Watched[:min(len(h.Watched), historyPerPlayer)] {
+			watched = append(watched, w.WinningMovieName)
+		}
+		fmt.Fprintf(&b, "- %s drafted: %s. Voted for: %s. Already watched: %s\n",
+			h.Username, listOrNone(drafted), listOrNone(voted), listOrNone(watched))
+	}
+
+	b.WriteString("\nMovies to choose from, as id | title (year) | genres:\n")
+	for _, sm := range shortlist {
+		title := sm.Movie.Name
+		if sm.Movie.ProductionYear > 0 {
+			title = fmt.Sprintf("%s (%d)", title, sm.Movie.ProductionYear)
+		}
+		fmt.Fprintf(&b, "%s | %s | %s\n", sm.Movie.Id, title, strings.Join(sm.Movie.Genres, ", "))
+	}
+
+	b.WriteString(`
+RULES:
+1. Only use ids from the list, copied exactly
+2. Don't pick movies the group already watched
+3. Give each pick a short, fun reason that mentions the players it's for`)
+	return b.String()
+}
+
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "nothing"
+	}
+	return strings.Join(items, ", ")
+}
+
+func containsMovie(suggestions []movie.Suggestion, id string) bool {
+	for _, s := range suggestions {
+		if s.Movie.Id == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package recommend
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"watchma/db"
+	"watchma/db/sqlcgen"
+	"watchma/pkg/llm"
+	"watchma/pkg/movie"
+)
+
+var candidates = []movie.Movie{
+	{Id: "movie-1", Name: "The Matrix", Genres: []string{"Action", "Sci-Fi"}, CommunityRating: 8.7},
+	{Id: "movie-2", Name: "Inception", Genres: []string{"Action", "Sci-Fi"}, CommunityRating: 8.8},
+	{Id: "movie-3", Name: "Pulp Fiction", Genres: []string{"Crime", "Drama"}, CommunityRating: 8.9},
+	{Id: "movie-4", Name: "Forrest Gump", Genres: []string{"Drama", "Romance"}, CommunityRating: 8.8},
+}
+
+func newTestService(t *testing.T, provider llm.Provider) *Service {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(filepath.Join(t.TempDir(), "watchma.db"), logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewService(sqlcgen.New(database.DB), provider, logger)
+}
+
+func ids(suggestions []movie.Suggestion) []string {
+	var out []string
+	for _, s := range suggestions {
+		out = append(out, s.Movie.Id)
+	}
+	return out
+}
+
+func TestSuggestUsesLLMPicks(t *testing.T) {
+	fake := llm.NewFake(`{"picks": [
+  {"id": "movie-4", "reason": " Everyone cries at this one "},
+  {"id": "movie-3", "reason": "A classic"}
+]}`)
+	s := newTestService(t, fake)
+
+	suggestions, err := s.Suggest(context.Background(), "movienight", []string{"nobody"}, candidates, 2)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if got := strings.Join(ids(suggestions), ","); got != "movie-4,movie-3" {
+		t.Errorf("suggestions = %s, want movie-4,movie-3", got)
+	}
+	if suggestions[0].Reason != "Everyone cries at this one" {
+		t.Errorf("reason = %q, want it trimmed", suggestions[0].Reason)
+	}
+
+	if len(fake.Requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(fake.Requests))
+	}
+	req := fake.Requests[0]
+	if req.Schema == nil || req.Purpose != llm.PurposeSuggestions || req.Room != "movienight" {
+		t.Errorf("request = %+v, want a schema, the suggestions purpose and the room", req)
+	}
+	if !strings.Contains(req.Messages[0].Content, "movie-1 | The Matrix") {
+		t.Errorf("prompt doesn't list the candidates:\n%s", req.Messages[0].Content)
+	}
+}
+
+func TestSuggestDropsUnknownAndDuplicatePicks(t *testing.T) {
+	fake := llm.NewFake("```json\n" + `{"picks": [
+  {"id": "movie-99", "reason": "Not in the library"},
+  {"id": "movie-3", "reason": "A classic"},
+  {"id": "movie-3", "reason": "Still a classic"}
+]}` + "\n```")
+	s := newTestService(t, fake)
+
+	suggestions, err := s.Suggest(context.Background(), "movienight", nil, candidates, 3)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	got := ids(suggestions)
+	if len(got) != 3 || got[0] != "movie-3" {
+		t.Fatalf("suggestions = %v, want movie-3 topped up to 3", got)
+	}
+	for _, id := range got[1:] {
+		if id == "movie-3" || id == "movie-99" {
+			t.Errorf("suggestions = %v, want unknown and duplicate picks dropped", got)
+		}
+	}
+}
+
+func TestSuggestFallsBackToAffinity(t *testing.T) {
+	s := newTestService(t, llm.NewFake("I'd go with The Matrix!"))
+
+	suggestions, err := s.Suggest(context.Background(), "movienight", nil, candidates, 2)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	// With no history the community rating decides
+	if got := strings.Join(ids(suggestions), ","); got != "movie-3,movie-2" {
+		t.Errorf("suggestions = %s, want movie-3,movie-2", got)
+	}
+}
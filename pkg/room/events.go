@@ -13,6 +13,9 @@ const (
 	RoomVotingEvent     = "Room Voting Event"
 	RoomAnnounceEvent   = "Room Announce Event"
 	RoomFinishEvent     = "Room Finish Event"
+	HostCommentEvent    = "Host Comment Event"
+	RoomProgressEvent   = "Room Progress Event" // A player picked or submitted, for spectators
+	RoomPlayAgainEvent  = "Room Play Again Event"
 	RoomListUpdateEvent = "Room List Update Event"
 )
 
@@ -12,14 +12,42 @@ const (
 	Results
 )
 
+// Visibility decides who can find and walk into a room
+type Visibility string
+
+const (
+	// Public rooms are listed on the join page and anyone can walk in
+	Public Visibility = "public"
+	// Unlisted rooms are left off the join page, only the share link gets people in
+	Unlisted Visibility = "unlisted"
+	// PasswordProtected rooms are listed but ask for the room's password to get in
+	PasswordProtected Visibility = "password"
+)
+
+// ParseVisibility reads a visibility from a form, empty means public
+func ParseVisibility(s string) (Visibility, bool) {
+	switch v := Visibility(s); v {
+	case "":
+		return Public, true
+	case Public, Unlisted, PasswordProtected:
+		return v, true
+	}
+	return "", false
+}
+
 type Session struct {
 	Host          string
+	Visibility    Visibility
+	PasswordHash  []byte // bcrypt hash of the room password, only for password protected rooms
 	AllMovies     []movie.Movie
 	AllMoviesMap  map[string]*movie.Movie // for fast lookup by ID
 	VotingMovies  []movie.Movie
 	MaxPlayers    int
 	MaxDraftCount int
+	Source        movie.Source      // Where the movies are drafted from
+	Constraints   movie.Constraints // Which of the source's movies are drafted from
 	Announcement  []DialogueLine
+	HostComment   HostComment          // What the game show host last said
 	Votes         map[*movie.Movie]int // Movie -> vote count
 	VotingNumber  int
 	Ties          int
@@ -41,6 +69,17 @@ func (g *Session) GetMovie(m movie.Movie) (*movie.Movie, bool) {
 	return foundMovie, ok
 }
 
+// InPool keeps the movies that are in the draft pool, AllMovies
+func (g *Session) InPool(movies []movie.Movie) []movie.Movie {
+	pool := make([]movie.Movie, 0, len(movies))
+	for _, m := range movies {
+		if _, ok := g.AllMoviesMap[m.Id]; ok {
+			pool = append(pool, m)
+		}
+	}
+	return pool
+}
+
 // VotingMoviesContains checks if a movie ID already exists in VotingMovies
 func (g *Session) VotingMoviesContains(m movie.Movie) bool {
 	for _, vm := range g.VotingMovies {
@@ -59,6 +98,12 @@ type Message struct {
 	Room     string `json:"room"`
 }
 
+// HostComment is a line from the game show host persona
+type HostComment struct {
+	Persona string
+	Line    string
+}
+
 // DialogueLine represents the parsing of gippity
 type DialogueLine struct {
 	Character string
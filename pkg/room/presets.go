@@ -0,0 +1,135 @@
+package room
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/movie"
+)
+
+// How long a preset's name can be
+const maxPresetNameLength = 40
+
+var ErrInvalidPresetName = errors.New("Give the preset a name of up to 40 characters")
+
+// Preset is a user's saved room settings, to host the same kind of room again.
+// Password protected presets don't keep the password, it is asked for when hosting.
+type Preset struct {
+	ID            int64
+	Name          string
+	MaxDraftCount int
+	MaxPlayers    int
+	Visibility    Visibility
+	// Source only keeps which list, its name is looked up again when hosting
+	Source      movie.Source
+	Constraints movie.Constraints
+}
+
+// PresetOf takes the settings a preset keeps from a room's session
+func PresetOf(name string, game *Session) Preset {
+	return Preset{
+		Name:          name,
+		MaxDraftCount: game.MaxDraftCount,
+		MaxPlayers:    game.MaxPlayers,
+		Visibility:    game.Visibility,
+		Source:        game.Source,
+		Constraints:   game.Constraints,
+	}
+}
+
+// Presets lists userID's presets by name
+func (rs *Service) Presets(ctx context.Context, userID int64) ([]Preset, error) {
+	rows, err := rs.queries.ListRoomPresets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list room presets: %w", err)
+	}
+	presets := make([]Preset, 0, len(rows))
+	for _, row := range rows {
+		presets = append(presets, presetFromRow(row))
+	}
+	return presets, nil
+}
+
+// Preset gets one of userID's presets, other users' presets aren't found
+func (rs *Service) Preset(ctx context.Context, userID, id int64) (Preset, error) {
+	row, err := rs.queries.GetRoomPreset(ctx, sqlcgen.GetRoomPresetParams{ID: id, UserID: userID})
+	if err != nil {
+		return Preset{}, fmt.Errorf("get room preset %d: %w", id, err)
+	}
+	return presetFromRow(row), nil
+}
+
+// SavePreset saves p for userID, replacing their preset of the same name
+func (rs *Service) SavePreset(ctx context.Context, userID int64, p Preset) (Preset, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > maxPresetNameLength {
+		return Preset{}, ErrInvalidPresetName
+	}
+
+	genres, err := json.Marshal(p.Constraints.Genres)
+	if err != nil || p.Constraints.Genres == nil {
+		genres = []byte("[]")
+	}
+
+	row, err := rs.queries.UpsertRoomPreset(ctx, sqlcgen.UpsertRoomPresetParams{
+		UserID:        userID,
+		Name:          p.Name,
+		MaxDraftCount: int64(p.MaxDraftCount),
+		MaxPlayers:    int64(p.MaxPlayers),
+		Visibility:    string(p.Visibility),
+		Genres:        string(genres),
+		MaxRating:     p.Constraints.MaxRating,
+		MinYear:       int64(p.Constraints.MinYear),
+		MaxYear:       int64(p.Constraints.MaxYear),
+		MaxRuntime:    int64(p.Constraints.MaxRuntime),
+		Source:        string(p.Source.Kind),
+		SourceListID:  p.Source.ListID,
+	})
+	if err != nil {
+		return Preset{}, fmt.Errorf("save room preset %q: %w", p.Name, err)
+	}
+	rs.logger.Debug("Room preset saved", "userID", userID, "preset", p.Name)
+	return presetFromRow(row), nil
+}
+
+func (rs *Service) DeletePreset(ctx context.Context, userID, id int64) error {
+	if err := rs.queries.DeleteRoomPreset(ctx, sqlcgen.DeleteRoomPresetParams{ID: id, UserID: userID}); err != nil {
+		return fmt.Errorf("delete room preset %d: %w", id, err)
+	}
+	return nil
+}
+
+func presetFromRow(row sqlcgen.RoomPreset) Preset {
+	visibility, ok := ParseVisibility(row.Visibility)
+	if !ok {
+		visibility = Public
+	}
+	var genres []string
+	if err := json.Unmarshal([]byte(row.Genres), &genres); err != nil {
+		genres = nil
+	}
+	source, ok := movie.ParseSourceKind(row.Source)
+	if !ok {
+		source = movie.FromLibrary
+	}
+	return Preset{
+		ID:            row.ID,
+		Name:          row.Name,
+		MaxDraftCount: int(row.MaxDraftCount),
+		MaxPlayers:    int(row.MaxPlayers),
+		Visibility:    visibility,
+		Source:        movie.Source{Kind: source, ListID: row.SourceListID},
+		Constraints: movie.Constraints{
+			Genres:     genres,
+			MaxRating:  row.MaxRating,
+			MinYear:    int(row.MinYear),
+			MaxYear:    int(row.MaxYear),
+			MaxRuntime: int(row.MaxRuntime),
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"watchma/db"
+	"watchma/db/sqlcgen"
+)
+
+func TestSavePresetNameLength(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(filepath.Join(t.TempDir(), "watchma.db"), logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer database.Close()
+	queries := sqlcgen.New(database.DB)
+	user, err := queries.CreateUser(context.Background(), sqlcgen.CreateUserParams{Username: "alice"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	rs := NewService(queries, nil, logger)
+
+	tests := []struct {
+		name    string
+		preset  string
+		wantErr error
+	}{
+		{"empty", "   ", ErrInvalidPresetName},
+		{"40 letters", strings.Repeat("a", 40), nil},
+		{"41 letters", strings.Repeat("a", 41), ErrInvalidPresetName},
+		// 80 bytes, but 40 characters
+		{"40 accented letters", strings.Repeat("é", 40), nil},
+		{"40 emoji", strings.Repeat("🍿", 40), nil},
+		{"41 emoji", strings.Repeat("🍿", 41), ErrInvalidPresetName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rs.SavePreset(context.Background(), user.ID, Preset{Name: tt.preset, MaxDraftCount: 3, MaxPlayers: 4, Visibility: Public})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package room
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"watchma/pkg/movie"
+)
+
+// Rematch is a fresh session with the same settings, for playing again
+func (g *Session) Rematch() *Session {
+	return &Session{
+		Host:          g.Host,
+		Visibility:    g.Visibility,
+		PasswordHash:  g.PasswordHash,
+		MaxPlayers:    g.MaxPlayers,
+		MaxDraftCount: g.MaxDraftCount,
+		Source:        g.Source,
+		Constraints:   g.Constraints,
+		Votes:         make(map[*movie.Movie]int),
+	}
+}
+
+// PlayAgain recreates a finished room with the same settings and players, under a new
+// name so the old room's results stay up for whoever is still looking. Asking again
+// returns the room that was already made.
+func (rs *Service) PlayAgain(roomName string) (*Room, bool) {
+	old, ok := rs.GetRoom(roomName)
+	if !ok {
+		return nil, false
+	}
+
+	old.mu.Lock()
+	defer old.mu.Unlock()
+	if old.Game.Step != Results {
+		return nil, false
+	}
+	if old.NextRoom != "" {
+		return rs.GetRoom(old.NextRoom)
+	}
+
+	next := newRoom("", old.Game.Rematch())
+	for username, p := range old.Players {
+		next.Players[username] = &Player{Username: username, JoinedAt: p.JoinedAt}
+		next.admitted[username] = true
+	}
+	// Spectators and whoever got in with the link or password can follow without it
+	for username := range old.Spectators {
+		next.admitted[username] = true
+	}
+	for username := range old.admitted {
+		next.admitted[username] = true
+	}
+
+	rs.mu.Lock()
+	next.Name = rs.rematchName(old.Name)
+	rs.addRoom(next)
+	rs.mu.Unlock()
+
+	old.NextRoom = next.Name
+	rs.logger.Info("Room played again", "from", old.Name, "to", next.Name)
+	rs.pub.PublishRoomEvent(old.Name, RoomPlayAgainEvent)
+	return next, true
+}
+
+// rematchName numbers the rooms of a group playing again, Friday becomes Friday-2, then
+// Friday-3. Callers must hold rs.mu.
+func (rs *Service) rematchName(name string) string {
+	base, n := name, 1
+	if i := strings.LastIndex(name, "-"); i > 0 {
+		if v, err := strconv.Atoi(name[i+1:]); err == nil && v > 0 {
+			base, n = name[:i], v
+		}
+	}
+	for {
+		n++
+		candidate := fmt.Sprintf("%s-%d", base, n)
+		if _, taken := rs.Rooms[candidate]; !taken {
+			return candidate
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package room
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -10,10 +11,19 @@ import (
 // Room represents a single room for players, cleans up when all players leave
 type Room struct {
 	Name         string
+	JoinCode     string // Short code in the room's share link, see Service.RoomByCode
 	Game         *Session
 	RoomMessages []Message
 	Players      map[string]*Player
-	mu           sync.RWMutex
+	Spectators   map[string]*Spectator
+	NextRoom     string // Where the players went when they played again, see Service.PlayAgain
+	// admitted are the users let into an unlisted or password protected room, so they
+	// can come back after leaving
+	admitted map[string]bool
+	mu       sync.RWMutex
+	// ctx is cancelled when the room is deleted, stopping its background jobs
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type Player struct {
@@ -23,6 +33,7 @@ type Player struct {
 	AvailableMovies   []movie.Movie // Each player's own copy of all movies
 	DraftMovies       []movie.Movie
 	VotingMovies      []movie.Movie
+	Suggestions       []movie.Suggestion // Picks for the group, shown in the draft when asked for
 	HasFinishedDraft  bool
 	HasFinishedVoting bool
 }
@@ -2,46 +2,108 @@ package room
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"database/sql"
 	"fmt"
 	"log/slog"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"watchma/db/sqlcgen"
 	"watchma/pkg/movie"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Service represents the orchestrator of all rooms and room operations
 type Service struct {
 	mu      sync.RWMutex
 	Rooms   map[string]*Room
+	codes   map[string]string // Join code -> room name
 	pub     *EventPublisher
 	queries *sqlcgen.Queries
 	logger  *slog.Logger
+
+	gameFinishedHooks []RoomHook
+	roomDeletedHooks  []RoomHook
 }
 
+// Join codes are this many characters from joinCodeAlphabet, about 40 bits so they
+// can't be guessed while the room is open
+const joinCodeLength = 8
+
+// No 0/O or 1/I, codes get read off the TV and typed on phones
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// RoomHook is run in the background with the name of the room an event happened in
+type RoomHook func(ctx context.Context, roomName string) error
+
 func NewService(queries *sqlcgen.Queries, pub *EventPublisher, l *slog.Logger) *Service {
 	return &Service{
 		Rooms:   make(map[string]*Room),
+		codes:   make(map[string]string),
 		pub:     pub,
 		queries: queries,
 		logger:  l,
 	}
 }
 
+// OnGameFinished registers hook to run when a room's game reaches the results
+func (rs *Service) OnGameFinished(hook RoomHook) {
+	rs.gameFinishedHooks = append(rs.gameFinishedHooks, hook)
+}
+
+// OnRoomDeleted registers hook to run after a room is deleted
+func (rs *Service) OnRoomDeleted(hook RoomHook) {
+	rs.roomDeletedHooks = append(rs.roomDeletedHooks, hook)
+}
+
+func (rs *Service) runHooks(roomName, event string, hooks []RoomHook) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, hook := range hooks {
+			if err := hook(ctx, roomName); err != nil {
+				rs.logger.Error("Room hook failed", "roomName", roomName, "event", event, "error", err)
+			}
+		}
+	}()
+}
+
 func (rs *Service) AddRoom(roomName string, game *Session) {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
-	rs.Rooms[roomName] = &Room{
+	rs.addRoom(newRoom(roomName, game))
+}
+
+func newRoom(roomName string, game *Session) *Room {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Room{
 		Name:         roomName,
 		Game:         game,
 		RoomMessages: make([]Message, 0),
 		Players:      make(map[string]*Player),
+		Spectators:   make(map[string]*Spectator),
+		admitted:     make(map[string]bool),
+		ctx:          ctx,
+		cancel:       cancel,
 	}
+}
+
+// addRoom gives room a join code and opens it, callers must hold rs.mu
+func (rs *Service) addRoom(room *Room) {
+	room.JoinCode = rs.newJoinCode()
+	rs.codes[room.JoinCode] = room.Name
+	rs.Rooms[room.Name] = room
 
-	rs.logger.Info("Room added", "name", roomName)
+	rs.logger.Info("Room added", "name", room.Name)
 
 	rs.pub.PublishLobbyEvent(RoomListUpdateEvent)
 }
@@ -49,6 +111,12 @@ func (rs *Service) AddRoom(roomName string, game *Session) {
 func (rs *Service) DeleteRoom(roomName string) {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
+	if room, ok := rs.Rooms[roomName]; ok {
+		room.cancel()
+		delete(rs.codes, room.JoinCode)
+		// The last player leaving deletes the room too, hooks only run for the first delete
+		rs.runHooks(roomName, "deleted", rs.roomDeletedHooks)
+	}
 	delete(rs.Rooms, roomName)
 
 	rs.logger.Info("Room deleted", "name", roomName)
@@ -56,6 +124,61 @@ func (rs *Service) DeleteRoom(roomName string) {
 	rs.pub.PublishLobbyEvent(RoomListUpdateEvent)
 }
 
+// RoomByCode finds a room by the join code in its share link
+func (rs *Service) RoomByCode(code string) (*Room, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	roomName, ok := rs.codes[strings.ToUpper(code)]
+	if !ok {
+		return nil, false
+	}
+	room, ok := rs.Rooms[roomName]
+	return room, ok
+}
+
+// ListedRooms are the rooms shown on the join page, everything but unlisted rooms
+func (rs *Service) ListedRooms() map[string]*Room {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	listed := make(map[string]*Room, len(rs.Rooms))
+	for name, room := range rs.Rooms {
+		if room.Game.Visibility != Unlisted {
+			listed[name] = room
+		}
+	}
+	return listed
+}
+
+// AdmitPlayer lets username into an unlisted or password protected room, after they
+// used its share link or password
+func (rs *Service) AdmitPlayer(roomName, username string) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	room.admitted[username] = true
+	room.mu.Unlock()
+
+	rs.logger.Debug("Player admitted to room", "roomName", roomName, "playerName", username)
+	return true
+}
+
+// newJoinCode picks a random code no other room uses, callers must hold rs.mu
+func (rs *Service) newJoinCode() string {
+	for {
+		b := make([]byte, joinCodeLength)
+		rand.Read(b)
+		for i := range b {
+			b[i] = joinCodeAlphabet[int(b[i])%len(joinCodeAlphabet)]
+		}
+		if _, taken := rs.codes[string(b)]; !taken {
+			return string(b)
+		}
+	}
+}
+
 func (rs *Service) AddPlayerToRoom(roomName, username string) (*Player, bool) {
 	room, ok := rs.GetRoom(roomName)
 	if !ok {
@@ -114,6 +237,34 @@ func (rs *Service) TransferHost(roomName, newHost string) bool {
 	return true
 }
 
+// RenamePlayer moves a player to a new username, keeping them host if they were. Used
+// when a guest converts to a full account.
+func (rs *Service) RenamePlayer(roomName, oldUsername, newUsername string) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	player, found := room.Players[oldUsername]
+	if found {
+		delete(room.Players, oldUsername)
+		player.Username = newUsername
+		room.Players[newUsername] = player
+		if room.Game.Host == oldUsername {
+			room.Game.Host = newUsername
+		}
+	}
+	room.mu.Unlock()
+	if !found {
+		return false
+	}
+
+	rs.logger.Debug("Player renamed", "roomName", roomName, "from", oldUsername, "to", newUsername)
+	rs.pub.PublishRoomEvent(roomName, RoomUpdateEvent)
+	return true
+}
+
 func (rs *Service) TogglePlayerReady(roomName, username string) bool {
 	room, ok := rs.GetRoom(roomName)
 	if !ok {
@@ -146,7 +297,9 @@ func (rs *Service) AddMessage(roomName string, msg Message) bool {
 	return true
 }
 
-func (rs *Service) StartGame(roomName string, movies []movie.Movie) bool {
+// StartGame moves the room to the draft. Each player drafts from their own copy of
+// available[username], or of every movie if they have no entry.
+func (rs *Service) StartGame(roomName string, movies []movie.Movie, available map[string][]movie.Movie) bool {
 	room, ok := rs.GetRoom(roomName)
 	if !ok {
 		return false
@@ -159,7 +312,11 @@ func (rs *Service) StartGame(roomName string, movies []movie.Movie) bool {
 
 	// Give each player their own copy of the movies
 	for _, player := range room.Players {
-		player.AvailableMovies = movie.CopySlice(movies)
+		if playerMovies, ok := available[player.Username]; ok {
+			player.AvailableMovies = movie.CopySlice(playerMovies)
+		} else {
+			player.AvailableMovies = movie.CopySlice(movies)
+		}
 	}
 
 	rs.logger.Info("Game Started", "roomName", roomName)
@@ -201,6 +358,61 @@ func (rs *Service) StreamAnnouncement(roomName string) {
 	rs.pub.PublishRoomEvent(roomName, RoomAnnounceEvent)
 }
 
+// SetAnnouncement replaces the announcement dialogue and pushes it to every player
+func (rs *Service) SetAnnouncement(roomName string, lines []DialogueLine) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	room.Game.Announcement = lines
+	room.mu.Unlock()
+
+	rs.pub.PublishRoomEvent(roomName, RoomAnnounceEvent)
+	return true
+}
+
+// SetHostComment shows a new line from the game show host to every player
+func (rs *Service) SetHostComment(roomName string, comment HostComment) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	room.Game.HostComment = comment
+	room.mu.Unlock()
+
+	rs.pub.PublishRoomEvent(roomName, HostCommentEvent)
+	return true
+}
+
+// RunInBackground runs job in its own goroutine with a context that is cancelled when
+// the room is deleted, so long running work like the announcement doesn't block the
+// request that started it or outlive the room
+func (rs *Service) RunInBackground(roomName, jobName string, job func(ctx context.Context)) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				rs.logger.Error("Room background job panicked", "roomName", roomName, "job", jobName, "error", err)
+			}
+		}()
+
+		rs.logger.Debug("Room background job started", "roomName", roomName, "job", jobName)
+		job(room.ctx)
+		if room.ctx.Err() != nil {
+			rs.logger.Info("Room background job cancelled", "roomName", roomName, "job", jobName)
+		}
+	}()
+	return true
+}
+
 func (rs *Service) FinishGame(roomName string) bool {
 	room, ok := rs.GetRoom(roomName)
 	if !ok {
@@ -217,6 +429,7 @@ func (rs *Service) FinishGame(roomName string) bool {
 		}
 	}()
 
+	rs.runHooks(roomName, "game finished", rs.gameFinishedHooks)
 	rs.pub.PublishRoomEvent(roomName, RoomFinishEvent)
 	return true
 }
@@ -275,6 +488,24 @@ func (rs *Service) SubmitFinalVotes(room *Room) {
 	rs.logger.Debug("All Movie Votes submitted to Voting Movies Results Array", "Room Name", room.Name, "votes", room.Game.Votes)
 }
 
+// SetSuggestions stores the movies suggested to a player during the draft
+func (rs *Service) SetSuggestions(roomName, username string, suggestions []movie.Suggestion) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	player, ok := room.Players[username]
+	if !ok {
+		return false
+	}
+	player.Suggestions = suggestions
+	return true
+}
+
 // RemoveDraftMovie removes a specific movie from a player's draft selection
 // Returns true if the movie was found and removed
 func (rs *Service) RemoveDraftMovie(roomName, username string, movieId string) bool {
@@ -298,6 +529,7 @@ func (rs *Service) RemoveDraftMovie(roomName, username string, movieId string) b
 				player.DraftMovies[i+1:]...,
 			)
 			rs.logger.Debug("Movie removed from draft", "roomName", roomName, "player", username, "movie", m.Name)
+			rs.pub.PublishRoomEvent(roomName, RoomProgressEvent)
 			return true
 		}
 	}
@@ -307,7 +539,8 @@ func (rs *Service) RemoveDraftMovie(roomName, username string, movieId string) b
 
 // ToggleDraftMovie adds or removes a movie from a player's draft selection
 // If the movie is already in the draft, it will be removed
-// If the movie is not in the draft and the player hasn't reached MaxDraftCount, it will be added
+// If the movie is not in the draft, is in the room's pool and the player hasn't reached
+// MaxDraftCount, it will be added
 // Returns true if toggle occurred
 func (rs *Service) ToggleDraftMovie(roomName, username string, movie movie.Movie) bool {
 	room, ok := rs.GetRoom(roomName)
@@ -340,8 +573,9 @@ func (rs *Service) ToggleDraftMovie(roomName, username string, movie movie.Movie
 		}
 	}
 
-	// Movie not found in draft, try to add it if under limit
-	if !wasToggled && len(player.DraftMovies) < room.Game.MaxDraftCount {
+	// Movie not found in draft, try to add it if it's in the pool and under limit
+	_, inPool := room.Game.AllMoviesMap[movie.Id]
+	if !wasToggled && inPool && len(player.DraftMovies) < room.Game.MaxDraftCount {
 		player.DraftMovies = append(player.DraftMovies, movie)
 		action = "selected"
 		wasToggled = true
@@ -352,6 +586,9 @@ func (rs *Service) ToggleDraftMovie(roomName, username string, movie movie.Movie
 	if wasToggled && rs.queries != nil {
 		go rs.recordVoteEvent(username, "draft_toggle", action, movie)
 	}
+	if wasToggled {
+		rs.pub.PublishRoomEvent(roomName, RoomProgressEvent)
+	}
 
 	return wasToggled
 }
@@ -400,10 +637,59 @@ func (rs *Service) ToggleVotingMovie(roomName, username string, movie movie.Movi
 	if wasToggled && rs.queries != nil {
 		go rs.recordVoteEvent(username, "vote_toggle", action, movie)
 	}
+	if wasToggled {
+		rs.pub.PublishRoomEvent(roomName, RoomProgressEvent)
+	}
 
 	return wasToggled
 }
 
+// GetHostComment returns what the game show host last said
+func (r *Room) GetHostComment() HostComment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Game.HostComment
+}
+
+// GetAnnouncement returns the current announcement dialogue
+func (r *Room) GetAnnouncement() []DialogueLine {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Game.Announcement
+}
+
+// GetNextRoom returns the room the players moved on to when they played again, if any
+func (r *Room) GetNextRoom() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.NextRoom
+}
+
+// CanEnter reports whether username may come into the room. Anyone can walk into a
+// public room, other rooms let in their host, their players and anyone admitted.
+func (r *Room) CanEnter(username string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.Game.Visibility == Public || r.Game.Visibility == "" || r.Game.Host == username {
+		return true
+	}
+	_, isPlayer := r.Players[username]
+	return isPlayer || r.admitted[username]
+}
+
+// CheckPassword reports whether password is the password of a password protected room
+func (r *Room) CheckPassword(password string) bool {
+	if r.Game.Visibility != PasswordProtected {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(r.Game.PasswordHash, []byte(password)) == nil
+}
+
+// CheckJoinCode reports whether code is the room's join code, as found in its share link
+func (r *Room) CheckJoinCode(code string) bool {
+	return subtle.ConstantTimeCompare([]byte(strings.ToUpper(code)), []byte(r.JoinCode)) == 1
+}
+
 func (r *Room) GetPlayer(username string) (*Player, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
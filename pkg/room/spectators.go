@@ -0,0 +1,124 @@
+package room
+
+import (
+	"sort"
+	"time"
+)
+
+// Spectator watches a room's game without playing, they don't count toward MaxPlayers
+type Spectator struct {
+	Username string
+	JoinedAt time.Time
+}
+
+// AddSpectator has username watch the room. Players can't also be spectators.
+func (rs *Service) AddSpectator(roomName, username string) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if _, isPlayer := room.Players[username]; isPlayer {
+		return false
+	}
+	if _, watching := room.Spectators[username]; !watching {
+		room.Spectators[username] = &Spectator{Username: username, JoinedAt: time.Now()}
+		rs.logger.Debug("Spectator added to room", "roomName", roomName, "spectator", username)
+		rs.pub.PublishRoomEvent(roomName, RoomUpdateEvent)
+	}
+	return true
+}
+
+func (rs *Service) RemoveSpectator(roomName, username string) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if _, watching := room.Spectators[username]; !watching {
+		return false
+	}
+	delete(room.Spectators, username)
+
+	rs.logger.Debug("Spectator removed from room", "roomName", roomName, "spectator", username)
+	rs.pub.PublishRoomEvent(roomName, RoomUpdateEvent)
+	return true
+}
+
+// PromoteSpectator makes a spectator a player. Only in the lobby, while there is room.
+func (rs *Service) PromoteSpectator(roomName, username string) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if _, watching := room.Spectators[username]; !watching ||
+		room.Game.Step != Lobby || len(room.Players) >= room.Game.MaxPlayers {
+		return false
+	}
+	delete(room.Spectators, username)
+	room.Players[username] = &Player{
+		Username: username,
+		JoinedAt: time.Now(),
+	}
+
+	rs.logger.Debug("Spectator promoted to player", "roomName", roomName, "playerName", username)
+	rs.pub.PublishRoomEvent(roomName, RoomUpdateEvent)
+	rs.pub.PublishLobbyEvent(RoomListUpdateEvent)
+	return true
+}
+
+// FinishDraft marks a player's draft as submitted, spectators see the progress
+func (rs *Service) FinishDraft(roomName, username string) bool {
+	return rs.finishStep(roomName, username, func(p *Player) { p.HasFinishedDraft = true })
+}
+
+// FinishVoting marks a player's votes as submitted, spectators see the progress
+func (rs *Service) FinishVoting(roomName, username string) bool {
+	return rs.finishStep(roomName, username, func(p *Player) { p.HasFinishedVoting = true })
+}
+
+func (rs *Service) finishStep(roomName, username string, finish func(*Player)) bool {
+	room, ok := rs.GetRoom(roomName)
+	if !ok {
+		return false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	player, ok := room.Players[username]
+	if !ok {
+		return false
+	}
+	finish(player)
+
+	rs.pub.PublishRoomEvent(roomName, RoomProgressEvent)
+	return true
+}
+
+func (r *Room) IsSpectator(username string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, watching := r.Spectators[username]
+	return watching
+}
+
+func (r *Room) SpectatorsByJoinTime() []*Spectator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spectators := make([]*Spectator, 0, len(r.Spectators))
+	for _, s := range r.Spectators {
+		spectators = append(spectators, s)
+	}
+
+	sort.Slice(spectators, func(i, j int) bool {
+		return spectators[i].JoinedAt.Before(spectators[j].JoinedAt)
+	})
+	return spectators
+}
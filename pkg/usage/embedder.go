@@ -0,0 +1,50 @@
+package usage
+
+import (
+	"context"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/embedding"
+	"watchma/pkg/llm"
+)
+
+// Embedder is an embedding.Embedder whose calls go through a Tracker. Embeddings
+// APIs don't all report usage, so tokens are estimated from the input.
+type Embedder struct {
+	embedder embedding.Embedder
+	tracker  *Tracker
+}
+
+// Embedder wraps embedder so its calls are counted against the budget
+func (t *Tracker) Embedder(embedder embedding.Embedder) *Embedder {
+	return &Embedder{embedder: embedder, tracker: t}
+}
+
+func (e *Embedder) Name() string {
+	return e.embedder.Name()
+}
+
+func (e *Embedder) Model() string {
+	return e.embedder.Model()
+}
+
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.tracker.checkBudget(ctx); err != nil {
+		return nil, err
+	}
+
+	vectors, err := e.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := estimateTokens(texts...)
+	e.tracker.record(sqlcgen.CreateLLMUsageParams{
+		Purpose:      llm.PurposeEmbeddings,
+		Backend:      e.embedder.Name(),
+		Model:        e.embedder.Model(),
+		PromptTokens: int64(tokens),
+		CostUsd:      float64(tokens) * e.tracker.prices.Embedding / 1_000_000,
+	})
+	return vectors, nil
+}
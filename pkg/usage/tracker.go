@@ -0,0 +1,228 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/llm"
+)
+
+// ErrBudgetExceeded is returned instead of calling the LLM once this month's estimated
+// cost has reached the budget. Every AI feature has a non-AI fallback it then uses.
+var ErrBudgetExceeded = errors.New("monthly LLM budget exceeded")
+
+// budgetSettingKey is where an admin's budget override lives in app_settings
+const budgetSettingKey = "llm_monthly_budget_usd"
+
+// Prices are in USD per million tokens
+type Prices struct {
+	Prompt     float64
+	Completion float64
+	Embedding  float64
+}
+
+// Cost estimates what a call with the given token counts cost
+func (p Prices) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1_000_000
+}
+
+// Tracker records the tokens and estimated cost of every AI call, and refuses calls
+// once the monthly budget is spent. Backends are wrapped with Provider and Embedder.
+type Tracker struct {
+	queries       *sqlcgen.Queries
+	prices        Prices
+	defaultBudget float64 // USD per month, 0 is unlimited
+	logger        *slog.Logger
+}
+
+func NewTracker(queries *sqlcgen.Queries, prices Prices, defaultBudget float64, logger *slog.Logger) *Tracker {
+	return &Tracker{
+		queries:       queries,
+		prices:        prices,
+		defaultBudget: defaultBudget,
+		logger:        logger,
+	}
+}
+
+// Provider is an llm.Provider whose calls go through a Tracker
+type Provider struct {
+	provider llm.Provider
+	tracker  *Tracker
+}
+
+// Provider wraps provider so its calls are counted against the budget
+func (t *Tracker) Provider(provider llm.Provider) *Provider {
+	return &Provider{provider: provider, tracker: t}
+}
+
+func (p *Provider) Name() string {
+	return p.provider.Name()
+}
+
+func (p *Provider) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	if err := p.tracker.checkBudget(ctx); err != nil {
+		return llm.Response{}, err
+	}
+
+	resp, err := p.provider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	p.record(req, resp)
+	return resp, nil
+}
+
+func (p *Provider) Stream(ctx context.Context, req llm.Request, onDelta func(delta string)) (llm.Response, error) {
+	if err := p.tracker.checkBudget(ctx); err != nil {
+		return llm.Response{}, err
+	}
+
+	// Keep what was streamed, so a call that fails or is cancelled part way through
+	// still counts the tokens it used
+	var streamed strings.Builder
+	resp, err := p.provider.Stream(ctx, req, func(delta string) {
+		streamed.WriteString(delta)
+		onDelta(delta)
+	})
+	if err != nil {
+		if streamed.Len() > 0 {
+			p.record(req, estimate(req, streamed.String()))
+		}
+		return resp, err
+	}
+	p.record(req, resp)
+	return resp, nil
+}
+
+func (p *Provider) record(req llm.Request, resp llm.Response) {
+	p.tracker.record(sqlcgen.CreateLLMUsageParams{
+		RoomName:         req.Room,
+		Purpose:          req.Purpose,
+		Backend:          p.provider.Name(),
+		Model:            resp.Model,
+		PromptTokens:     int64(resp.PromptTokens),
+		CompletionTokens: int64(resp.CompletionTokens),
+		CostUsd:          p.tracker.prices.Cost(resp.PromptTokens, resp.CompletionTokens),
+	})
+}
+
+// estimate guesses the usage of a stream that stopped before the backend reported it
+func estimate(req llm.Request, content string) llm.Response {
+	var prompt []string
+	for _, m := range req.Messages {
+		prompt = append(prompt, m.Content)
+	}
+	return llm.Response{
+		Content:          content,
+		PromptTokens:     estimateTokens(prompt...),
+		CompletionTokens: estimateTokens(content),
+	}
+}
+
+// estimateTokens counts about four characters a token, close enough for English
+func estimateTokens(texts ...string) int {
+	n := 0
+	for _, text := range texts {
+		n += len(text)
+	}
+	return (n + 3) / 4
+}
+
+// Prices returns the token prices costs are estimated with
+func (t *Tracker) Prices() Prices {
+	return t.prices
+}
+
+// MonthlyBudget is the admin's override if they set one, LLM_MONTHLY_BUDGET_USD if not
+func (t *Tracker) MonthlyBudget(ctx context.Context) (float64, error) {
+	value, err := t.queries.GetAppSetting(ctx, budgetSettingKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t.defaultBudget, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get monthly budget: %w", err)
+	}
+
+	budget, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse monthly budget %q: %w", value, err)
+	}
+	return budget, nil
+}
+
+// SetMonthlyBudget overrides the configured budget, 0 removes the limit
+func (t *Tracker) SetMonthlyBudget(ctx context.Context, budget float64) error {
+	if budget < 0 {
+		return errors.New("budget must not be negative")
+	}
+
+	err := t.queries.SetAppSetting(ctx, sqlcgen.SetAppSettingParams{
+		Key:   budgetSettingKey,
+		Value: strconv.FormatFloat(budget, 'f', -1, 64),
+	})
+	if err != nil {
+		return fmt.Errorf("set monthly budget: %w", err)
+	}
+	t.logger.Info("LLM monthly budget changed", "budget", budget)
+	return nil
+}
+
+// CostThisMonth is the estimated spend since the first of the month (UTC)
+func (t *Tracker) CostThisMonth(ctx context.Context) (float64, error) {
+	cost, err := t.queries.GetLLMCostThisMonth(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get cost this month: %w", err)
+	}
+	return cost, nil
+}
+
+// checkBudget returns ErrBudgetExceeded if this month's spend has reached the budget.
+// If the budget can't be checked the call is let through, a broken database shouldn't
+// also take out the AI features.
+func (t *Tracker) checkBudget(ctx context.Context) error {
+	budget, err := t.MonthlyBudget(ctx)
+	if err != nil {
+		t.logger.Error("Failed to check LLM budget", "error", err)
+		return nil
+	}
+	if budget <= 0 {
+		return nil
+	}
+
+	cost, err := t.CostThisMonth(ctx)
+	if err != nil {
+		t.logger.Error("Failed to check LLM budget", "error", err)
+		return nil
+	}
+	if cost >= budget {
+		t.logger.Warn("LLM call refused, monthly budget exceeded", "cost", cost, "budget", budget)
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// record saves a call's usage. It uses its own context, so a call that finished
+// just as the room was closed still gets counted.
+func (t *Tracker) record(usage sqlcgen.CreateLLMUsageParams) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := t.queries.CreateLLMUsage(ctx, usage); err != nil {
+		t.logger.Error("Failed to record LLM usage", "purpose", usage.Purpose, "error", err)
+		return
+	}
+	t.logger.Debug("LLM usage recorded",
+		"purpose", usage.Purpose,
+		"room", usage.RoomName,
+		"prompt_tokens", usage.PromptTokens,
+		"completion_tokens", usage.CompletionTokens,
+		"cost", usage.CostUsd,
+	)
+}
@@ -0,0 +1,149 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"watchma/db"
+	"watchma/db/sqlcgen"
+	"watchma/pkg/embedding"
+	"watchma/pkg/llm"
+)
+
+func newTestTracker(t *testing.T) (*Tracker, *sqlcgen.Queries) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	database, err := db.New(filepath.Join(t.TempDir(), "watchma.db"), logger)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	queries := sqlcgen.New(database.DB)
+	return NewTracker(queries, Prices{Prompt: 1, Completion: 2, Embedding: 3}, 0, logger), queries
+}
+
+func recorded(t *testing.T, queries *sqlcgen.Queries) []sqlcgen.LlmUsage {
+	t.Helper()
+	rows, err := queries.ListRecentLLMUsage(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("list usage: %v", err)
+	}
+	return rows
+}
+
+func TestStreamRecordsUsage(t *testing.T) {
+	tracker, queries := newTestTracker(t)
+	provider := tracker.Provider(llm.NewFake("Lights down, popcorn up."))
+
+	req := llm.UserPrompt("Announce it")
+	req.Purpose = llm.PurposeAnnouncement
+	req.Room = "movienight"
+	if _, err := provider.Stream(context.Background(), req, func(string) {}); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+
+	rows := recorded(t, queries)
+	if len(rows) != 1 {
+		t.Fatalf("recorded %d calls, want 1", len(rows))
+	}
+	u := rows[0]
+	if u.Purpose != llm.PurposeAnnouncement || u.RoomName != "movienight" || u.PromptTokens != 2 || u.CompletionTokens != 4 {
+		t.Errorf("usage = %+v, want the fake's token counts for the announcement", u)
+	}
+}
+
+func TestStreamRecordsInterruptedStream(t *testing.T) {
+	tracker, queries := newTestTracker(t)
+	provider := tracker.Provider(llm.NewFake("Lights down, popcorn up."))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := provider.Stream(ctx, llm.UserPrompt("Announce it"), func(string) {
+		// Leave after the first word, like a room closing mid announcement
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	rows := recorded(t, queries)
+	if len(rows) != 1 {
+		t.Fatalf("recorded %d calls, want the interrupted one", len(rows))
+	}
+	// "Announce it" and "Lights " at four characters a token
+	if u := rows[0]; u.PromptTokens != 3 || u.CompletionTokens != 2 || u.CostUsd <= 0 {
+		t.Errorf("usage = %+v, want an estimate of what was streamed", u)
+	}
+}
+
+func TestStreamSkipsCallsThatStreamedNothing(t *testing.T) {
+	tracker, queries := newTestTracker(t)
+	provider := tracker.Provider(llm.NewFake())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.Stream(ctx, llm.UserPrompt("Announce it"), func(string) {}); err == nil {
+		t.Fatal("Stream succeeded with a cancelled context")
+	}
+	if rows := recorded(t, queries); len(rows) != 0 {
+		t.Errorf("recorded %d calls, want none", len(rows))
+	}
+}
+
+func TestBudgetRefusesCalls(t *testing.T) {
+	fake := llm.NewFake("Lights down.")
+	tracker, _ := newTestTracker(t)
+	provider := tracker.Provider(fake)
+	if err := tracker.SetMonthlyBudget(context.Background(), 0.000001); err != nil {
+		t.Fatalf("SetMonthlyBudget: %v", err)
+	}
+
+	if _, err := provider.Complete(context.Background(), llm.UserPrompt("hi")); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := provider.Stream(context.Background(), llm.UserPrompt("hi"), func(string) {}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded", err)
+	}
+	if len(fake.Requests) != 1 {
+		t.Errorf("backend got %d requests, want 1", len(fake.Requests))
+	}
+}
+
+func TestEmbedderRecordsUsage(t *testing.T) {
+	tracker, queries := newTestTracker(t)
+	embedder := tracker.Embedder(embedding.NewFake())
+
+	if _, err := embedder.Embed(context.Background(), []string{"a cozy 90s heist movie"}); err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	rows := recorded(t, queries)
+	if len(rows) != 1 {
+		t.Fatalf("recorded %d calls, want 1", len(rows))
+	}
+	// 22 characters at four a token
+	if u := rows[0]; u.Purpose != llm.PurposeEmbeddings || u.Backend != embedding.BackendFake || u.PromptTokens != 6 || u.CostUsd != 6*3/1_000_000.0 {
+		t.Errorf("usage = %+v, want 6 estimated embedding tokens", u)
+	}
+}
+
+func TestEmbedderRespectsBudget(t *testing.T) {
+	tracker, _ := newTestTracker(t)
+	if err := tracker.SetMonthlyBudget(context.Background(), 0.000001); err != nil {
+		t.Fatalf("SetMonthlyBudget: %v", err)
+	}
+	embedder := tracker.Embedder(embedding.NewFake())
+
+	texts := []string{strings.Repeat("heist ", 100)}
+	if _, err := embedder.Embed(context.Background(), texts); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := embedder.Embed(context.Background(), texts); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded", err)
+	}
+}
@@ -61,18 +61,32 @@ export default defineConfig({
     // },
   ],
 
-  // Run local dev server before tests
-  webServer: {
-    command:
-      "templ generate && tailwindcss -i web/input.css -o public/styles.css && go run cmd/main.go",
-    url: "http://localhost:58008",
-    timeout: 10 * 1000, // 10 seconds to start server
-    env: {
-      PORT: "58008",
-      LOG_LEVEL: "WARN",
-      IS_DEV: "true",
-      OPENAI_API_KEY: "",
-      TEST_MODE: "true",
+  // Run local dev server before tests, with a mock OIDC issuer for single sign-on
+  webServer: [
+    {
+      command: "go run ./cmd/mockoidc -port 58009",
+      url: "http://localhost:58009/.well-known/openid-configuration",
+      timeout: 10 * 1000,
     },
-  },
+    {
+      command:
+        "templ generate && tailwindcss -i web/input.css -o public/styles.css && go run cmd/main.go",
+      url: "http://localhost:58008",
+      timeout: 10 * 1000, // 10 seconds to start server
+      env: {
+        PORT: "58008",
+        LOG_LEVEL: "WARN",
+        IS_DEV: "true",
+        OPENAI_API_KEY: "",
+        LLM_BACKEND: "",
+        LLM_API_KEY: "",
+        TEST_MODE: "true",
+        OIDC_ISSUER_URL: "http://localhost:58009",
+        OIDC_CLIENT_ID: "watchma",
+        OIDC_CLIENT_SECRET: "watchma-secret",
+        OIDC_REDIRECT_URL: "http://localhost:58008/login/oidc/callback",
+        OIDC_PROVIDER_NAME: "Mock SSO",
+      },
+    },
+  ],
 });
@@ -0,0 +1,103 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import {
+  createRoom,
+  generateRoomName,
+  joinRoom,
+  waitForPlayer,
+} from "./helpers/rooms";
+
+/**
+ * CSRF protection tests. page.request shares the signed in browser's cookies, so these
+ * requests look exactly like a forged form or fetch from another site would.
+ */
+test.describe("CSRF protection", () => {
+  test("Forged request without a token is rejected", async ({ page }) => {
+    await signup(page, generateUsername("csrf"), "MischiefMANAGED07");
+
+    const response = await page.request.post("/host", {
+      form: {
+        roomName: generateRoomName("Forged"),
+        draftNumber: "3",
+        maxplayers: "2",
+      },
+      maxRedirects: 0,
+    });
+
+    expect(response.status()).toBe(403);
+  });
+
+  test("Request from another origin is rejected even with the token", async ({
+    page,
+  }) => {
+    await signup(page, generateUsername("csrf"), "MischiefMANAGED07");
+    await page.goto("/host");
+    const token = await page.locator('input[name="csrf_token"]').inputValue();
+
+    const response = await page.request.post("/host", {
+      form: {
+        csrf_token: token,
+        roomName: generateRoomName("Forged"),
+        draftNumber: "3",
+        maxplayers: "2",
+      },
+      headers: { Origin: "https://evil.example" },
+      maxRedirects: 0,
+    });
+
+    expect(response.status()).toBe(403);
+  });
+
+  test("Forged logout leaves the user signed in", async ({ page }) => {
+    await signup(page, generateUsername("csrf"), "MischiefMANAGED07");
+
+    const response = await page.request.post("/logout", {
+      headers: { "X-CSRF-Token": "not-the-token" },
+      maxRedirects: 0,
+    });
+    expect(response.status()).toBe(403);
+
+    await page.goto("/host");
+    await expect(page).toHaveURL("/host");
+  });
+
+  test("Requests from our own pages still work", async ({ page }) => {
+    await signup(page, generateUsername("csrf"), "MischiefMANAGED07");
+    const roomName = generateRoomName("Allowed");
+
+    await createRoom(page, roomName, 3, 2);
+
+    await expect(page).toHaveURL(`/room/${roomName}/lobby`);
+  });
+
+  test("Leaving the page with the beacon passes the check", async ({
+    browser,
+  }) => {
+    const hostContext = await browser.newContext();
+    const friendContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const friend = await friendContext.newPage();
+    const roomName = generateRoomName("Beacon");
+    const friendName = generateUsername("friend");
+
+    try {
+      await signup(host, generateUsername("host"), "MischiefMANAGED07");
+      await createRoom(host, roomName, 3, 4);
+
+      await signup(friend, friendName, "MischiefMANAGED07");
+      await joinRoom(friend, roomName);
+      await waitForPlayer(host, friendName);
+
+      // pagehide sends the leave beacon, which can't set the token header
+      await friend.evaluate(() => {
+        (window as any).allowNavigation = true;
+      });
+      await friend.goto("/");
+
+      await expect(host.locator(`text=${friendName}`)).toHaveCount(0);
+    } finally {
+      await hostContext.close();
+      await friendContext.close();
+    }
+  });
+});
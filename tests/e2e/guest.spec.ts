@@ -0,0 +1,66 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import { createRoom, generateRoomName, waitForPlayer } from "./helpers/rooms";
+
+/**
+ * Guests join a room from its link without an account
+ */
+test.describe("Guest players", () => {
+  test("Room link lets a guest join and keeps them in that room", async ({
+    browser,
+  }) => {
+    const hostContext = await browser.newContext();
+    const guestContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const guest = await guestContext.newPage();
+    const roomName = generateRoomName("Guests");
+    const guestName = `Guest${Math.floor(Math.random() * 100000)}`;
+
+    try {
+      await signup(host, generateUsername("host"), "PopcornPLEASE42");
+      await createRoom(host, roomName, 3, 4);
+
+      // Signed out visitors to the lobby are offered the guest join page
+      await guest.goto(`/room/${roomName}/lobby`);
+      await expect(guest).toHaveURL(`/room/${roomName}/guest`);
+
+      await guest.fill("#guestName", guestName);
+      await guest.click('button:has-text("Join as Guest")');
+      await expect(guest).toHaveURL(`/room/${roomName}/lobby`);
+      await waitForPlayer(host, `${guestName} (guest)`);
+
+      // Anywhere else sends them back to their room
+      await guest.goto("/statistics");
+      await expect(
+        guest.locator("text=Guests can only play in the room they joined"),
+      ).toBeVisible();
+      const response = await guest.request.get("/host");
+      expect(response.status()).toBe(403);
+    } finally {
+      await hostContext.close();
+      await guestContext.close();
+    }
+  });
+
+  test("Guest names are checked", async ({ browser }) => {
+    const hostContext = await browser.newContext();
+    const guestContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const guest = await guestContext.newPage();
+    const roomName = generateRoomName("GuestNames");
+    const hostName = generateUsername("host");
+
+    try {
+      await signup(host, hostName, "PopcornPLEASE42");
+      await createRoom(host, roomName, 3, 4);
+
+      await guest.goto(`/room/${roomName}/guest`);
+      await guest.fill("#guestName", "<script>");
+      await guest.click('button:has-text("Join as Guest")');
+      await expect(guest.locator("#error")).toContainText("Pick a name");
+    } finally {
+      await hostContext.close();
+      await guestContext.close();
+    }
+  });
+});
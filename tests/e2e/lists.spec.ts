@@ -0,0 +1,115 @@
+import { expect, test, type Page } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import {
+  clickReady,
+  generateRoomName,
+  joinRoom,
+  startGame,
+} from "./helpers/rooms";
+
+async function addToWatchlist(page: Page, search: string, movie: string) {
+  await page.goto("/lists");
+  await page.fill("#watchlistSearchInput", search);
+  await page.locator(`#watchlistSearch label:has(input[aria-label="${movie}"])`).click();
+  await expect(page.locator("#watchlist")).toContainText(movie);
+}
+
+async function draftedMovies(page: Page) {
+  const movies = page.locator('label:has(input[type="checkbox"])');
+  await expect(movies.first()).toBeVisible({ timeout: 5000 });
+  return movies.locator("input").evaluateAll((inputs) =>
+    inputs.map((input) => input.getAttribute("aria-label"))
+  );
+}
+
+/**
+ * Users keep watchlists and import collections and playlists, rooms can draft from them
+ */
+test.describe("Watchlists and lists", () => {
+  test("Room drafts from the players' watchlists", async ({ browser }) => {
+    const hostContext = await browser.newContext();
+    const playerContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const player = await playerContext.newPage();
+    const roomName = generateRoomName("Watch");
+
+    try {
+      await signup(host, generateUsername("watchhost"), "PopcornPLEASE42");
+      await addToWatchlist(host, "forrest", "Forrest Gump");
+      await signup(player, generateUsername("watchplayer"), "PopcornPLEASE42");
+      await addToWatchlist(player, "pulp", "Pulp Fiction");
+
+      await host.goto("/host");
+      await host.fill('input[name="roomName"]', roomName);
+      await host.selectOption('select[name="source"]', "watchlists");
+      await host.click('button[type="submit"]');
+      await host.waitForURL(`/room/${roomName}/lobby`);
+      await expect(host.locator("#draftSource")).toContainText("Players' watchlists");
+
+      await joinRoom(player, roomName);
+      await clickReady(host);
+      await clickReady(player);
+      await startGame(host);
+
+      expect((await draftedMovies(host)).sort()).toEqual(["Forrest Gump", "Pulp Fiction"]);
+    } finally {
+      await hostContext.close();
+      await playerContext.close();
+    }
+  });
+
+  test("Imported collection can be drafted from", async ({ browser }) => {
+    const hostContext = await browser.newContext();
+    const playerContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const player = await playerContext.newPage();
+    const roomName = generateRoomName("Nolan");
+
+    try {
+      await signup(host, generateUsername("listhost"), "PopcornPLEASE42");
+      await host.goto("/lists");
+      await host.click("#importLists");
+      await expect(host.locator("#movieLists")).toContainText("Christopher Nolan");
+      await expect(host.locator("#movieLists")).toContainText("Date Night");
+
+      await host.goto("/host");
+      await host.fill('input[name="roomName"]', roomName);
+      await host.selectOption('select[name="source"]', { label: "Christopher Nolan (2)" });
+      await host.click('button[type="submit"]');
+      await host.waitForURL(`/room/${roomName}/lobby`);
+      await expect(host.locator("#draftSource")).toContainText("Christopher Nolan");
+
+      await signup(player, generateUsername("listplayer"), "PopcornPLEASE42");
+      await joinRoom(player, roomName);
+      await clickReady(host);
+      await clickReady(player);
+      await startGame(host);
+
+      expect((await draftedMovies(host)).sort()).toEqual(["Inception", "The Dark Knight"]);
+    } finally {
+      await hostContext.close();
+      await playerContext.close();
+    }
+  });
+
+  test("Lists of other users are not found", async ({ browser }) => {
+    const ownerContext = await browser.newContext();
+    const otherContext = await browser.newContext();
+    const owner = await ownerContext.newPage();
+    const other = await otherContext.newPage();
+
+    try {
+      await signup(owner, generateUsername("listowner"), "PopcornPLEASE42");
+      await owner.goto("/lists");
+      await owner.click("#importLists");
+      const href = await owner.locator('#movieLists a:has-text("Date Night")').getAttribute("href");
+
+      await signup(other, generateUsername("listother"), "PopcornPLEASE42");
+      const response = await other.goto(href!);
+      expect(response?.status()).toBe(404);
+    } finally {
+      await ownerContext.close();
+      await otherContext.close();
+    }
+  });
+});
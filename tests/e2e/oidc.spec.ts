@@ -0,0 +1,48 @@
+import { expect, Page, test } from "@playwright/test";
+import { generateUsername, logout, signup } from "./helpers/auth";
+
+/**
+ * Single sign-on tests against the mock issuer from cmd/mockoidc, which lets anyone
+ * sign in as any username
+ */
+async function signInWithSSO(page: Page, username: string) {
+  await page.goto("/login");
+  await page.click("#oidcStart");
+
+  // On the issuer's page now
+  await page.fill('input[name="username"]', username);
+  await page.click('button[type="submit"]');
+}
+
+test.describe("Single sign-on", () => {
+  test("New user is created on first sign in", async ({ page }) => {
+    const username = generateUsername("sso");
+
+    await signInWithSSO(page, username);
+
+    await expect(page).toHaveURL("/");
+    await page.goto("/account");
+    await expect(page.locator("text=single sign-on")).toBeVisible();
+  });
+
+  test("Existing user is linked by username", async ({ page }) => {
+    const username = generateUsername("ssolink");
+
+    await signup(page, username, "AccioAccount42");
+    await logout(page);
+
+    await signInWithSSO(page, username);
+
+    await expect(page).toHaveURL("/");
+    // The dropdown shows the username capitalized
+    await expect(page.locator("#userDropdown")).toContainText(username.slice(1));
+  });
+
+  test("Callback without a started sign in is rejected", async ({ page }) => {
+    await page.goto("/login/oidc/callback?code=forged&state=forged");
+
+    await expect(page.locator("#error")).toContainText("try again");
+    await page.goto("/host");
+    await expect(page).toHaveURL(/\/login/);
+  });
+});
@@ -0,0 +1,79 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import {
+  clickReady,
+  generateRoomName,
+  joinRoom,
+  startGame,
+} from "./helpers/rooms";
+
+/**
+ * Hosts limit which movies a room drafts from
+ */
+test.describe("Movie pool", () => {
+  test("Room only drafts from its pool", async ({ browser }) => {
+    const hostContext = await browser.newContext();
+    const playerContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const player = await playerContext.newPage();
+    const roomName = generateRoomName("Pool");
+
+    try {
+      await signup(host, generateUsername("poolhost"), "PopcornPLEASE42");
+      await host.goto("/host");
+      await host.fill('input[name="roomName"]', roomName);
+      await host.click("#moviePool summary");
+      await host.check('input[name="genres"][value="Comedy"]');
+      await host.selectOption('select[name="maxRating"]', "PG-13");
+      await host.click('button[type="submit"]');
+      await host.waitForURL(`/room/${roomName}/lobby`);
+      await expect(host.locator("#moviePool")).toContainText("Comedy, up to PG-13");
+
+      await signup(player, generateUsername("poolplayer"), "PopcornPLEASE42");
+      await joinRoom(player, roomName);
+      await clickReady(host);
+      await clickReady(player);
+      await startGame(host);
+
+      const movies = host.locator('label:has(input[type="checkbox"])');
+      await expect(movies.first()).toBeVisible({ timeout: 5000 });
+      for (const alt of await movies.locator("img").evaluateAll((imgs) =>
+        imgs.map((img) => img.getAttribute("alt"))
+      )) {
+        expect(alt).not.toContain("The Matrix");
+      }
+    } finally {
+      await hostContext.close();
+      await playerContext.close();
+    }
+  });
+
+  test("Room can't start when nothing fits its pool", async ({ browser }) => {
+    const hostContext = await browser.newContext();
+    const playerContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const player = await playerContext.newPage();
+    const roomName = generateRoomName("Empty");
+
+    try {
+      await signup(host, generateUsername("emptyhost"), "PopcornPLEASE42");
+      await host.goto("/host");
+      await host.fill('input[name="roomName"]', roomName);
+      await host.click("#moviePool summary");
+      await host.fill('input[name="minYear"]', "2090");
+      await host.click('button[type="submit"]');
+      await host.waitForURL(`/room/${roomName}/lobby`);
+
+      await signup(player, generateUsername("emptyplayer"), "PopcornPLEASE42");
+      await joinRoom(player, roomName);
+      await clickReady(host);
+      await clickReady(player);
+      await host.click('button:has-text("Start Game!")');
+      await expect(host.locator("#error")).toContainText("movie pool");
+      await expect(host.locator("#lobbyPage")).toBeVisible();
+    } finally {
+      await hostContext.close();
+      await playerContext.close();
+    }
+  });
+});
@@ -0,0 +1,58 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import { createRoomAndReady2Users } from "./helpers/game";
+import { generateRoomName, startGame } from "./helpers/rooms";
+
+/**
+ * Hosts save room settings as presets, and can play again from the results
+ */
+test.describe("Room presets", () => {
+  test("Saved preset fills in the host form", async ({ page }) => {
+    await signup(page, generateUsername("preset"), "PopcornPLEASE42");
+
+    await page.goto("/host");
+    await page.fill('input[name="roomName"]', generateRoomName("Preset"));
+    await page.selectOption('select[name="draftNumber"]', "2");
+    await page.selectOption('select[name="maxplayers"]', "7");
+    await page.selectOption('select[name="visibility"]', "unlisted");
+    await page.fill('input[name="presetName"]', "Friday Night");
+    await page.click('button[type="submit"]');
+    await expect(page.locator("#lobbyPage")).toBeVisible();
+
+    await page.goto("/host");
+    await expect(page.locator("#hostPresets")).toContainText("Friday Night");
+    await page.click('#hostPresets a:has-text("Use")');
+    await expect(page.locator('select[name="draftNumber"]')).toHaveValue("2");
+    await expect(page.locator('select[name="maxplayers"]')).toHaveValue("7");
+    await expect(page.locator('select[name="visibility"]')).toHaveValue("unlisted");
+
+    await page.click('#hostPresets button:has-text("Delete")');
+    await expect(page.locator("#hostPresets")).toHaveCount(0);
+  });
+
+  test("Play again brings everyone to a new room", async ({ browser }) => {
+    const { pageA, pageB, contextA, contextB } = await createRoomAndReady2Users(
+      browser,
+    );
+
+    try {
+      await startGame(pageA);
+      for (const page of [pageA, pageB]) {
+        await page.locator('label:has(input[type="checkbox"])').first().click();
+        await page.click("#draftSubmit");
+      }
+      for (const page of [pageA, pageB]) {
+        await page.locator('label:has(input[type="checkbox"])').first().click();
+        await page.click("#votingSubmit");
+      }
+
+      await pageA.click("#playAgain", { timeout: 10_000 });
+      await expect(pageA).toHaveURL(/-2\/lobby$/);
+      await expect(pageB).toHaveURL(/-2\/lobby$/);
+      await expect(pageA.locator("#players")).toContainText("You are the host!");
+    } finally {
+      await contextA.close();
+      await contextB.close();
+    }
+  });
+});
@@ -0,0 +1,51 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import { createRoom, generateRoomName, waitForPlayer } from "./helpers/rooms";
+
+/**
+ * Rooms have a join code, a share link and a QR code of it in the lobby
+ */
+test.describe("Sharing rooms", () => {
+  test("Share link brings a signed out friend back to the room after signing in", async ({
+    browser,
+  }) => {
+    const hostContext = await browser.newContext();
+    const friendContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const friend = await friendContext.newPage();
+    const roomName = generateRoomName("Share");
+    const friendName = generateUsername("friend");
+
+    try {
+      await signup(host, generateUsername("host"), "PopcornPLEASE42");
+      await createRoom(host, roomName, 3, 4);
+
+      const joinCode = (await host.locator("#joinCode").textContent())?.trim();
+      expect(joinCode).toMatch(/^[A-Z2-9]{8}$/);
+      const shareURL = await host.locator("#shareURL").getAttribute("href");
+      expect(shareURL).toContain(`/j/${joinCode}`);
+
+      const qr = await host.request.get(`/room/${roomName}/qr.svg`);
+      expect(qr.headers()["content-type"]).toBe("image/svg+xml");
+      expect(await qr.text()).toContain("<svg");
+
+      // Signing in from the guest page lands back in the room
+      await friend.goto(`/j/${joinCode}`);
+      await expect(friend).toHaveURL(`/room/${roomName}/guest?code=${joinCode}`);
+      await friend.click("#guestLogin");
+      await friend.fill('input[name="username"]', friendName);
+      await friend.fill('input[name="password"]', "PopcornPLEASE42");
+      await friend.click('button[type="submit"]');
+      await expect(friend).toHaveURL(`/room/${roomName}/lobby?code=${joinCode}`);
+      await waitForPlayer(host, friendName);
+    } finally {
+      await hostContext.close();
+      await friendContext.close();
+    }
+  });
+
+  test("Unknown join codes are not found", async ({ page }) => {
+    const response = await page.goto("/j/ZZZZZZZZ");
+    expect(response?.status()).toBe(404);
+  });
+});
@@ -0,0 +1,44 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import { createRoom, generateRoomName, joinRoom } from "./helpers/rooms";
+
+/**
+ * People who can't play watch instead, and the host can let them play from the lobby
+ */
+test.describe("Spectators", () => {
+  test("Full room lets people watch until the host lets them play", async ({
+    browser,
+  }) => {
+    const hostContext = await browser.newContext();
+    const playerContext = await browser.newContext();
+    const watcherContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const player = await playerContext.newPage();
+    const watcher = await watcherContext.newPage();
+    const roomName = generateRoomName("Spectate");
+    const watcherName = generateUsername("watcher");
+
+    try {
+      await signup(host, generateUsername("host"), "PopcornPLEASE42");
+      await createRoom(host, roomName, 3, 2);
+      await signup(player, generateUsername("player"), "PopcornPLEASE42");
+      await joinRoom(player, roomName);
+
+      await signup(watcher, watcherName, "PopcornPLEASE42");
+      await watcher.goto(`/room/${roomName}/lobby`);
+      await expect(watcher.locator("#spectatePage")).toBeVisible();
+      await expect(watcher.locator("#spectateStep")).toContainText("2/2 seats taken");
+      await expect(host.locator("#spectators")).toContainText(watcherName);
+
+      // A seat frees up, the host lets the spectator play
+      player.on("dialog", (dialog) => dialog.accept());
+      await player.click('button:has-text("Leave Room")');
+      await host.click('#spectators button:has-text("Let play")');
+      await expect(watcher.locator("#lobbyPage")).toBeVisible();
+    } finally {
+      await hostContext.close();
+      await playerContext.close();
+      await watcherContext.close();
+    }
+  });
+});
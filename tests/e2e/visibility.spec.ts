@@ -0,0 +1,82 @@
+import { expect, test } from "@playwright/test";
+import { generateUsername, signup } from "./helpers/auth";
+import { generateRoomName, waitForPlayer } from "./helpers/rooms";
+
+/**
+ * Hosts pick who can join: anyone, only people with the link, or people with the password
+ */
+test.describe("Room visibility", () => {
+  test("Unlisted rooms are hidden and need the share link", async ({
+    browser,
+  }) => {
+    const hostContext = await browser.newContext();
+    const friendContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const friend = await friendContext.newPage();
+    const roomName = generateRoomName("Unlisted");
+    const friendName = generateUsername("friend");
+
+    try {
+      await signup(host, generateUsername("host"), "PopcornPLEASE42");
+      await host.goto("/host");
+      await host.fill('input[name="roomName"]', roomName);
+      await host.selectOption('select[name="visibility"]', "unlisted");
+      await host.click('button[type="submit"]');
+      await host.waitForURL(`/room/${roomName}/lobby`);
+      const shareURL = await host.locator("#shareURL").getAttribute("href");
+
+      await signup(friend, friendName, "PopcornPLEASE42");
+      await friend.goto("/join");
+      await expect(friend.locator("#joinTable")).not.toContainText(roomName);
+      await friend.goto(`/room/${roomName}/lobby`);
+      await expect(friend.locator("#lobbyPage")).toHaveCount(0);
+
+      await friend.goto(shareURL!);
+      await expect(friend.locator("#lobbyPage")).toBeVisible();
+      await waitForPlayer(host, friendName);
+    } finally {
+      await hostContext.close();
+      await friendContext.close();
+    }
+  });
+
+  test("Password protected rooms ask for the password", async ({ browser }) => {
+    const hostContext = await browser.newContext();
+    const friendContext = await browser.newContext();
+    const host = await hostContext.newPage();
+    const friend = await friendContext.newPage();
+    const roomName = generateRoomName("Locked");
+    const friendName = generateUsername("friend");
+
+    try {
+      await signup(host, generateUsername("host"), "PopcornPLEASE42");
+      await host.goto("/host");
+      await host.fill('input[name="roomName"]', roomName);
+      await host.selectOption('select[name="visibility"]', "password");
+      await host.fill("#roomPassword", "open sesame");
+      await host.click('button[type="submit"]');
+      await host.waitForURL(`/room/${roomName}/lobby`);
+
+      await signup(friend, friendName, "PopcornPLEASE42");
+      await friend.goto("/join");
+      await friend.click(`a[href="room/${roomName}/lobby"]`);
+      await expect(friend.locator(`text=${roomName} needs a password`)).toBeVisible();
+
+      // The QR code holds the share link, which skips the password
+      const qr = await friend.request.get(`/room/${roomName}/qr.svg`);
+      expect(qr.status()).toBe(404);
+
+      await friend.fill("#roomPassword", "wrong");
+      await friend.click('button:has-text("Join Room")');
+      await expect(friend.locator("#error")).toContainText("Wrong password");
+
+      await friend.fill("#roomPassword", "open sesame");
+      await friend.click('button:has-text("Join Room")');
+      await expect(friend.locator("#lobbyPage")).toBeVisible();
+      await waitForPlayer(host, friendName);
+    } finally {
+      await hostContext.close();
+      await friendContext.close();
+    }
+  });
+});
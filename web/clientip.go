@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	appctx "watchma/pkg/context"
+)
+
+// ClientIP is the address the request came from, without the port. Behind a trusted
+// proxy it is the address the proxy forwarded the request for.
+func ClientIP(r *http.Request) string {
+	if ip := appctx.GetClientIP(r); ip != "" {
+		return ip
+	}
+	return RemoteIP(r)
+}
+
+// RemoteIP is the address of whoever opened the connection, the proxy itself when
+// there is one
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RealIP sets the address ClientIP returns from X-Forwarded-For, but only on requests
+// from one of the trusted proxies. Anyone else could send the header to dodge rate
+// limits or get another address locked out.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote, err := netip.ParseAddr(RemoteIP(r))
+			if err == nil && isTrusted(remote, trusted) {
+				if ip := forwardedFor(r, trusted); ip != "" {
+					r = appctx.SetClientIPInRequest(r, ip)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor walks X-Forwarded-For back from the nearest hop, the first address that
+// isn't a trusted proxy is the client. Anything before it was sent by the client and
+// can't be believed.
+func forwardedFor(r *http.Request, trusted []netip.Prefix) string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		client = addr.Unmap().String()
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
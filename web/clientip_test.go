@@ -0,0 +1,49 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name         string
+		trusted      []netip.Prefix
+		remoteAddr   string
+		forwardedFor []string
+		wantClientIP string
+	}{
+		{"no proxies configured", nil, "10.0.0.2:5000", []string{"203.0.113.7"}, "10.0.0.2"},
+		{"untrusted sender", trusted, "198.51.100.9:5000", []string{"203.0.113.7"}, "198.51.100.9"},
+		{"trusted proxy", trusted, "10.0.0.2:5000", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"trusted proxy without header", trusted, "10.0.0.2:5000", nil, "10.0.0.2"},
+		{"chain of proxies", trusted, "10.0.0.2:5000", []string{"203.0.113.7, 10.0.0.3"}, "203.0.113.7"},
+		{"spoofed hops are ignored", trusted, "10.0.0.2:5000", []string{"192.0.2.1, 203.0.113.7"}, "203.0.113.7"},
+		{"repeated headers", trusted, "10.0.0.2:5000", []string{"192.0.2.1", "203.0.113.7"}, "203.0.113.7"},
+		{"garbage hop", trusted, "10.0.0.2:5000", []string{"203.0.113.7, nonsense"}, "10.0.0.2"},
+		{"mapped IPv4", trusted, "[::ffff:10.0.0.2]:5000", []string{"::ffff:203.0.113.7"}, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(tt.trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.wantClientIP {
+				t.Errorf("ClientIP = %q, want %q", got, tt.wantClientIP)
+			}
+		})
+	}
+}
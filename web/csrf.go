@@ -0,0 +1,130 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	appctx "watchma/pkg/context"
+)
+
+const (
+	CSRFCookieName = "csrf_token"
+	// CSRFHeaderName is set on every Datastar request by the script in the layout
+	CSRFHeaderName = "X-CSRF-Token"
+	// CSRFFieldName is the hidden input classic form posts carry the token in
+	CSRFFieldName = "csrf_token"
+)
+
+const csrfTokenBytes = 32
+
+// CSRF protects every state changing request. The browser has to prove it came from one
+// of our pages in two ways: Origin (or Referer) must be this host, and the request must
+// echo the token from the csrf_token cookie in the X-CSRF-Token header or csrf_token
+// form field. Safe methods only get a token issued.
+func CSRF(secure bool, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := csrfCookieToken(r)
+			if token == "" {
+				token = newCSRFToken()
+				http.SetCookie(w, &http.Cookie{
+					Name:     CSRFCookieName,
+					Value:    token,
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   secure,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			r = appctx.SetCSRFTokenInRequest(r, token)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if reason := checkCSRF(r, token); reason != "" {
+				logger.Warn("Rejected cross-site request", "reason", reason, "method", r.Method, "path", r.URL.Path, "ip", ClientIP(r))
+				message := "Your session is out of date, refresh the page and try again"
+				if r.Header.Get("Datastar-Request") == "true" {
+					SendSSEError(w, r, message, logger)
+					return
+				}
+				http.Error(w, message, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkCSRF returns why the request looks forged, or "" when it's fine
+func checkCSRF(r *http.Request, token string) string {
+	// Browsers that send Sec-Fetch-Site tell us directly
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		return "cross-site fetch"
+	}
+
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !sameHost(origin, r.Host) {
+			return "origin mismatch"
+		}
+	} else if referer := r.Header.Get("Referer"); referer != "" {
+		if !sameHost(referer, r.Host) {
+			return "referer mismatch"
+		}
+	}
+
+	sent := r.Header.Get(CSRFHeaderName)
+	if sent == "" && isFormPost(r) {
+		sent = r.PostFormValue(CSRFFieldName)
+	}
+	if sent == "" {
+		return "missing token"
+	}
+	if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		return "token mismatch"
+	}
+	return ""
+}
+
+// sameHost compares hosts only. Behind a TLS terminating proxy the scheme we see isn't
+// the one the browser used.
+func sameHost(rawURL, host string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
+
+func isFormPost(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(contentType, "multipart/form-data")
+}
+
+func csrfCookieToken(r *http.Request) string {
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil {
+		return ""
+	}
+	// Anything that isn't one of our tokens gets replaced
+	if b, err := base64.RawURLEncoding.DecodeString(cookie.Value); err != nil || len(b) != csrfTokenBytes {
+		return ""
+	}
+	return cookie.Value
+}
+
+func newCSRFToken() string {
+	b := make([]byte, csrfTokenBytes)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,207 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/auth"
+	"watchma/pkg/host"
+	"watchma/pkg/usage"
+	"watchma/web"
+	"watchma/web/features/admin/pages"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type handlers struct {
+	queries      *sqlcgen.Queries
+	authService  *auth.AuthService
+	usageTracker *usage.Tracker // nil when AI features are disabled
+	logger       *slog.Logger
+}
+
+func newHandlers(queries *sqlcgen.Queries, authService *auth.AuthService, usageTracker *usage.Tracker, logger *slog.Logger) *handlers {
+	return &handlers{
+		queries:      queries,
+		authService:  authService,
+		usageTracker: usageTracker,
+		logger:       logger,
+	}
+}
+
+func (h *handlers) personas(w http.ResponseWriter, r *http.Request) {
+	personas, err := h.queries.ListHostPersonas(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list host personas", "error", err)
+		http.Error(w, "Failed to load personas", http.StatusInternalServerError)
+		return
+	}
+
+	web.RenderPage(pages.Personas(personas), "Host Personas", w, r)
+}
+
+func (h *handlers) newPersona(w http.ResponseWriter, r *http.Request) {
+	web.RenderPage(pages.PersonaForm(sqlcgen.HostPersona{}), "New Persona", w, r)
+}
+
+func (h *handlers) createPersona(w http.ResponseWriter, r *http.Request) {
+	persona, err := personaFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.queries.CreateHostPersona(r.Context(), sqlcgen.CreateHostPersonaParams{
+		Name:                persona.Name,
+		SystemPrompt:        persona.SystemPrompt,
+		LobbyArrivalPrompt:  persona.LobbyArrivalPrompt,
+		DraftOverlapPrompt:  persona.DraftOverlapPrompt,
+		VotingHotTakePrompt: persona.VotingHotTakePrompt,
+		TiePrompt:           persona.TiePrompt,
+	})
+	if err != nil {
+		h.logger.Error("Failed to create host persona", "name", persona.Name, "error", err)
+		http.Error(w, "Failed to save persona, is the name already taken?", http.StatusConflict)
+		return
+	}
+
+	h.logger.Info("Host persona created", "name", persona.Name)
+	http.Redirect(w, r, "/admin/personas", http.StatusSeeOther)
+}
+
+func (h *handlers) editPersona(w http.ResponseWriter, r *http.Request) {
+	persona, ok := h.getPersona(w, r)
+	if !ok {
+		return
+	}
+
+	web.RenderPage(pages.PersonaForm(persona), "Edit Persona", w, r)
+}
+
+func (h *handlers) updatePersona(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.getPersona(w, r)
+	if !ok {
+		return
+	}
+
+	persona, err := personaFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.queries.UpdateHostPersona(r.Context(), sqlcgen.UpdateHostPersonaParams{
+		Name:                persona.Name,
+		SystemPrompt:        persona.SystemPrompt,
+		LobbyArrivalPrompt:  persona.LobbyArrivalPrompt,
+		DraftOverlapPrompt:  persona.DraftOverlapPrompt,
+		VotingHotTakePrompt: persona.VotingHotTakePrompt,
+		TiePrompt:           persona.TiePrompt,
+		ID:                  existing.ID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to update host persona", "id", existing.ID, "error", err)
+		http.Error(w, "Failed to save persona, is the name already taken?", http.StatusConflict)
+		return
+	}
+
+	h.logger.Info("Host persona updated", "name", persona.Name)
+	http.Redirect(w, r, "/admin/personas", http.StatusSeeOther)
+}
+
+func (h *handlers) activatePersona(w http.ResponseWriter, r *http.Request) {
+	persona, ok := h.getPersona(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.SetActiveHostPersona(r.Context(), persona.ID); err != nil {
+		h.logger.Error("Failed to activate host persona", "id", persona.ID, "error", err)
+		http.Error(w, "Failed to activate persona", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Host persona activated", "name", persona.Name)
+	http.Redirect(w, r, "/admin/personas", http.StatusSeeOther)
+}
+
+func (h *handlers) deletePersona(w http.ResponseWriter, r *http.Request) {
+	persona, ok := h.getPersona(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteHostPersona(r.Context(), persona.ID); err != nil {
+		h.logger.Error("Failed to delete host persona", "id", persona.ID, "error", err)
+		http.Error(w, "Failed to delete persona", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Host persona deleted", "name", persona.Name)
+	http.Redirect(w, r, "/admin/personas", http.StatusSeeOther)
+}
+
+// getPersona loads the persona in the URL, writing the error response if it can't
+func (h *handlers) getPersona(w http.ResponseWriter, r *http.Request) (sqlcgen.HostPersona, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid persona id", http.StatusBadRequest)
+		return sqlcgen.HostPersona{}, false
+	}
+
+	persona, err := h.queries.GetHostPersona(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Persona not found", http.StatusNotFound)
+		return sqlcgen.HostPersona{}, false
+	}
+	if err != nil {
+		h.logger.Error("Failed to get host persona", "id", id, "error", err)
+		http.Error(w, "Failed to load persona", http.StatusInternalServerError)
+		return sqlcgen.HostPersona{}, false
+	}
+	return persona, true
+}
+
+// personaFromForm reads and validates the persona form. Every prompt is required and
+// must be a valid template, so a typo can't break the host in the middle of a game.
+func personaFromForm(r *http.Request) (sqlcgen.HostPersona, error) {
+	if err := r.ParseForm(); err != nil {
+		return sqlcgen.HostPersona{}, fmt.Errorf("error parsing form")
+	}
+
+	persona := sqlcgen.HostPersona{
+		Name:                strings.TrimSpace(r.FormValue("name")),
+		SystemPrompt:        strings.TrimSpace(r.FormValue("systemPrompt")),
+		LobbyArrivalPrompt:  strings.TrimSpace(r.FormValue("lobbyArrivalPrompt")),
+		DraftOverlapPrompt:  strings.TrimSpace(r.FormValue("draftOverlapPrompt")),
+		VotingHotTakePrompt: strings.TrimSpace(r.FormValue("votingHotTakePrompt")),
+		TiePrompt:           strings.TrimSpace(r.FormValue("tiePrompt")),
+	}
+
+	fields := []struct{ label, value string }{
+		{"Name", persona.Name},
+		{"System prompt", persona.SystemPrompt},
+		{"Lobby arrival prompt", persona.LobbyArrivalPrompt},
+		{"Draft overlap prompt", persona.DraftOverlapPrompt},
+		{"Voting hot take prompt", persona.VotingHotTakePrompt},
+		{"Tie prompt", persona.TiePrompt},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			return sqlcgen.HostPersona{}, fmt.Errorf("%s is required", f.label)
+		}
+	}
+	// The name and system prompt are used as is, only the event prompts are templates
+	for _, f := range fields[2:] {
+		if _, err := host.RenderPrompt(f.value, host.Event{}); err != nil {
+			return sqlcgen.HostPersona{}, fmt.Errorf("%s is not a valid template: %v", f.label, err)
+		}
+	}
+	return persona, nil
+}
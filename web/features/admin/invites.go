@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"watchma/pkg/auth"
+	appctx "watchma/pkg/context"
+	"watchma/web"
+	"watchma/web/features/admin/pages"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *handlers) invites(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	mode, err := h.authService.RegistrationMode(ctx)
+	if err != nil {
+		h.invitesError(w, err)
+		return
+	}
+	codes, err := h.queries.ListInviteCodes(ctx)
+	if err != nil {
+		h.invitesError(w, err)
+		return
+	}
+	redemptions, err := h.queries.ListInviteRedemptions(ctx)
+	if err != nil {
+		h.invitesError(w, err)
+		return
+	}
+
+	web.RenderPage(pages.Invites(pages.InvitesPage{
+		Mode:        mode,
+		Codes:       codes,
+		Redemptions: redemptions,
+		Now:         time.Now(),
+	}), "Invites", w, r)
+}
+
+func (h *handlers) invitesError(w http.ResponseWriter, err error) {
+	h.logger.Error("Failed to load invites", "error", err)
+	http.Error(w, "Failed to load invites", http.StatusInternalServerError)
+}
+
+func (h *handlers) createInvite(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	maxUses, err := strconv.ParseInt(r.FormValue("maxUses"), 10, 64)
+	if err != nil || maxUses < 0 {
+		http.Error(w, "Uses must be a whole number, 0 for unlimited", http.StatusBadRequest)
+		return
+	}
+	expiresHours, err := strconv.Atoi(r.FormValue("expiresHours"))
+	if err != nil || expiresHours < 0 {
+		http.Error(w, "Expiry must be a whole number of hours, 0 for never", http.StatusBadRequest)
+		return
+	}
+
+	me := appctx.GetUserFromRequest(r)
+	if _, err := h.authService.CreateInvite(r.Context(), me, maxUses, time.Duration(expiresHours)*time.Hour); err != nil {
+		h.logger.Error("Failed to create invite code", "error", err)
+		http.Error(w, "Failed to create invite code", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invites", http.StatusSeeOther)
+}
+
+func (h *handlers) deleteInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invite id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.DeleteInvite(r.Context(), id); err != nil {
+		h.logger.Error("Failed to delete invite code", "id", id, "error", err)
+		http.Error(w, "Failed to delete invite code", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invites", http.StatusSeeOther)
+}
+
+func (h *handlers) setRegistrationMode(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	err := h.authService.SetRegistrationMode(r.Context(), r.FormValue("mode"))
+	if errors.Is(err, auth.ErrInvalidMode) {
+		http.Error(w, "Invalid registration mode", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to set registration mode", "error", err)
+		http.Error(w, "Failed to save registration mode", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/invites", http.StatusSeeOther)
+}
@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"log/slog"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/auth"
+	"watchma/pkg/usage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupRoutes registers the admin pages, callers must guard them with auth.RequireAdmin
+func SetupRoutes(
+	r chi.Router,
+	queries *sqlcgen.Queries,
+	authService *auth.AuthService,
+	usageTracker *usage.Tracker,
+	logger *slog.Logger,
+) error {
+	handlers := newHandlers(queries, authService, usageTracker, logger)
+
+	r.Get("/admin/users", handlers.users)
+	r.Post("/admin/users/{id}/role", handlers.setUserRole)
+	r.Post("/admin/users/{id}/delete", handlers.deleteUser)
+
+	r.Get("/admin/invites", handlers.invites)
+	r.Post("/admin/invites", handlers.createInvite)
+	r.Post("/admin/invites/{id}/delete", handlers.deleteInvite)
+	r.Post("/admin/registration", handlers.setRegistrationMode)
+
+	r.Get("/admin/personas", handlers.personas)
+	r.Get("/admin/personas/new", handlers.newPersona)
+	r.Post("/admin/personas", handlers.createPersona)
+	r.Get("/admin/personas/{id}", handlers.editPersona)
+	r.Post("/admin/personas/{id}", handlers.updatePersona)
+	r.Post("/admin/personas/{id}/activate", handlers.activatePersona)
+	r.Post("/admin/personas/{id}/delete", handlers.deletePersona)
+
+	r.Get("/admin/usage", handlers.usage)
+	r.Post("/admin/usage/budget", handlers.setBudget)
+
+	return nil
+}
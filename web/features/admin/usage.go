@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"watchma/web"
+	"watchma/web/features/admin/pages"
+)
+
+const (
+	// How many months and calls the usage report shows
+	usageReportMonths = 12
+	usageReportCalls  = 50
+)
+
+func (h *handlers) usage(w http.ResponseWriter, r *http.Request) {
+	if h.usageTracker == nil {
+		web.RenderPage(pages.Usage(pages.UsageReport{}), "LLM Usage", w, r)
+		return
+	}
+
+	ctx := r.Context()
+	report := pages.UsageReport{Enabled: true, Prices: h.usageTracker.Prices()}
+
+	var err error
+	if report.Budget, err = h.usageTracker.MonthlyBudget(ctx); err != nil {
+		h.usageReportError(w, err)
+		return
+	}
+	if report.CostThisMonth, err = h.usageTracker.CostThisMonth(ctx); err != nil {
+		h.usageReportError(w, err)
+		return
+	}
+	if report.ByPurpose, err = h.queries.GetLLMUsageByPurposeThisMonth(ctx); err != nil {
+		h.usageReportError(w, err)
+		return
+	}
+	if report.ByMonth, err = h.queries.GetLLMUsageByMonth(ctx, usageReportMonths); err != nil {
+		h.usageReportError(w, err)
+		return
+	}
+	if report.Recent, err = h.queries.ListRecentLLMUsage(ctx, usageReportCalls); err != nil {
+		h.usageReportError(w, err)
+		return
+	}
+
+	web.RenderPage(pages.Usage(report), "LLM Usage", w, r)
+}
+
+func (h *handlers) usageReportError(w http.ResponseWriter, err error) {
+	h.logger.Error("Failed to build LLM usage report", "error", err)
+	http.Error(w, "Failed to load usage", http.StatusInternalServerError)
+}
+
+func (h *handlers) setBudget(w http.ResponseWriter, r *http.Request) {
+	if h.usageTracker == nil {
+		http.Error(w, "AI features are disabled", http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	budget, err := strconv.ParseFloat(strings.TrimSpace(r.FormValue("budget")), 64)
+	if err != nil || budget < 0 {
+		http.Error(w, "Budget must be a number of dollars, 0 for no limit", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.usageTracker.SetMonthlyBudget(r.Context(), budget); err != nil {
+		h.logger.Error("Failed to set LLM budget", "error", err)
+		http.Error(w, "Failed to save budget", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/usage", http.StatusSeeOther)
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"watchma/pkg/auth"
+	appctx "watchma/pkg/context"
+	"watchma/web"
+	"watchma/web/features/admin/pages"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *handlers) users(w http.ResponseWriter, r *http.Request) {
+	users, err := h.authService.ListUsers(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to list users", "error", err)
+		http.Error(w, "Failed to load users", http.StatusInternalServerError)
+		return
+	}
+
+	me := appctx.GetUserFromRequest(r)
+	web.RenderPage(pages.Users(users, me.ID), "Users", w, r)
+}
+
+func (h *handlers) setUserRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	err := h.authService.SetRole(r.Context(), id, r.FormValue("role"))
+	if !h.userActionOK(w, err, "Failed to change role", id) {
+		return
+	}
+
+	http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+}
+
+func (h *handlers) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.authService.DeleteAccount(r.Context(), id)
+	if !h.userActionOK(w, err, "Failed to delete user", id) {
+		return
+	}
+
+	http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+}
+
+// userActionOK writes the error response for a failed user change
+func (h *handlers) userActionOK(w http.ResponseWriter, err error, message string, id int64) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, auth.ErrLastAdmin):
+		http.Error(w, "The last admin can't be demoted or deleted, promote someone else first", http.StatusConflict)
+	case errors.Is(err, auth.ErrInvalidRole):
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "User not found", http.StatusNotFound)
+	default:
+		h.logger.Error(message, "id", id, "error", err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+	return false
+}
+
+func userIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"watchma/pkg/auth"
+	appctx "watchma/pkg/context"
+	"watchma/pkg/jellyfin"
+	"watchma/web"
+	"watchma/web/features/auth/pages"
+	"watchma/web/views/common"
+
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+func (h *handlers) Account(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+	opts := pages.AccountOptions{
+		Username:    user.Username,
+		HasPassword: user.PasswordHash != "",
+		Jellyfin:    h.authService.JellyfinEnabled(),
+	}
+	if h.authService.OIDCEnabled() {
+		opts.OIDC = h.authService.OIDCName
+	}
+
+	// Where linking single sign-on ended up, see finishOIDCLink
+	switch r.URL.Query().Get("oidc") {
+	case "linked":
+		opts.Notice = h.authService.OIDCName + " is linked, you can sign in with it now"
+	case "taken":
+		opts.Error = "That " + h.authService.OIDCName + " account is already linked to another user"
+	case "unreachable":
+		opts.Error = "Could not reach " + h.authService.OIDCName + ", try again later"
+	case "failed":
+		opts.Error = "Could not link " + h.authService.OIDCName + ", try again"
+	}
+
+	web.RenderPage(pages.Account(opts), "Account", w, r)
+}
+
+func (h *handlers) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	current := r.FormValue("currentPassword")
+	next := r.FormValue("newPassword")
+
+	if next != r.FormValue("confirmPassword") {
+		web.SendSSEError(w, r, "New passwords don't match", h.logger)
+		return
+	}
+	if _, ok := valid(next); !ok {
+		web.SendSSEError(w, r, "New password is invalid!", h.logger)
+		return
+	}
+
+	// Someone with a stolen session shouldn't get unlimited guesses at the password
+	_, userKey := lockoutKeys(r, user.Username)
+	if wait := h.lockout.Check(userKey); wait > 0 {
+		web.SendSSEError(w, r, lockedOutMessage(wait), h.logger)
+		return
+	}
+
+	err := h.authService.ChangePassword(r.Context(), user, current, next)
+	switch {
+	case errors.Is(err, auth.ErrWrongPassword):
+		h.lockout.Fail(userKey)
+		web.SendSSEError(w, r, "Current password is incorrect", h.logger)
+		return
+	case errors.Is(err, auth.ErrNoLocalPassword):
+		web.SendSSEError(w, r, "This account signs in with Jellyfin or single sign-on", h.logger)
+		return
+	case err != nil:
+		h.logger.Error("Failed to change password", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to change password", h.logger)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	sse.PatchElementTempl(common.Error(""))
+	sse.PatchElementTempl(pages.AccountNotice("Password changed"))
+}
+
+// HandleLinkJellyfin links the Jellyfin account the user signs in to here to their
+// account, like signing in with Jellyfin would for a new user
+func (h *handlers) HandleLinkJellyfin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	username := r.FormValue("jellyfinUsername")
+	if username == "" {
+		web.SendSSEError(w, r, "Username required", h.logger)
+		return
+	}
+
+	// Counted with Jellyfin sign ins, it's the same password to guess
+	ipKey, userKey := lockoutKeys(r, "jellyfin:"+username)
+	if wait := h.lockout.Check(ipKey, userKey); wait > 0 {
+		web.SendSSEError(w, r, lockedOutMessage(wait), h.logger)
+		return
+	}
+
+	err := h.authService.LinkJellyfin(r.Context(), user, username, r.FormValue("jellyfinPassword"))
+	switch {
+	case errors.Is(err, jellyfin.ErrInvalidCredentials):
+		h.lockout.Fail(ipKey, userKey)
+		web.SendSSEError(w, r, "Invalid Jellyfin username or password", h.logger)
+		return
+	case errors.Is(err, auth.ErrJellyfinLinkedElsewhere):
+		web.SendSSEError(w, r, err.Error(), h.logger)
+		return
+	case err != nil:
+		h.logger.Error("Failed to link Jellyfin", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Could not reach Jellyfin, try again later", h.logger)
+		return
+	}
+	h.lockout.Reset(userKey)
+
+	sse := datastar.NewSSE(w, r)
+	sse.PatchElementTempl(common.Error(""))
+	sse.PatchElementTempl(pages.AccountNotice("Jellyfin is linked, you can sign in with it now"))
+}
+
+func (h *handlers) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	if r.FormValue("confirmUsername") != user.Username {
+		web.SendSSEError(w, r, "Type your username to confirm", h.logger)
+		return
+	}
+
+	err := h.authService.DeleteAccount(r.Context(), user.ID)
+	if errors.Is(err, auth.ErrLastAdmin) {
+		web.SendSSEError(w, r, "You are the last admin, promote someone else first", h.logger)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete account", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to delete account", h.logger)
+		return
+	}
+
+	h.clearSessionCookie(w)
+	datastar.NewSSE(w, r).Redirect("/login")
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"watchma/pkg/auth"
+	appctx "watchma/pkg/context"
+	"watchma/pkg/room"
+	"watchma/web"
+	"watchma/web/features/auth/pages"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// guestHandlers let people with a room's link join it without an account
+type guestHandlers struct {
+	*handlers
+	roomService *room.Service
+}
+
+// GuestJoin asks for a name, or sends people who are already signed in straight on to
+// the lobby
+func (h *guestHandlers) GuestJoin(w http.ResponseWriter, r *http.Request) {
+	roomName := chi.URLParam(r, "roomName")
+	code := r.URL.Query().Get("code")
+	if token := getSessionToken(r); token != "" {
+		if _, err := h.authService.GetUserBySessionToken(token); err == nil {
+			http.Redirect(w, r, lobbyURL(roomName, code), http.StatusSeeOther)
+			return
+		}
+	}
+
+	// Signing in instead of joining as a guest comes back to the room too
+	setNextCookie(w, lobbyURL(roomName, code), !h.authService.IsDev)
+
+	message := ""
+	if err := h.checkRoomJoinable(roomName, code); err != nil {
+		message = err.Error()
+	}
+	web.RenderPageNoLayout(pages.GuestJoin(roomName, code, message), "Join "+roomName, w, r)
+}
+
+func (h *guestHandlers) HandleGuestJoin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	roomName := chi.URLParam(r, "roomName")
+	code := r.FormValue("code")
+	if err := h.checkRoomJoinable(roomName, code); err != nil {
+		web.SendSSEError(w, r, err.Error(), h.logger)
+		return
+	}
+
+	user, token, err := h.authService.JoinAsGuest(r.Context(), roomName, r.FormValue("name"), r.UserAgent())
+	switch {
+	case errors.Is(err, auth.ErrInvalidGuestName), errors.Is(err, auth.ErrGuestNameTaken):
+		web.SendSSEError(w, r, err.Error(), h.logger)
+		return
+	case err != nil:
+		h.logger.Error("Failed to create guest", "error", err, "room", roomName)
+		web.SendSSEError(w, r, "Something went wrong, try again", h.logger)
+		return
+	}
+
+	h.setSessionCookie(w, token)
+
+	sse := datastar.NewSSE(w, r)
+	h.logger.Debug("Guest join successful", "user_id", user.ID, "username", user.Username)
+	sse.Redirect(lobbyURL(roomName, code))
+}
+
+// HandleKeepAccount turns a guest into a full account from the results page. Their
+// player in the room follows the new username, so leaving the room still works.
+func (h *guestHandlers) HandleKeepAccount(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	if !auth.IsGuest(user) {
+		web.SendSSEError(w, r, "You already have an account", h.logger)
+		return
+	}
+	roomName := chi.URLParam(r, "roomName")
+	if myRoom, ok := h.roomService.GetRoom(roomName); !ok || myRoom.Game.Step != room.Results {
+		web.SendSSEError(w, r, "You can create an account once the game is over", h.logger)
+		return
+	}
+
+	password := r.FormValue("password")
+	if password != r.FormValue("confirmPassword") {
+		web.SendSSEError(w, r, "Passwords don't match", h.logger)
+		return
+	}
+	if _, ok := valid(password); !ok {
+		web.SendSSEError(w, r, "Password is invalid!", h.logger)
+		return
+	}
+
+	// Guessing invite codes counts against the IP, like on the login page
+	ipKey, _ := lockoutKeys(r, "")
+	if wait := h.lockout.Check(ipKey); wait > 0 {
+		web.SendSSEError(w, r, lockedOutMessage(wait), h.logger)
+		return
+	}
+
+	guestName := user.Username
+	err := h.authService.ConvertGuest(r.Context(), user, r.FormValue("username"), password, r.FormValue("invite"))
+	switch {
+	case errors.Is(err, auth.ErrInvalidInvite), errors.Is(err, auth.ErrInviteRequired):
+		h.lockout.Fail(ipKey)
+		web.SendSSEError(w, r, err.Error(), h.logger)
+		return
+	case errors.Is(err, auth.ErrInvalidUsername), errors.Is(err, auth.ErrUsernameTaken), errors.Is(err, auth.ErrRegistrationClosed):
+		web.SendSSEError(w, r, err.Error(), h.logger)
+		return
+	case err != nil:
+		h.logger.Error("Failed to convert guest", "error", err, "username", guestName)
+		web.SendSSEError(w, r, "Something went wrong, try again", h.logger)
+		return
+	}
+
+	h.roomService.RenamePlayer(roomName, guestName, user.Username)
+	// Reload so the page and its event stream pick up the new username
+	datastar.NewSSE(w, r).Redirect(fmt.Sprintf("/room/%s/results", roomName))
+}
+
+// checkRoomJoinable refuses rooms a guest couldn't get into anyway, so no guest is
+// created for nothing. Password protected rooms ask for the password in the lobby.
+func (h *guestHandlers) checkRoomJoinable(roomName, code string) error {
+	myRoom, ok := h.roomService.GetRoom(roomName)
+	switch {
+	case !ok, myRoom.Game.Visibility == room.Unlisted && !myRoom.CheckJoinCode(code):
+		return errors.New("This room doesn't exist anymore")
+	case myRoom.Game.Step != room.Lobby:
+		return errors.New("This game has already started")
+	case myRoom.Game.MaxPlayers <= len(myRoom.GetAllPlayers()):
+		return errors.New("This room is full")
+	}
+	return nil
+}
+
+// lobbyURL is the room's lobby, keeping the join code of a share link
+func lobbyURL(roomName, code string) string {
+	if code == "" {
+		return fmt.Sprintf("/room/%s/lobby", roomName)
+	}
+	return fmt.Sprintf("/room/%s/lobby?code=%s", roomName, url.QueryEscape(code))
+}
+
+// guestAllowed reports whether a guest of roomName may use path: the room's pages, its
+// event stream and chat. Chat messages name their room in the body, the chat handler
+// checks that.
+func guestAllowed(roomName, path string) bool {
+	if path == "/message" || path == "/sse/"+roomName {
+		return true
+	}
+	for _, section := range []string{"/room/", "/draft/", "/voting/"} {
+		if strings.HasPrefix(path, section+roomName+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// loginRedirect is where a signed out visitor to u is sent. With guests on, a room's
+// lobby link leads to its guest join page, so friends don't need an account to play.
+// The query comes along, it carries the join code of share links.
+func loginRedirect(authService *auth.AuthService, u *url.URL) string {
+	if !authService.GuestsEnabled() {
+		return "/login"
+	}
+	roomName, ok := strings.CutPrefix(u.Path, "/room/")
+	if !ok {
+		return "/login"
+	}
+	roomName, ok = strings.CutSuffix(roomName, "/lobby")
+	if !ok || roomName == "" || strings.Contains(roomName, "/") {
+		return "/login"
+	}
+	if u.RawQuery != "" {
+		return fmt.Sprintf("/room/%s/guest?%s", roomName, u.RawQuery)
+	}
+	return fmt.Sprintf("/room/%s/guest", roomName)
+}
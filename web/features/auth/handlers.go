@@ -2,10 +2,16 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 	"unicode"
 	"watchma/pkg/auth"
+	"watchma/pkg/jellyfin"
+	"watchma/pkg/ratelimit"
 	"watchma/web"
 	"watchma/web/features/auth/pages"
 	"watchma/web/views/common"
@@ -13,15 +19,24 @@ import (
 	"github.com/starfederation/datastar-go/datastar"
 )
 
+const (
+	quickConnectCookieName = "watchma_quickconnect"
+	// Jellyfin forgets pending Quick Connect requests after a few minutes anyway
+	quickConnectTTL = 5 * time.Minute
+)
+
 type handlers struct {
 	authService *auth.AuthService
-	logger      *slog.Logger
+	// Locks IPs and usernames out after too many wrong passwords
+	lockout *ratelimit.Lockout
+	logger  *slog.Logger
 }
 
-func newHandlers(auth *auth.AuthService, l *slog.Logger) *handlers {
+func newHandlers(auth *auth.AuthService, lockout *ratelimit.Lockout, l *slog.Logger) *handlers {
 	return &handlers{
 		logger:      l,
 		authService: auth,
+		lockout:     lockout,
 	}
 }
 
@@ -63,19 +78,40 @@ func (h *handlers) ValidatePassword(w http.ResponseWriter, r *http.Request) {
 	rules, _ := valid(signals.Password)
 
 	sse := datastar.NewSSE(w, r)
-	sse.PatchElementTempl(pages.LoginForm(rules))
+	sse.PatchElementTempl(pages.LoginForm(rules, h.loginOptions(r)))
 }
 
 func (h *handlers) Login(w http.ResponseWriter, r *http.Request) {
-	component := pages.LoginForm(pages.PwRules{
+	component := pages.Login(pages.PwRules{
 		Has8:      false,
 		HasLower:  false,
 		HasUpper:  false,
 		HasNumber: false,
-	})
+	}, h.loginOptions(r))
 	web.RenderPageNoLayout(component, "Watchma", w, r)
 }
 
+// loginOptions works out what the login page offers. Invite links look like
+// /login?invite=CODE, the code is filled in for the user.
+func (h *handlers) loginOptions(r *http.Request) pages.LoginOptions {
+	mode, err := h.authService.RegistrationMode(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get registration mode", "error", err)
+		mode = auth.RegistrationClosed
+	}
+
+	opts := pages.LoginOptions{
+		LocalAccounts: !h.authService.JellyfinOnly,
+		Jellyfin:      h.authService.JellyfinEnabled(),
+		Registration:  mode,
+		Invite:        r.URL.Query().Get("invite"),
+	}
+	if h.authService.OIDCEnabled() {
+		opts.OIDC = h.authService.OIDCName
+	}
+	return opts
+}
+
 func (h *handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -83,6 +119,11 @@ func (h *handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.authService.JellyfinOnly {
+		web.SendSSEError(w, r, "Sign in with your Jellyfin account", h.logger)
+		return
+	}
+
 	username := r.FormValue("username")
 	password := r.FormValue("password")
 
@@ -100,15 +141,170 @@ func (h *handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := h.authService.LoginOrCreate(username, password)
+	ipKey, userKey := lockoutKeys(r, username)
+	if wait := h.lockout.Check(ipKey, userKey); wait > 0 {
+		h.logger.Warn("Login locked out", "username", username, "ip", web.ClientIP(r), "wait", wait)
+		web.SendSSEError(w, r, lockedOutMessage(wait), h.logger)
+		return
+	}
+
+	user, token, err := h.authService.LoginOrCreate(username, password, r.FormValue("invite"), r.UserAgent())
+	if err != nil {
+		h.logger.Warn("Login failed", "error", err, "username", username, "ip", web.ClientIP(r))
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			h.lockout.Fail(ipKey, userKey)
+			web.SendSSEError(w, r, err.Error(), h.logger)
+		case errors.Is(err, auth.ErrInvalidInvite), errors.Is(err, auth.ErrInviteRequired):
+			// Guessing invite codes counts against the IP
+			h.lockout.Fail(ipKey)
+			web.SendSSEError(w, r, err.Error(), h.logger)
+		case errors.Is(err, auth.ErrInvalidUsername):
+			web.SendSSEError(w, r, err.Error(), h.logger)
+		default:
+			web.SendSSEError(w, r, "Something went wrong, try again", h.logger)
+		}
+		return
+	}
+	h.lockout.Reset(userKey)
+
+	h.setSessionCookie(w, token)
+	next := h.afterLogin(w, r)
+
+	sse := datastar.NewSSE(w, r)
+	h.logger.Debug("Login successful", "user_id", user.ID, "username", user.Username)
+	sse.Redirect(next)
+}
+
+func (h *handlers) HandleJellyfinLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	username := r.FormValue("jellyfinUsername")
+	password := r.FormValue("jellyfinPassword")
+	if username == "" {
+		web.SendSSEError(w, r, "Username required", h.logger)
+		return
+	}
+
+	ipKey, userKey := lockoutKeys(r, "jellyfin:"+username)
+	if wait := h.lockout.Check(ipKey, userKey); wait > 0 {
+		h.logger.Warn("Jellyfin login locked out", "username", username, "ip", web.ClientIP(r), "wait", wait)
+		web.SendSSEError(w, r, lockedOutMessage(wait), h.logger)
+		return
+	}
+
+	user, token, err := h.authService.LoginWithJellyfin(r.Context(), username, password, r.UserAgent())
+	if errors.Is(err, jellyfin.ErrInvalidCredentials) {
+		h.logger.Warn("Jellyfin login failed", "error", err, "username", username, "ip", web.ClientIP(r))
+		h.lockout.Fail(ipKey, userKey)
+		web.SendSSEError(w, r, "Invalid Jellyfin username or password", h.logger)
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidUsername) || errors.Is(err, auth.ErrJellyfinLinkRequired) {
+		web.SendSSEError(w, r, err.Error(), h.logger)
+		return
+	}
 	if err != nil {
+		h.logger.Error("Jellyfin login failed", "error", err, "username", username)
+		web.SendSSEError(w, r, "Could not reach Jellyfin, try again later", h.logger)
+		return
+	}
+
+	h.lockout.Reset(userKey)
+	h.setSessionCookie(w, token)
+	next := h.afterLogin(w, r)
+
+	sse := datastar.NewSSE(w, r)
+	h.logger.Debug("Jellyfin login successful", "user_id", user.ID, "username", user.Username)
+	sse.Redirect(next)
+}
+
+// StartQuickConnect shows a Quick Connect code. The secret that redeems it is kept in a
+// short lived cookie so it never has to be shown on the page.
+func (h *handlers) StartQuickConnect(w http.ResponseWriter, r *http.Request) {
+	qc, err := h.authService.StartQuickConnect(r.Context())
+	if errors.Is(err, jellyfin.ErrQuickConnectDisabled) {
+		web.SendSSEError(w, r, "Quick Connect is not enabled on this Jellyfin server", h.logger)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to start Quick Connect", "error", err)
+		web.SendSSEError(w, r, "Could not reach Jellyfin, try again later", h.logger)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     quickConnectCookieName,
+		Value:    qc.Secret,
+		Path:     "/login/quickconnect",
+		HttpOnly: true,
+		Secure:   !h.authService.IsDev,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(quickConnectTTL.Seconds()),
+	})
+
+	sse := datastar.NewSSE(w, r)
+	sse.PatchElementTempl(pages.QuickConnectCode(qc.Code))
+	sse.PatchElementTempl(common.Error(""))
+}
+
+// PollQuickConnect is called every couple of seconds while a code is shown, and signs
+// the user in once it has been approved in Jellyfin
+func (h *handlers) PollQuickConnect(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(quickConnectCookieName)
+	if err != nil || cookie.Value == "" {
+		sse := datastar.NewSSE(w, r)
+		sse.PatchElementTempl(pages.QuickConnectCode(""))
+		sse.PatchElementTempl(common.Error("Quick Connect code expired, try again"))
+		return
+	}
+
+	user, token, err := h.authService.FinishQuickConnect(r.Context(), cookie.Value, r.UserAgent())
+	if errors.Is(err, jellyfin.ErrQuickConnectPending) {
+		// Nothing to patch, the code stays up and is polled again
+		datastar.NewSSE(w, r)
+		return
+	}
+
+	h.clearQuickConnectCookie(w)
+	if err != nil {
+		h.logger.Warn("Quick Connect failed", "error", err)
+		message := "Could not reach Jellyfin, try again later"
+		switch {
+		case errors.Is(err, jellyfin.ErrQuickConnectNotFound):
+			message = "Quick Connect code expired, try again"
+		case errors.Is(err, auth.ErrInvalidUsername), errors.Is(err, auth.ErrJellyfinLinkRequired):
+			message = err.Error()
+		}
 		sse := datastar.NewSSE(w, r)
-		h.logger.Warn("Login failed", "error", err, "username", username)
-		sse.PatchElementTempl(common.Error(err.Error()))
+		sse.PatchElementTempl(pages.QuickConnectCode(""))
+		sse.PatchElementTempl(common.Error(message))
 		return
 	}
 
-	// Set session token as HTTP-only cookie
+	h.setSessionCookie(w, token)
+	next := h.afterLogin(w, r)
+
+	sse := datastar.NewSSE(w, r)
+	h.logger.Debug("Quick Connect login successful", "user_id", user.ID, "username", user.Username)
+	sse.Redirect(next)
+}
+
+// lockoutKeys are the lockout keys for a sign in attempt, one for the client's IP and
+// one for the username being tried
+func lockoutKeys(r *http.Request, username string) (string, string) {
+	return "ip:" + web.ClientIP(r), "user:" + strings.ToLower(username)
+}
+
+func lockedOutMessage(wait time.Duration) string {
+	return fmt.Sprintf("Too many failed attempts, try again in %s", wait.Round(time.Second))
+}
+
+// setSessionCookie stores the session token as an HTTP-only cookie
+func (h *handlers) setSessionCookie(w http.ResponseWriter, token string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     auth.SessionCookieName,
 		Value:    token,
@@ -117,13 +313,21 @@ func (h *handlers) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		Secure:   !h.authService.IsDev,
 		SameSite: http.SameSiteLaxMode,
 	})
+}
 
-	sse := datastar.NewSSE(w, r)
-	h.logger.Debug("Login successful", "user_id", user.ID, "username", user.Username)
-	sse.Redirect("/")
+func (h *handlers) clearQuickConnectCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     quickConnectCookieName,
+		Value:    "",
+		Path:     "/login/quickconnect",
+		HttpOnly: true,
+		Secure:   !h.authService.IsDev,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1,
+	})
 }
 
-func (h *handlers) HandleLogout(w http.ResponseWriter, r *http.Request) {
+func (h *handlers) clearSessionCookie(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     auth.SessionCookieName,
 		Value:    "",
@@ -133,6 +337,15 @@ func (h *handlers) HandleLogout(w http.ResponseWriter, r *http.Request) {
 		SameSite: http.SameSiteLaxMode,
 		MaxAge:   -1, // This deletes the cookie
 	})
+}
+
+func (h *handlers) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if token := getSessionToken(r); token != "" {
+		if err := h.authService.Logout(r.Context(), token); err != nil {
+			h.logger.Error("Failed to revoke session on logout", "error", err)
+		}
+	}
+	h.clearSessionCookie(w)
 
 	// Redirect to login page
 	sse := datastar.NewSSE(w, r)
@@ -4,13 +4,20 @@ import (
 	"database/sql"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"strings"
 
+	"watchma/db/sqlcgen"
 	"watchma/pkg/auth"
 	appctx "watchma/pkg/context"
+	"watchma/web"
+	"watchma/web/features/auth/pages"
 )
 
-// RequireLogin middleware checks for session cookie, loads user data, and stores in context
+// RequireLogin middleware checks for session cookie, loads user data, and stores in context.
+// With proxy auth on, a trusted proxy's header is checked first and the session cookie is
+// the fallback. Signed out visitors to a room's lobby are offered to join as a guest, and
+// everyone comes back to the page they asked for once signed in.
 func RequireLogin(authService *auth.AuthService, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -20,17 +27,25 @@ func RequireLogin(authService *auth.AuthService, logger *slog.Logger) func(http.
 				return
 			}
 
+			if user := proxyUser(authService, r, logger); user != nil {
+				r = appctx.SetUserInRequest(r, user)
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			token := getSessionToken(r)
 			if token == "" {
 				logger.Debug("User redirected to login, no session cookie")
-				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				rememberNext(w, r, !authService.IsDev)
+				http.Redirect(w, r, loginRedirect(authService, r.URL), http.StatusSeeOther)
 				return
 			}
 
 			user, err := authService.GetUserBySessionToken(token)
 			if err == sql.ErrNoRows {
 				logger.Info("Session token exists but does not belong to a user. Session token invalid", "error", err.Error())
-				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				rememberNext(w, r, !authService.IsDev)
+				http.Redirect(w, r, loginRedirect(authService, r.URL), http.StatusSeeOther)
 				return
 			}
 			if err != nil {
@@ -47,6 +62,73 @@ func RequireLogin(authService *auth.AuthService, logger *slog.Logger) func(http.
 	}
 }
 
+// RequireAdmin middleware only lets admins through, it must run after RequireLogin
+func RequireAdmin(authService *auth.AuthService, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := appctx.GetUserFromRequest(r)
+			if !authService.IsAdmin(user) {
+				logger.Warn("Non admin tried to reach an admin page", "path", r.URL.Path)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RestrictGuests keeps guests in the room they joined, it must run after RequireLogin
+func RestrictGuests(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := appctx.GetUserFromRequest(r)
+			if !auth.IsGuest(user) || guestAllowed(user.GuestRoom, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Debug("Guest kept out of a page outside their room", "username", user.Username, "path", r.URL.Path)
+			switch {
+			case r.Method == http.MethodGet:
+				w.WriteHeader(http.StatusForbidden)
+				web.RenderPage(pages.GuestOnly(user.GuestRoom), "Watchma", w, r)
+			case r.Header.Get("Datastar-Request") == "true":
+				web.SendSSEError(w, r, "Guests can only play in the room they joined", logger)
+			default:
+				http.Error(w, "Forbidden", http.StatusForbidden)
+			}
+		})
+	}
+}
+
+// proxyUser is the user named by the proxy header, or nil when proxy auth is off, the
+// header is missing or the request didn't come from a trusted proxy. Anyone can send the
+// header, so it only counts from the trusted ranges.
+func proxyUser(authService *auth.AuthService, r *http.Request, logger *slog.Logger) *sqlcgen.User {
+	if !authService.ProxyAuthEnabled() {
+		return nil
+	}
+	username := r.Header.Get(authService.ProxyHeader)
+	if username == "" {
+		return nil
+	}
+
+	// The proxy's own address, X-Forwarded-For says who the proxy is acting for
+	addr, err := netip.ParseAddr(web.RemoteIP(r))
+	if err != nil || !authService.IsTrustedProxy(addr) {
+		logger.Warn("Ignored proxy auth header from an untrusted address", "header", authService.ProxyHeader, "ip", web.RemoteIP(r))
+		return nil
+	}
+
+	user, err := authService.GetProxyUser(r.Context(), username)
+	if err != nil {
+		logger.Error("Failed to get user from proxy header", "error", err, "username", username)
+		return nil
+	}
+	return user
+}
+
 // getSessionToken retrieves the session token from the request cookie
 func getSessionToken(r *http.Request) string {
 	cookie, err := r.Cookie(auth.SessionCookieName)
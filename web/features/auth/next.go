@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	nextCookieName = "watchma_next"
+	// Long enough to create an account or sign in at a single sign-on issuer
+	nextTTL = 10 * time.Minute
+)
+
+// rememberNext keeps the page a signed out visitor asked for, so signing in takes them
+// back there. A cookie carries it through every sign in method, including the round trip
+// to a single sign-on issuer. Only page loads count, not background requests.
+func rememberNext(w http.ResponseWriter, r *http.Request, secure bool) {
+	if r.Method != http.MethodGet || r.Header.Get("Datastar-Request") == "true" {
+		return
+	}
+	setNextCookie(w, r.URL.RequestURI(), secure)
+}
+
+func setNextCookie(w http.ResponseWriter, next string, secure bool) {
+	if !localPath(next) {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     nextCookieName,
+		Value:    url.QueryEscape(next),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   secure,
+		// The single sign-on callback is a top level GET from the issuer, Strict would
+		// drop the cookie
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(nextTTL.Seconds()),
+	})
+}
+
+// afterLogin is where to send a user who just signed in, the remembered page or home
+func (h *handlers) afterLogin(w http.ResponseWriter, r *http.Request) string {
+	cookie, err := r.Cookie(nextCookieName)
+	if err != nil {
+		return "/"
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     nextCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   !h.authService.IsDev,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+
+	next, err := url.QueryUnescape(cookie.Value)
+	if err != nil || !localPath(next) {
+		return "/"
+	}
+	return next
+}
+
+// localPath reports whether next is a path on this site, so a crafted cookie can't
+// send people somewhere else after signing in
+func localPath(next string) bool {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, `\`) {
+		return false
+	}
+	u, err := url.Parse(next)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
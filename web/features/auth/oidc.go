@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"watchma/pkg/auth"
+	appctx "watchma/pkg/context"
+	"watchma/pkg/oidc"
+	"watchma/web"
+	"watchma/web/features/auth/pages"
+)
+
+const (
+	oidcCookieName = "watchma_oidc"
+	// Long enough to type a password and do a second factor at the issuer
+	oidcTTL = 10 * time.Minute
+)
+
+// Why the browser was sent to the issuer, both come back to the same callback
+const (
+	oidcSignIn = "login"
+	oidcLink   = "link"
+)
+
+// StartOIDC sends the browser to the issuer to sign in
+func (h *handlers) StartOIDC(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.startOIDC(w, r, oidcSignIn)
+	if err != nil {
+		h.logger.Error("Failed to start OIDC sign in", "error", err)
+		h.renderLoginError(w, r, "Could not reach "+h.authService.OIDCName+", try again later")
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// StartOIDCLink sends a signed in user to the issuer, to link the account they sign in
+// with there to this one
+func (h *handlers) StartOIDCLink(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.startOIDC(w, r, oidcLink)
+	if err != nil {
+		h.logger.Error("Failed to start OIDC link", "error", err)
+		http.Redirect(w, r, "/account?oidc=unreachable", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusSeeOther)
+}
+
+// startOIDC returns the issuer's sign in page. State, nonce, the PKCE verifier and the
+// purpose wait in a short lived cookie for the callback.
+func (h *handlers) startOIDC(w http.ResponseWriter, r *http.Request, purpose string) (string, error) {
+	state, nonce, verifier := oidc.RandomString(), oidc.RandomString(), oidc.RandomString()
+
+	authURL, err := h.authService.OIDCAuthURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    strings.Join([]string{state, nonce, verifier, purpose}, "."),
+		Path:     "/login/oidc",
+		HttpOnly: true,
+		Secure:   !h.authService.IsDev,
+		// The issuer redirects back with a top level GET, Strict would drop the cookie
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oidcTTL.Seconds()),
+	})
+	return authURL, nil
+}
+
+// OIDCCallback is where the issuer sends the browser back after signing in
+func (h *handlers) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cookie, cookieErr := r.Cookie(oidcCookieName)
+	h.clearOIDCCookie(w)
+
+	if issuerErr := query.Get("error"); issuerErr != "" {
+		h.logger.Warn("OIDC sign in refused by issuer", "error", issuerErr, "description", query.Get("error_description"))
+		h.renderLoginError(w, r, "Sign in with "+h.authService.OIDCName+" was cancelled")
+		return
+	}
+
+	parts := []string{}
+	if cookieErr == nil {
+		parts = strings.Split(cookie.Value, ".")
+	}
+	if len(parts) != 4 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(query.Get("state"))) != 1 {
+		h.logger.Warn("OIDC callback with missing or wrong state", "ip", web.ClientIP(r))
+		h.renderLoginError(w, r, "Sign in took too long, try again")
+		return
+	}
+	nonce, verifier := parts[1], parts[2]
+
+	if parts[3] == oidcLink {
+		h.finishOIDCLink(w, r, query.Get("code"), verifier, nonce)
+		return
+	}
+
+	user, token, err := h.authService.LoginWithOIDC(r.Context(), query.Get("code"), verifier, nonce, r.UserAgent())
+	if errors.Is(err, auth.ErrOIDCLinkRequired) {
+		h.renderLoginError(w, r, "An account with your "+h.authService.OIDCName+" username already exists. Sign in to it another way, then link "+h.authService.OIDCName+" from your account page.")
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidUsername) {
+		h.renderLoginError(w, r, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error("OIDC sign in failed", "error", err)
+		h.renderLoginError(w, r, "Could not sign in with "+h.authService.OIDCName+", try again")
+		return
+	}
+
+	h.setSessionCookie(w, token)
+	h.logger.Debug("OIDC login successful", "user_id", user.ID, "username", user.Username)
+	http.Redirect(w, r, h.afterLogin(w, r), http.StatusSeeOther)
+}
+
+// finishOIDCLink links the issuer account to whoever started the link, they must still
+// be signed in with the same session
+func (h *handlers) finishOIDCLink(w http.ResponseWriter, r *http.Request, code, verifier, nonce string) {
+	user, err := h.authService.GetUserBySessionToken(getSessionToken(r))
+	if err != nil {
+		h.renderLoginError(w, r, "Sign in again to link "+h.authService.OIDCName)
+		return
+	}
+	r = appctx.SetUserInRequest(r, user)
+
+	err = h.authService.LinkOIDC(r.Context(), user, code, verifier, nonce)
+	switch {
+	case errors.Is(err, auth.ErrOIDCLinkedElsewhere):
+		http.Redirect(w, r, "/account?oidc=taken", http.StatusSeeOther)
+	case err != nil:
+		h.logger.Error("OIDC link failed", "username", user.Username, "error", err)
+		http.Redirect(w, r, "/account?oidc=failed", http.StatusSeeOther)
+	default:
+		http.Redirect(w, r, "/account?oidc=linked", http.StatusSeeOther)
+	}
+}
+
+func (h *handlers) renderLoginError(w http.ResponseWriter, r *http.Request, message string) {
+	opts := h.loginOptions(r)
+	opts.Error = message
+	web.RenderPageNoLayout(pages.Login(pages.PwRules{}, opts), "Watchma", w, r)
+}
+
+func (h *handlers) clearOIDCCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    "",
+		Path:     "/login/oidc",
+		HttpOnly: true,
+		Secure:   !h.authService.IsDev,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+	})
+}
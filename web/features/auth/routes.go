@@ -2,7 +2,10 @@ package auth
 
 import (
 	"log/slog"
+	"net/http"
 	"watchma/pkg/auth"
+	"watchma/pkg/ratelimit"
+	"watchma/pkg/room"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -10,16 +13,96 @@ import (
 func SetupRoutes(
 	r chi.Router,
 	authService *auth.AuthService,
+	loginLockout *ratelimit.Lockout,
 	logger *slog.Logger,
 ) error {
-	handlers := newHandlers(authService, logger)
+	handlers := newHandlers(authService, loginLockout, logger)
 
 	// Public web routes
 	r.Get("/login", handlers.Login)
+	if authService.OIDCEnabled() {
+		r.Get("/login/oidc", handlers.StartOIDC)
+		r.Get("/login/oidc/callback", handlers.OIDCCallback)
+	}
 
 	r.Post("/login", handlers.HandleLogin)
+	r.Post("/login/jellyfin", handlers.HandleJellyfinLogin)
+	r.Post("/login/quickconnect", handlers.StartQuickConnect)
+	r.Post("/login/quickconnect/poll", handlers.PollQuickConnect)
 	r.Post("/logout", handlers.HandleLogout)
 	r.Post("/validate", handlers.ValidatePassword)
 
 	return nil
 }
+
+// SetupGuestRoutes registers the public guest join page of each room. joinLimit caps how
+// many guests one address can create.
+func SetupGuestRoutes(
+	r chi.Router,
+	authService *auth.AuthService,
+	roomService *room.Service,
+	loginLockout *ratelimit.Lockout,
+	joinLimit func(http.Handler) http.Handler,
+	logger *slog.Logger,
+) error {
+	if !authService.GuestsEnabled() {
+		return nil
+	}
+	handlers := &guestHandlers{
+		handlers:    newHandlers(authService, loginLockout, logger),
+		roomService: roomService,
+	}
+
+	r.Get("/room/{roomName}/guest", handlers.GuestJoin)
+	r.With(joinLimit).Post("/room/{roomName}/guest", handlers.HandleGuestJoin)
+
+	return nil
+}
+
+// SetupGuestAccountRoutes registers turning a guest into a full account, callers must
+// guard it with RequireLogin
+func SetupGuestAccountRoutes(
+	r chi.Router,
+	authService *auth.AuthService,
+	roomService *room.Service,
+	loginLockout *ratelimit.Lockout,
+	logger *slog.Logger,
+) error {
+	if !authService.GuestsEnabled() {
+		return nil
+	}
+	handlers := &guestHandlers{
+		handlers:    newHandlers(authService, loginLockout, logger),
+		roomService: roomService,
+	}
+
+	r.Post("/room/{roomName}/account", handlers.HandleKeepAccount)
+
+	return nil
+}
+
+// SetupAccountRoutes registers the signed in user's account pages, callers must guard
+// them with RequireLogin
+func SetupAccountRoutes(
+	r chi.Router,
+	authService *auth.AuthService,
+	loginLockout *ratelimit.Lockout,
+	logger *slog.Logger,
+) error {
+	handlers := newHandlers(authService, loginLockout, logger)
+
+	r.Get("/account", handlers.Account)
+	r.Post("/account/password", handlers.HandleChangePassword)
+	r.Post("/account/delete", handlers.HandleDeleteAccount)
+	r.Get("/account/sessions", handlers.Sessions)
+	r.Post("/account/sessions/{id}/revoke", handlers.HandleRevokeSession)
+	r.Post("/account/sessions/revoke-all", handlers.HandleLogoutEverywhere)
+	if authService.JellyfinEnabled() {
+		r.Post("/account/jellyfin/link", handlers.HandleLinkJellyfin)
+	}
+	if authService.OIDCEnabled() {
+		r.Post("/account/oidc/link", handlers.StartOIDCLink)
+	}
+
+	return nil
+}
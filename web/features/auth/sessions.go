@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	appctx "watchma/pkg/context"
+	"watchma/web"
+	"watchma/web/features/auth/pages"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+func (h *handlers) Sessions(w http.ResponseWriter, r *http.Request) {
+	sessions, ok := h.listSessions(w, r)
+	if !ok {
+		return
+	}
+	web.RenderPage(pages.Sessions(sessions), "Signed In Devices", w, r)
+}
+
+func (h *handlers) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		web.SendSSEError(w, r, "Invalid session", h.logger)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	if err := h.authService.RevokeSession(r.Context(), user.ID, id); err != nil {
+		h.logger.Error("Failed to revoke session", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to log out that device", h.logger)
+		return
+	}
+
+	sessions, ok := h.listSessions(w, r)
+	if !ok {
+		return
+	}
+	datastar.NewSSE(w, r).PatchElementTempl(pages.SessionList(sessions))
+}
+
+func (h *handlers) HandleLogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+	if err := h.authService.LogoutEverywhere(r.Context(), user); err != nil {
+		h.logger.Error("Failed to log out everywhere", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to log out everywhere", h.logger)
+		return
+	}
+
+	h.clearSessionCookie(w)
+	datastar.NewSSE(w, r).Redirect("/login")
+}
+
+// listSessions loads the signed in user's sessions, marking the one making the request
+func (h *handlers) listSessions(w http.ResponseWriter, r *http.Request) ([]pages.Session, bool) {
+	user := appctx.GetUserFromRequest(r)
+	rows, err := h.authService.ListSessions(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "username", user.Username, "error", err)
+		http.Error(w, "Failed to load sessions", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	current := getSessionToken(r)
+	sessions := make([]pages.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, pages.Session{
+			ID:         row.ID,
+			UserAgent:  row.UserAgent,
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			Current:    row.Token == current,
+		})
+	}
+	return sessions, true
+}
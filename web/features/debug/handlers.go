@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"net/http"
 	appctx "watchma/pkg/context"
+	"watchma/pkg/jellyfin"
 	"watchma/pkg/room"
 	"watchma/web"
 	"watchma/web/features/debug/pages"
@@ -13,26 +14,37 @@ import (
 )
 
 type handlers struct {
-	roomService *room.Service
-	logger      *slog.Logger
-	nats        *nats.Conn
+	roomService    *room.Service
+	jellyfinClient *jellyfin.Client
+	logger         *slog.Logger
+	nats           *nats.Conn
 }
 
-func newHandlers(rs *room.Service, logger *slog.Logger, nc *nats.Conn) *handlers {
+func newHandlers(rs *room.Service, jc *jellyfin.Client, logger *slog.Logger, nc *nats.Conn) *handlers {
 	return &handlers{
-		roomService: rs,
-		logger:      logger,
-		nats:        nc,
+		roomService:    rs,
+		jellyfinClient: jc,
+		logger:         logger,
+		nats:           nc,
 	}
 }
 
+// jellyfinHealth returns nil when running on dummy data
+func (h *handlers) jellyfinHealth() *jellyfin.Health {
+	if h.jellyfinClient == nil {
+		return nil
+	}
+	health := h.jellyfinClient.Health()
+	return &health
+}
+
 // renderPage is a shared helper to render pages with the common layout
 func (h *handlers) debug(w http.ResponseWriter, r *http.Request) {
 	user := appctx.GetUserFromRequest(r)
 
 	debugSnapshot := h.roomService.GetDebugSnapshot()
 
-	web.RenderPageNoLayout(pages.Debug(debugSnapshot, user), "debug", w, r)
+	web.RenderPageNoLayout(pages.Debug(debugSnapshot, user, h.jellyfinHealth()), "debug", w, r)
 }
 
 func (h *handlers) sse(w http.ResponseWriter, r *http.Request) {
@@ -41,7 +53,7 @@ func (h *handlers) sse(w http.ResponseWriter, r *http.Request) {
 
 	// Send initial debug snapshot to new client
 	debugSnapshot := h.roomService.GetDebugSnapshot()
-	if err := sse.PatchElementTempl(pages.Debug(debugSnapshot, user)); err != nil {
+	if err := sse.PatchElementTempl(pages.Debug(debugSnapshot, user, h.jellyfinHealth())); err != nil {
 		h.logger.Error("Error patching initial debug snapshot", "error", err)
 		return
 	}
@@ -68,7 +80,7 @@ func (h *handlers) sse(w http.ResponseWriter, r *http.Request) {
 
 		// Refresh debug snapshot on any event
 		debugSnapshot := h.roomService.GetDebugSnapshot()
-		if err := sse.PatchElementTempl(pages.Debug(debugSnapshot, user)); err != nil {
+		if err := sse.PatchElementTempl(pages.Debug(debugSnapshot, user, h.jellyfinHealth())); err != nil {
 			h.logger.Error("Error patching debug snapshot", "error", err)
 			return
 		}
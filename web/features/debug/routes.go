@@ -2,6 +2,7 @@ package debug
 
 import (
 	"log/slog"
+	"watchma/pkg/jellyfin"
 	"watchma/pkg/room"
 
 	"github.com/go-chi/chi/v5"
@@ -11,10 +12,11 @@ import (
 func SetupRoutes(
 	r chi.Router,
 	roomService *room.Service,
+	jellyfinClient *jellyfin.Client,
 	logger *slog.Logger,
 	nc *nats.Conn,
 ) error {
-	handlers := newHandlers(roomService, logger, nc)
+	handlers := newHandlers(roomService, jellyfinClient, logger, nc)
 
 	r.Get("/debug", handlers.debug)
 	r.Get("/debug/sse", handlers.sse)
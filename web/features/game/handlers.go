@@ -1,22 +1,29 @@
 package game
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"watchma/db/sqlcgen"
+	"watchma/pkg/announcement"
+	"watchma/pkg/auth"
 	appctx "watchma/pkg/context"
+	"watchma/pkg/embedding"
+	"watchma/pkg/host"
+	"watchma/pkg/llm"
 	"watchma/pkg/movie"
-	"watchma/pkg/openai"
+	"watchma/pkg/ratelimit"
+	"watchma/pkg/recommend"
 	"watchma/pkg/room"
 	"watchma/web"
 	"watchma/web/features/game/pages"
+	"watchma/web/features/rooms"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/nats-io/nats.go"
@@ -24,26 +31,45 @@ import (
 )
 
 type handlers struct {
-	roomService    *room.Service
-	movieService   *movie.Service
-	openAiProvider *openai.Provider
-	logger         *slog.Logger
-	nats           *nats.Conn
+	roomService  *room.Service
+	authService  *auth.AuthService
+	movieService *movie.Service
+	hostService  *host.Service
+	recommender  *recommend.Service
+	vibeIndex    *embedding.Index // nil when vibe search is disabled
+	llmProvider  llm.Provider     // nil when AI features are disabled
+	// Locks users out of a password protected room after too many wrong passwords
+	lockout *ratelimit.Lockout
+	// announcementWriter writes the reveal scene, only usable with an llmProvider
+	announcementWriter *announcement.Writer
+	logger             *slog.Logger
+	nats               *nats.Conn
 }
 
 func newHandlers(
 	roomService *room.Service,
+	authService *auth.AuthService,
 	movieService *movie.Service,
-	openAiProvider *openai.Provider,
+	hostService *host.Service,
+	recommender *recommend.Service,
+	vibeIndex *embedding.Index,
+	llmProvider llm.Provider,
+	lockout *ratelimit.Lockout,
 	logger *slog.Logger,
 	nc *nats.Conn,
 ) *handlers {
 	return &handlers{
-		roomService:    roomService,
-		movieService:   movieService,
-		openAiProvider: openAiProvider,
-		logger:         logger,
-		nats:           nc,
+		roomService:        roomService,
+		authService:        authService,
+		movieService:       movieService,
+		hostService:        hostService,
+		recommender:        recommender,
+		vibeIndex:          vibeIndex,
+		llmProvider:        llmProvider,
+		announcementWriter: announcement.NewWriter(llmProvider, logger),
+		lockout:            lockout,
+		logger:             logger,
+		nats:               nc,
 	}
 }
 
@@ -61,26 +87,43 @@ func (h *handlers) singleRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if game has already started
-	if myRoom.Game.Step != room.Lobby {
-		// Game in progress - check if player is already in the room
-		_, playerInRoom := myRoom.GetPlayer(user.Username)
-		if !playerInRoom {
-			// Player not in room and game started - redirect to home
-			http.Redirect(w, r, "/", http.StatusSeeOther)
+	if !myRoom.CanEnter(user.Username) {
+		switch {
+		case myRoom.CheckJoinCode(r.URL.Query().Get("code")):
+			// Whoever has the share link was invited by someone inside
+			h.roomService.AdmitPlayer(myRoom.Name, user.Username)
+		case myRoom.Game.Visibility == room.PasswordProtected:
+			web.RenderPage(pages.RoomPassword(myRoom.Name), roomName, w, r)
+			return
+		default:
+			// Without the link an unlisted room looks like it doesn't exist
+			web.RenderPage(pages.NoRoom(roomName), roomName, w, r)
 			return
 		}
-		// Player is in room - let them reconnect (fall through)
 	}
 
-	if myRoom.Game.MaxPlayers <= len(myRoom.Players) {
-		web.RenderPage(pages.RoomFull(), roomName, w, r)
+	// Players in the room can always reconnect. Everyone else watches when the game has
+	// started or the room is full, and spectators keep watching until the host lets
+	// them play.
+	_, rejoining := myRoom.GetPlayer(user.Username)
+	if !rejoining && (myRoom.Game.Step != room.Lobby ||
+		myRoom.Game.MaxPlayers <= len(myRoom.GetAllPlayers()) ||
+		myRoom.IsSpectator(user.Username)) {
+		h.spectate(w, r, myRoom, user)
 		return
 	}
 
 	h.roomService.AddPlayerToRoom(myRoom.Name, user.Username)
+	if !rejoining {
+		h.hostService.Comment(host.Event{
+			Kind:     host.LobbyArrival,
+			Room:     myRoom.Name,
+			Username: user.Username,
+			Players:  playerNames(myRoom),
+		})
+	}
 
-	web.RenderPageNoLayout(pages.Lobby(myRoom, user.Username), myRoom.Name, w, r)
+	web.RenderPageNoLayout(pages.Lobby(myRoom, user.Username, rooms.ShareURL(r, myRoom.JoinCode)), myRoom.Name, w, r)
 }
 
 // Function that does the heavy lifting by keeping the SSE channel open and sending
@@ -97,7 +140,7 @@ func (h *handlers) singleRoomSSE(w http.ResponseWriter, r *http.Request) {
 
 	// Check if room exists
 	myRoom, ok := h.roomService.GetRoom(roomName)
-	if !ok {
+	if !ok || !myRoom.CanEnter(user.Username) {
 		h.logger.Warn("Room not found on SSE reconnect", "Room", roomName, "Username", user.Username)
 		if err := sse.Redirect("/"); err != nil {
 			h.logger.Warn("Error redirecting after room not found", "error", err)
@@ -105,6 +148,11 @@ func (h *handlers) singleRoomSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if myRoom.IsSpectator(user.Username) {
+		h.spectateSSE(w, r, sse, myRoom, user)
+		return
+	}
+
 	// Send existing user list to new client
 	userBox := pages.UserBox(myRoom, user.Username)
 	if err := sse.PatchElementTempl(userBox); err != nil {
@@ -153,7 +201,7 @@ func (h *handlers) singleRoomSSE(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			draftPage := pages.Draft(player, myRoom)
+			draftPage := pages.Draft(player, myRoom, h.vibeIndex != nil)
 			if err := sse.PatchElementTempl(draftPage); err != nil {
 				h.logger.Error("Error patching draft page", "error", err)
 				return
@@ -169,18 +217,26 @@ func (h *handlers) singleRoomSSE(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		case room.RoomAnnounceEvent:
-			streamedMessagePage := pages.AiAnnounce(myRoom, myRoom.Game.Announcement)
+			streamedMessagePage := pages.AiAnnounce(myRoom, myRoom.GetAnnouncement())
 			if err := sse.PatchElementTempl(streamedMessagePage); err != nil {
 				return
 			}
+		case room.HostCommentEvent:
+			if err := sse.PatchElementTempl(pages.HostComment(myRoom.GetHostComment())); err != nil {
+				h.logger.Error("Error patching host comment", "error", err)
+				return
+			}
 		case room.RoomFinishEvent:
 			movieVotes := sortMoviesByVotes(myRoom.Game.Votes)
 			winnerMovies := getWinnerMovies(movieVotes)
-			resultsPage := pages.ResultsScreen(winnerMovies[0], myRoom)
+			resultsPage := pages.ResultsScreen(winnerMovies[0], myRoom, user.Username, h.keepAccount(r.Context(), user))
 			if err := sse.PatchElementTempl(resultsPage); err != nil {
 				h.logger.Error("Error patching results page", "error", err)
 				return
 			}
+		case room.RoomPlayAgainEvent:
+			h.followRematch(r.Context(), sse, myRoom, user)
+			return
 
 		default: // discard for now, maybe error?
 		}
@@ -199,7 +255,17 @@ func (h *handlers) leaveRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.roomService.RemovePlayerFromRoom(myRoom.Name, user.Username)
+	h.leave(myRoom, user.Username)
+}
+
+// leave takes username out of the room, the room closes when its last player leaves
+func (h *handlers) leave(myRoom *room.Room, username string) {
+	// Spectators leaving doesn't touch the game
+	if h.roomService.RemoveSpectator(myRoom.Name, username) {
+		return
+	}
+
+	h.roomService.RemovePlayerFromRoom(myRoom.Name, username)
 
 	allUsers := myRoom.GetAllPlayers()
 	if len(allUsers) == 0 {
@@ -207,7 +273,7 @@ func (h *handlers) leaveRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if myRoom.Game.Host == user.Username {
+	if myRoom.Game.Host == username {
 		// If host leaves transfer to random other user
 		for newHostUsername := range myRoom.Players {
 			h.roomService.TransferHost(myRoom.Name, newHostUsername)
@@ -216,6 +282,41 @@ func (h *handlers) leaveRoom(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// unlockRoom lets a user into a password protected room. Wrong passwords count against
+// the user like failed sign ins, so the password can't be guessed.
+func (h *handlers) unlockRoom(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Error parsing form", http.StatusBadRequest)
+		return
+	}
+
+	roomName := chi.URLParam(r, "roomName")
+	user, ok := h.getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+	myRoom, ok := h.roomService.GetRoom(roomName)
+	if !ok || myRoom.Game.Visibility != room.PasswordProtected {
+		web.SendSSEError(w, r, "This room doesn't exist anymore", h.logger)
+		return
+	}
+
+	key := fmt.Sprintf("room:%s:%s", roomName, user.Username)
+	if wait := h.lockout.Check(key); wait > 0 {
+		web.SendSSEError(w, r, fmt.Sprintf("Too many wrong passwords, try again in %s", wait.Round(time.Second)), h.logger)
+		return
+	}
+	if !myRoom.CheckPassword(r.FormValue("password")) {
+		h.lockout.Fail(key)
+		web.SendSSEError(w, r, "Wrong password", h.logger)
+		return
+	}
+	h.lockout.Reset(key)
+
+	h.roomService.AdmitPlayer(myRoom.Name, user.Username)
+	datastar.NewSSE(w, r).Redirect(fmt.Sprintf("/room/%s/lobby", myRoom.Name))
+}
+
 func (h *handlers) publishChatMessage(w http.ResponseWriter, r *http.Request) {
 	var req room.Message
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -231,9 +332,19 @@ func (h *handlers) publishChatMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Guests can only chat in the room they joined
+	if auth.IsGuest(user) && req.Room != user.GuestRoom {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	req.Username = user.Username
+	// Only players chat, spectators just read along
 	myRoom, ok := h.roomService.GetRoom(req.Room)
-	if ok {
+	if !ok {
+		return
+	}
+	if _, isPlayer := myRoom.GetPlayer(user.Username); isPlayer {
 		h.roomService.AddMessage(myRoom.Name, req)
 	}
 }
@@ -253,23 +364,65 @@ func (h *handlers) ready(w http.ResponseWriter, r *http.Request) {
 
 func (h *handlers) startGame(w http.ResponseWriter, r *http.Request) {
 	roomName := chi.URLParam(r, "roomName")
+	user, ok := h.getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	myRoom, ok := h.roomService.GetRoom(roomName)
-	if ok {
-		myRoom.Game.Step = room.Draft
-		movies, err := h.movieService.GetMovies()
-		if err != nil {
-			h.logger.Error("Call to MovieService.GetMovies failed", "Error", err)
-			return
-		}
+	if !ok {
+		return
+	}
+	if myRoom.Game.Host != user.Username {
+		http.Error(w, "Only the host can start the game", http.StatusForbidden)
+		return
+	}
 
-		if len(movies) == 0 {
-			h.logger.Warn(fmt.Sprintf("Room %s: No Movies Found", myRoom.Name))
+	players := myRoom.GetAllPlayers()
+	usernames := make([]string, 0, len(players))
+	for _, player := range players {
+		usernames = append(usernames, player.Username)
+	}
+
+	movies, err := h.movieService.Pool(r.Context(), myRoom.Game.Source, usernames)
+	if err != nil {
+		h.logger.Error("Call to MovieService.Pool failed", "Error", err, "source", myRoom.Game.Source.String())
+		web.SendSSEError(w, r, "Could not get the movies to draft from, try again.", h.logger)
+		return
+	}
+
+	// The room only drafts from the part of the source the host picked
+	movies = myRoom.Game.Constraints.Filter(movies)
+	if len(movies) == 0 {
+		h.logger.Warn(fmt.Sprintf("Room %s: No Movies Found", myRoom.Name), "source", myRoom.Game.Source.String(), "constraints", myRoom.Game.Constraints.String())
+		switch {
+		case myRoom.Game.Source.Kind == movie.FromWatchlists:
+			web.SendSSEError(w, r, "None of the players' watchlists have movies that fit this room's movie pool.", h.logger)
+			return
+		case myRoom.Game.Source.Kind == movie.FromList:
+			web.SendSSEError(w, r, fmt.Sprintf("The list %s has no movies that fit this room's movie pool.", myRoom.Game.Source.ListName), h.logger)
+			return
+		case !myRoom.Game.Constraints.IsZero():
+			web.SendSSEError(w, r, "No movies in the library fit this room's movie pool.", h.logger)
+			return
 		}
+	}
 
-		myRoom.Game.AllMovies = movies
+	myRoom.Game.Step = room.Draft
+	myRoom.Game.AllMovies = movies
 
-		h.roomService.StartGame(roomName, myRoom.Game.AllMovies)
+	// Players signed in through Jellyfin only get the movies they are allowed to see
+	available := make(map[string][]movie.Movie)
+	for _, player := range players {
+		visible, err := h.movieService.VisibleTo(r.Context(), player.Username, movies)
+		if err != nil {
+			h.logger.Error("Failed to get visible movies", "Room", roomName, "Username", player.Username, "error", err)
+			visible = []movie.Movie{}
+		}
+		available[player.Username] = visible
 	}
+
+	h.roomService.StartGame(roomName, myRoom.Game.AllMovies, available)
 }
 
 // ============= DRAFT HANDLERS =============
@@ -278,6 +431,7 @@ type movieQueryRequest struct {
 	Search string `json:"search"`
 	Genre  string `json:"genre"`
 	Sort   string `json:"sort"`
+	Vibe   string `json:"vibe"`
 }
 
 func (h *handlers) draft(w http.ResponseWriter, r *http.Request) {
@@ -287,7 +441,7 @@ func (h *handlers) draft(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	web.RenderPageNoLayout(pages.Draft(player, myRoom), myRoom.Name, w, r)
+	web.RenderPageNoLayout(pages.Draft(player, myRoom, h.vibeIndex != nil), myRoom.Name, w, r)
 }
 
 func (h *handlers) deleteFromSelectedMovies(w http.ResponseWriter, r *http.Request) {
@@ -337,6 +491,12 @@ func (h *handlers) toggleDraftMovie(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 	}
+	// Suggestions can be drafted even when a search hides them from the grid
+	for _, sug := range player.Suggestions {
+		if mov.Id == "" && sug.Movie.Id == movieId {
+			mov = sug.Movie
+		}
+	}
 
 	if !h.roomService.ToggleDraftMovie(roomName, user.Username, mov) {
 		h.logger.Warn("Failed to toggle draft movie", "Room", roomName, "Username", user.Username, "MovieId", movieId)
@@ -349,6 +509,33 @@ func (h *handlers) queryMovies(w http.ResponseWriter, r *http.Request) {
 	h.renderDraftPage(w, r)
 }
 
+// suggestMovies asks for picks the whole room is likely to agree on, from the movies
+// this player can see
+func (h *handlers) suggestMovies(w http.ResponseWriter, r *http.Request) {
+	roomName := chi.URLParam(r, "roomName")
+	myRoom, _, player, ok := h.getRoomUserAndPlayer(w, r, roomName)
+	if !ok {
+		return
+	}
+
+	candidates, err := h.movieService.VisibleTo(r.Context(), player.Username, myRoom.Game.AllMovies)
+	if err != nil {
+		h.logger.Error("Failed to filter movies for suggestions", "error", err)
+		web.SendSSEError(w, r, "Couldn't load suggestions, try again.", h.logger)
+		return
+	}
+
+	suggestions, err := h.recommender.Suggest(r.Context(), roomName, playerNames(myRoom), candidates, suggestionCount)
+	if err != nil {
+		h.logger.Error("Failed to suggest movies", "roomName", roomName, "error", err)
+		web.SendSSEError(w, r, "Couldn't load suggestions, try again.", h.logger)
+		return
+	}
+	h.roomService.SetSuggestions(roomName, player.Username, suggestions)
+
+	h.renderDraftPage(w, r)
+}
+
 func (h *handlers) draftSubmit(w http.ResponseWriter, r *http.Request) {
 	roomName := chi.URLParam(r, "roomName")
 	myRoom, _, player, ok := h.getRoomUserAndPlayer(w, r, roomName)
@@ -361,7 +548,7 @@ func (h *handlers) draftSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	player.HasFinishedDraft = true
+	h.roomService.FinishDraft(roomName, player.Username)
 
 	isDraftFinished := myRoom.IsDraftFinished()
 
@@ -370,6 +557,7 @@ func (h *handlers) draftSubmit(w http.ResponseWriter, r *http.Request) {
 		myRoom.Game.Step = room.Voting
 		h.roomService.SubmitDraftVotes(myRoom)
 		h.roomService.MoveToVoting(roomName)
+		h.commentOnDraft(myRoom)
 	} else {
 		h.renderDraftPage(w, r)
 	}
@@ -417,8 +605,8 @@ func (h *handlers) renderDraftPage(w http.ResponseWriter, r *http.Request) {
 		// sortField = movie.SortByName
 	}
 
-	var err error
-	player.AvailableMovies, err = h.movieService.GetMoviesWithQuery(
+	movies, err := h.movieService.GetMoviesWithQuery(
+		r.Context(),
 		movie.Query{
 			Search:     queryRequest.Search,
 			Genre:      queryRequest.Genre,
@@ -426,11 +614,26 @@ func (h *handlers) renderDraftPage(w http.ResponseWriter, r *http.Request) {
 			Descending: descending,
 		},
 	)
+	if err == nil {
+		// Searches run over the whole library, only the room's pool can be drafted
+		movies = myRoom.Game.InPool(movies)
+		movies, err = h.movieService.VisibleTo(r.Context(), player.Username, movies)
+	}
+	// The vibe overrides the sort, the other filters still apply
+	if vibe := strings.TrimSpace(queryRequest.Vibe); err == nil && vibe != "" && h.vibeIndex != nil {
+		// Past the budget, or with the embedder down, the movies keep their sort
+		if ranked, rankErr := h.vibeIndex.Rank(r.Context(), vibe, movies); rankErr != nil {
+			h.logger.Warn("Vibe search failed", "error", rankErr)
+		} else {
+			movies = ranked
+		}
+	}
+	player.AvailableMovies = movies
 
 	if err != nil {
 		h.logger.Error("Movie Query Error", "Error", err)
 	}
-	draft := pages.Draft(player, myRoom)
+	draft := pages.Draft(player, myRoom, h.vibeIndex != nil)
 	if err := datastar.NewSSE(w, r).PatchElementTempl(draft); err != nil {
 		h.logger.Error("Error Rendering Draft Page", "error", err)
 	}
@@ -460,7 +663,7 @@ func (h *handlers) votingSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	player.HasFinishedVoting = true
+	h.roomService.FinishVoting(roomName, player.Username)
 
 	isVotingFinished := myRoom.IsVotingFinished()
 
@@ -475,7 +678,7 @@ func (h *handlers) votingSubmit(w http.ResponseWriter, r *http.Request) {
 			// Cap number of ties at 3, choose randomly after
 			if myRoom.Game.Ties >= 3 {
 				myRoom.Game.Step = room.Announce
-				h.generateAndStreamAnnouncement(roomName, tiedMovies[0].Movie)
+				h.startAnnouncement(roomName, tiedMovies[0].Movie)
 				return
 			}
 			tied := make([]movie.Movie, 0, len(tiedMovies))
@@ -491,9 +694,15 @@ func (h *handlers) votingSubmit(w http.ResponseWriter, r *http.Request) {
 			}
 			h.logger.Info("Tie detected, moving to revote", "roomName", roomName, "tiedMovies", len(tiedMovies), "votes", tiedMovies[0].Votes)
 			h.roomService.MoveToVoting(myRoom.Name)
+			h.hostService.Comment(host.Event{
+				Kind:    host.Tie,
+				Room:    myRoom.Name,
+				Players: playerNames(myRoom),
+				Movies:  movieNames(tied),
+			})
 		} else {
 			myRoom.Game.Step = room.Announce
-			h.generateAndStreamAnnouncement(roomName, tiedMovies[0].Movie)
+			h.startAnnouncement(roomName, tiedMovies[0].Movie)
 		}
 	} else {
 		h.renderVotingPage(w, r)
@@ -541,7 +750,7 @@ func (h *handlers) renderVotingPage(w http.ResponseWriter, r *http.Request) {
 
 func (h *handlers) results(w http.ResponseWriter, r *http.Request) {
 	roomName := chi.URLParam(r, "roomName")
-	myRoom, _, _, ok := h.getRoomUserAndPlayer(w, r, roomName)
+	myRoom, user, _, ok := h.getRoomUserAndPlayer(w, r, roomName)
 	if !ok {
 		return
 	}
@@ -549,11 +758,129 @@ func (h *handlers) results(w http.ResponseWriter, r *http.Request) {
 	movieVotes := sortMoviesByVotes(myRoom.Game.Votes)
 	winnerMovies := getWinnerMovies(movieVotes)
 
-	web.RenderPage(pages.ResultsScreen(winnerMovies[0], myRoom), myRoom.Name, w, r)
+	web.RenderPage(pages.ResultsScreen(winnerMovies[0], myRoom, user.Username, h.keepAccount(r.Context(), user)), myRoom.Name, w, r)
+}
+
+// playAgain has the host start a new room with the same settings and players once the
+// game is over. Everyone in the room is sent on to its lobby.
+func (h *handlers) playAgain(w http.ResponseWriter, r *http.Request) {
+	roomName := chi.URLParam(r, "roomName")
+	user, ok := h.getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	myRoom, ok := h.roomService.GetRoom(roomName)
+	if !ok || myRoom.Game.Host != user.Username {
+		http.Error(w, "Only the host can play again", http.StatusForbidden)
+		return
+	}
+
+	next, ok := h.roomService.PlayAgain(myRoom.Name)
+	if !ok {
+		http.Error(w, "The game isn't over yet", http.StatusConflict)
+		return
+	}
+	h.logger.Debug("Host played again", "Room", roomName, "NextRoom", next.Name)
+}
+
+// followRematch sends the user on from myRoom to the room their group is playing again
+// in. Guests can only be in one room, so they are moved there first.
+func (h *handlers) followRematch(ctx context.Context, sse *datastar.ServerSentEventGenerator, myRoom *room.Room, user *sqlcgen.User) {
+	nextRoom := myRoom.GetNextRoom()
+	// Done here rather than by the page's leave beacon, guests can't reach the old room
+	// once they're moved
+	h.leave(myRoom, user.Username)
+	if auth.IsGuest(user) {
+		if err := h.authService.MoveGuest(ctx, user, nextRoom); err != nil {
+			h.logger.Error("Failed to move guest to the next room", "username", user.Username, "nextRoom", nextRoom, "error", err)
+			return
+		}
+	}
+
+	// The lobby asks before leaving the page, this isn't leaving
+	script := fmt.Sprintf("window.allowNavigation = true; window.location.href = '/room/%s/lobby';", nextRoom)
+	if err := sse.ExecuteScript(script); err != nil {
+		h.logger.Warn("Error sending player to the next room", "error", err)
+	}
+}
+
+// keepAccount offers guests an account of their own on the results page, so their picks
+// count towards their stats
+func (h *handlers) keepAccount(ctx context.Context, user *sqlcgen.User) pages.KeepAccount {
+	if !auth.IsGuest(user) {
+		return pages.KeepAccount{}
+	}
+
+	mode, err := h.authService.RegistrationMode(ctx)
+	if err != nil {
+		h.logger.Error("Failed to get registration mode", "error", err)
+		mode = auth.RegistrationClosed
+	}
+	if h.authService.JellyfinOnly {
+		mode = auth.RegistrationClosed
+	}
+	return pages.KeepAccount{
+		Guest:        true,
+		Name:         strings.TrimSuffix(user.Username, auth.GuestSuffix),
+		Registration: mode,
+	}
 }
 
 // =============== HELPERS ================
 
+// commentOnDraft has the host point out movies more than one player drafted, or give
+// a hot take on the vote ahead if everyone picked something different
+func (h *handlers) commentOnDraft(myRoom *room.Room) {
+	drafted := make(map[string]int)
+	var overlaps []movie.Movie
+	for _, p := range myRoom.GetAllPlayers() {
+		for _, m := range p.DraftMovies {
+			drafted[m.Id]++
+			if drafted[m.Id] == 2 {
+				overlaps = append(overlaps, m)
+			}
+		}
+	}
+
+	ev := host.Event{
+		Kind:    host.DraftOverlap,
+		Room:    myRoom.Name,
+		Players: playerNames(myRoom),
+		Movies:  movieNames(overlaps),
+	}
+	if len(overlaps) == 0 {
+		ev.Kind = host.VotingHotTake
+		ev.Movies = movieNames(myRoom.Game.VotingMovies)
+	}
+	h.hostService.Comment(ev)
+}
+
+func playerNames(myRoom *room.Room) []string {
+	players := myRoom.PlayersByJoinTime()
+	names := make([]string, 0, len(players))
+	for _, p := range players {
+		names = append(names, p.Username)
+	}
+	return names
+}
+
+func movieNames(movies []movie.Movie) []string {
+	names := make([]string, 0, len(movies))
+	for _, m := range movies {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// startAnnouncement runs the announcement in the background so the vote that finished
+// the game isn't held open for the whole show
+func (h *handlers) startAnnouncement(roomName string, winnerMovie *movie.Movie) {
+	h.roomService.RunInBackground(roomName, "announcement", func(ctx context.Context) {
+		h.generateAndStreamAnnouncement(ctx, roomName, winnerMovie)
+	})
+}
+
 // sortMoviesByVotes converts a vote map to a sorted slice (descending by votes)
 func sortMoviesByVotes(votes map[*movie.Movie]int) []movie.Vote {
 	movieVotes := make([]movie.Vote, 0, len(votes))
@@ -591,82 +918,87 @@ func getWinnerMovies(moviesSortedByVote []movie.Vote) []movie.Vote {
 	return winners
 }
 
-// generateAndStreamAnnouncement runs AI generation once and streams to all clients via NATS
-func (h *handlers) generateAndStreamAnnouncement(roomName string, winnerMovie *movie.Movie) {
-	myRoom, ok := h.roomService.GetRoom(roomName)
-	if !ok {
-		return
-	}
-
+// generateAndStreamAnnouncement runs AI generation once and streams to all clients via
+// NATS as it is generated. It runs as a room background job, so ctx is cancelled if the
+// room is deleted part way through.
+func (h *handlers) generateAndStreamAnnouncement(ctx context.Context, roomName string, winnerMovie *movie.Movie) {
 	// Initial drum roll
-	myRoom.Game.Announcement = []room.DialogueLine{{
+	h.roomService.SetAnnouncement(roomName, []room.DialogueLine{{
 		Character: "Announcer ",
 		Dialogue:  "Drum Roll Please",
-	}}
-	h.roomService.StreamAnnouncement(roomName)
-	time.Sleep(2 * time.Second)
-
-	// Clear and generate AI dialogue
-	myRoom.Game.Announcement = []room.DialogueLine{}
-
-	buildGptMessage := fmt.Sprintf(`You are writing a reveal scene for: %s
-
-  Write a dialogue-only scene using this EXACT format:
-
-  **[Character Name]:** *"their dialogue"*
-  **[Different Character]:** *"their response"*
-
-  RULES:
-  1. Use 2-4 characters from the movie
-  2. Each character speaks 1-2 times
-  3. Include character catchphrases naturally
-  4. Build suspense without saying the movie title
-  5. NO actor names, NO spoilers, NO narration, NO movie title
-  6. Match the movie's genre/tone
-  7. Keep your responses TERSE
-
-  EXAMPLE (for a different movie):
-  **Morpheus:** *"What if I told you... we're the ones they chose?"*
-  **Trinity:** *"The question isn't how, it's why."*
-
-  NOW write the scene for:`, winnerMovie.Name)
+	}})
+	if !sleepCtx(ctx, 2*time.Second) {
+		return
+	}
 
-	var finalMessage string
-	if h.openAiProvider != nil {
-		var err error
-		finalMessage, err = h.openAiProvider.FetchAiResponse(buildGptMessage)
+	var lines []room.DialogueLine
+	if h.llmProvider != nil {
+		// Push the dialogue as it is typed, but don't re-render every player's page
+		// for every token
+		var lastPush time.Time
+		script, err := h.announcementWriter.Write(ctx, roomName, *winnerMovie, func(partial []announcement.Line) {
+			if time.Since(lastPush) < announcementPushInterval {
+				return
+			}
+			lastPush = time.Now()
+			h.roomService.SetAnnouncement(roomName, toDialogue(partial))
+		})
 		if err != nil {
-			h.logger.Error("AI request failed", "error", err)
+			return
 		}
-	}
 
-	dialogueRegex := regexp.MustCompile(`\*\*\[?([^\]:]+)\]?:\*\*\s*\*"([^"]+)"\*`)
-	matches := dialogueRegex.FindAllStringSubmatch(finalMessage, -1)
+		h.logger.Debug("Announcement scene ready", "roomName", roomName, "mood", script.Mood, "lines", len(script.Lines))
+		lines = toDialogue(script.Lines)
+		h.roomService.SetAnnouncement(roomName, lines)
+	}
 
-	// Stream each dialogue line
-	for _, match := range matches {
-		if len(match) == 3 {
-			line := room.DialogueLine{
-				Character: strings.TrimSpace(match[1]),
-				Dialogue:  strings.TrimSpace(match[2]),
-			}
-			myRoom.Game.Announcement = append(myRoom.Game.Announcement, line)
-			h.roomService.StreamAnnouncement(roomName)
-			time.Sleep(2 * time.Second)
-		}
+	// Give everyone a moment to read the end of the scene
+	if len(lines) > 0 && !sleepCtx(ctx, announcementReadTime) {
+		return
 	}
 
 	// Final announcement
-	myRoom.Game.Announcement = []room.DialogueLine{{
+	h.roomService.SetAnnouncement(roomName, []room.DialogueLine{{
 		Character: "Announcer",
 		Dialogue:  "And the Winner Is...",
-	}}
-	h.roomService.StreamAnnouncement(roomName)
-	time.Sleep(2 * time.Second)
+	}})
+	if !sleepCtx(ctx, 2*time.Second) {
+		return
+	}
 
 	h.roomService.FinishGame(roomName)
 }
 
+// How many movies "Suggest for this group" picks
+const suggestionCount = 6
+
+const (
+	// How often partial dialogue is pushed to players while the AI is still writing
+	announcementPushInterval = 150 * time.Millisecond
+	announcementReadTime     = 3 * time.Second
+)
+
+func toDialogue(lines []announcement.Line) []room.DialogueLine {
+	dialogue := make([]room.DialogueLine, 0, len(lines))
+	for _, l := range lines {
+		dialogue = append(dialogue, room.DialogueLine{
+			Character: l.Character,
+			Dialogue:  l.Text,
+		})
+	}
+	return dialogue
+}
+
+// sleepCtx waits for d, returning false if ctx is cancelled first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
 // =============== VALIDATION HELPERS ================
 
 // getUserFromRequest retrieves and validates the user from the request context
@@ -679,6 +1011,26 @@ func (h *handlers) getUserFromRequest(w http.ResponseWriter, r *http.Request) (*
 	return user, true
 }
 
+// requireEntry keeps everyone the room doesn't let in away from its game, see
+// Room.CanEnter. To them the room doesn't exist, like its lobby.
+func (h *handlers) requireEntry(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := h.getUserFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		roomName := chi.URLParam(r, "roomName")
+		if myRoom, ok := h.roomService.GetRoom(roomName); ok && !myRoom.CanEnter(user.Username) {
+			h.logger.Warn("Kept out of a room they can't enter", "username", user.Username, "room", roomName, "path", r.URL.Path)
+			http.Error(w, "Room not found", http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // getRoomByName retrieves a room by name and handles error response if not found
 func (h *handlers) getRoomByName(w http.ResponseWriter, r *http.Request, roomName string) (*room.Room, bool) {
 	myRoom, ok := h.roomService.GetRoom(roomName)
@@ -2,9 +2,15 @@ package game
 
 import (
 	"log/slog"
+	"net/http"
 
+	"watchma/pkg/auth"
+	"watchma/pkg/embedding"
+	"watchma/pkg/host"
+	"watchma/pkg/llm"
 	"watchma/pkg/movie"
-	"watchma/pkg/openai"
+	"watchma/pkg/ratelimit"
+	"watchma/pkg/recommend"
 	"watchma/pkg/room"
 
 	"github.com/go-chi/chi/v5"
@@ -14,35 +20,52 @@ import (
 func SetupRoutes(
 	r chi.Router,
 	roomService *room.Service,
+	authService *auth.AuthService,
 	movieService *movie.Service,
-	openAiProvider *openai.Provider,
+	hostService *host.Service,
+	recommender *recommend.Service,
+	vibeIndex *embedding.Index,
+	llmProvider llm.Provider,
+	roomLockout *ratelimit.Lockout,
+	chatLimit func(http.Handler) http.Handler,
 	logger *slog.Logger,
 	nats *nats.Conn,
 ) error {
-	handlers := newHandlers(roomService, movieService, openAiProvider, logger, nats)
+	handlers := newHandlers(roomService, authService, movieService, hostService, recommender, vibeIndex, llmProvider, roomLockout, logger, nats)
 
 	// Lobby
 	r.Get("/room/{roomName}/lobby", handlers.singleRoom)
 	r.Get("/sse/{roomName}", handlers.singleRoomSSE)
-	r.Post("/message", handlers.publishChatMessage)
-	r.Post("/room/{roomName}/ready", handlers.ready)
-	r.Post("/room/{roomName}/start", handlers.startGame)
-	r.Post("/room/{roomName}/leave", handlers.leaveRoom)
-
-	// Draft
-	r.Get("/room/{roomName}/draft", handlers.draft)
-	r.Post("/draft/{roomName}/submit", handlers.draftSubmit)
-	r.Post("/draft/{roomName}/query", handlers.queryMovies)
-	r.Patch("/draft/{roomName}/{id}", handlers.toggleDraftMovie)
-	r.Delete("/draft/{roomName}/{id}", handlers.deleteFromSelectedMovies)
-
-	// Voting
-	r.Get("/room/{roomName}/voting", handlers.voting)
-	r.Post("/voting/{roomName}/submit", handlers.votingSubmit)
-	r.Patch("/voting/{roomName}/{id}", handlers.toggleVotingMovie)
-
-	// Results
-	r.Get("/room/{roomName}/results", handlers.results)
+	r.With(chatLimit).Post("/message", handlers.publishChatMessage)
+	// The way in for people the room doesn't let in yet, the rest is behind requireEntry
+	r.Post("/room/{roomName}/unlock", handlers.unlockRoom)
+
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.requireEntry)
+
+		// Lobby
+		r.Post("/room/{roomName}/ready", handlers.ready)
+		r.Post("/room/{roomName}/start", handlers.startGame)
+		r.Post("/room/{roomName}/leave", handlers.leaveRoom)
+		r.Post("/room/{roomName}/promote", handlers.promoteSpectator)
+
+		// Draft
+		r.Get("/room/{roomName}/draft", handlers.draft)
+		r.Post("/draft/{roomName}/submit", handlers.draftSubmit)
+		r.Post("/draft/{roomName}/query", handlers.queryMovies)
+		r.Post("/draft/{roomName}/suggest", handlers.suggestMovies)
+		r.Patch("/draft/{roomName}/{id}", handlers.toggleDraftMovie)
+		r.Delete("/draft/{roomName}/{id}", handlers.deleteFromSelectedMovies)
+
+		// Voting
+		r.Get("/room/{roomName}/voting", handlers.voting)
+		r.Post("/voting/{roomName}/submit", handlers.votingSubmit)
+		r.Patch("/voting/{roomName}/{id}", handlers.toggleVotingMovie)
+
+		// Results
+		r.Get("/room/{roomName}/results", handlers.results)
+		r.Post("/room/{roomName}/again", handlers.playAgain)
+	})
 
 	return nil
 }
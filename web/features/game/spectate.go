@@ -0,0 +1,131 @@
+package game
+
+import (
+	"fmt"
+	"net/http"
+
+	"watchma/db/sqlcgen"
+	"watchma/pkg/room"
+	"watchma/web"
+	"watchma/web/features/game/pages"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// ============= SPECTATOR HANDLERS =============
+
+// spectate has the user watch the room, for when it is full or the game has started
+func (h *handlers) spectate(w http.ResponseWriter, r *http.Request, myRoom *room.Room, user *sqlcgen.User) {
+	h.roomService.AddSpectator(myRoom.Name, user.Username)
+	web.RenderPageNoLayout(pages.Spectate(myRoom, spectatorContent(myRoom)), myRoom.Name, w, r)
+}
+
+// spectatorContent is what spectators see of the room at its current step: progress
+// counts until the announcement, then the same announcement and results as players
+func spectatorContent(myRoom *room.Room) templ.Component {
+	switch myRoom.Game.Step {
+	case room.Announce:
+		return pages.AiAnnounce(myRoom, myRoom.GetAnnouncement())
+	case room.Results:
+		winnerMovies := getWinnerMovies(sortMoviesByVotes(myRoom.Game.Votes))
+		return pages.ResultsScreen(winnerMovies[0], myRoom, "", pages.KeepAccount{})
+	default:
+		return pages.SpectatorView(myRoom)
+	}
+}
+
+// spectateSSE keeps a spectator's page up to date. Spectators the host lets play are
+// sent on to the lobby.
+func (h *handlers) spectateSSE(w http.ResponseWriter, r *http.Request, sse *datastar.ServerSentEventGenerator, myRoom *room.Room, user *sqlcgen.User) {
+	patchContent := func() bool {
+		if err := sse.PatchElementTempl(spectatorContent(myRoom)); err != nil {
+			h.logger.Error("Error patching spectator view", "error", err)
+			return false
+		}
+		if myRoom.Game.Step == room.Lobby && len(myRoom.RoomMessages) > 0 {
+			if err := sse.PatchElementTempl(pages.ChatBox(myRoom.RoomMessages)); err != nil {
+				h.logger.Error("Error patching spectator chat", "error", err)
+				return false
+			}
+		}
+		return true
+	}
+	if !patchContent() {
+		return
+	}
+
+	roomSubject := room.RoomSubject(myRoom.Name)
+	sub, err := h.nats.SubscribeSync(roomSubject)
+	h.logger.Debug(room.NATSSub, "subject", roomSubject)
+	if err != nil {
+		http.Error(w, "Subscribe Failed", http.StatusInternalServerError)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		msg, err := sub.NextMsgWithContext(r.Context())
+		if err != nil {
+			// context canceled or sub closed
+			return
+		}
+
+		if _, isPlayer := myRoom.GetPlayer(user.Username); isPlayer {
+			sse.Redirect(fmt.Sprintf("/room/%s/lobby", myRoom.Name))
+			return
+		}
+		if !myRoom.IsSpectator(user.Username) {
+			sse.Redirect("/")
+			return
+		}
+
+		switch string(msg.Data) {
+		case room.MessageSentEvent:
+			if myRoom.Game.Step != room.Lobby {
+				continue
+			}
+			if err := sse.PatchElementTempl(pages.ChatBox(myRoom.RoomMessages)); err != nil {
+				h.logger.Error("Error patching spectator chat", "error", err)
+				return
+			}
+		case room.HostCommentEvent:
+			if err := sse.PatchElementTempl(pages.HostComment(myRoom.GetHostComment())); err != nil {
+				h.logger.Error("Error patching host comment", "error", err)
+				return
+			}
+		case room.RoomPlayAgainEvent:
+			h.followRematch(r.Context(), sse, myRoom, user)
+			return
+		case room.RoomUpdateEvent, room.RoomProgressEvent, room.RoomStartEvent, room.RoomVotingEvent,
+			room.RoomAnnounceEvent, room.RoomFinishEvent:
+			if !patchContent() {
+				return
+			}
+		default: // discard unknown non-matching messages
+		}
+	}
+}
+
+// promoteSpectator lets the host turn a spectator into a player while in the lobby
+func (h *handlers) promoteSpectator(w http.ResponseWriter, r *http.Request) {
+	roomName := chi.URLParam(r, "roomName")
+	user, ok := h.getUserFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	myRoom, ok := h.roomService.GetRoom(roomName)
+	if !ok || myRoom.Game.Host != user.Username {
+		http.Error(w, "Only the host can let spectators play", http.StatusForbidden)
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if !h.roomService.PromoteSpectator(roomName, username) {
+		http.Error(w, "Spectator can't join the game", http.StatusConflict)
+		return
+	}
+	h.logger.Debug("Spectator promoted by host", "Room", roomName, "Username", username)
+}
@@ -29,7 +29,8 @@ func newHandlers(ms *movie.Service, queries *sqlcgen.Queries) *handlers {
 }
 
 func (h *handlers) index(w http.ResponseWriter, r *http.Request) {
-	movieOfTheDay, err := h.movieService.GetMovieOfTheDay()
+	user := appctx.GetUserFromRequest(r)
+	movieOfTheDay, err := h.movieService.GetMovieOfTheDayFor(r.Context(), user.Username)
 
 	if err != nil {
 		// Check if it's an HTTP error from Jellyfin
@@ -58,7 +59,14 @@ func (h *handlers) shuffle(w http.ResponseWriter, r *http.Request) {
 		numberOfMovies = n
 	}
 
-	shuffledMovies, err := h.movieService.GetShuffledMovies()
+	shuffledMovies, err := h.movieService.GetShuffledMovies(r.Context())
+	if err != nil {
+		http.Error(w, "failed to get movies", http.StatusInternalServerError)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	shuffledMovies, err = h.movieService.VisibleTo(r.Context(), user.Username, shuffledMovies)
 	if err != nil {
 		http.Error(w, "failed to get movies", http.StatusInternalServerError)
 		return
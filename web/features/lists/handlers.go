@@ -0,0 +1,213 @@
+package lists
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appctx "watchma/pkg/context"
+	"watchma/pkg/movie"
+	"watchma/web"
+	"watchma/web/features/lists/pages"
+	"watchma/web/views/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// How many movies a watchlist search shows
+const maxSearchResults = 24
+
+type handlers struct {
+	movieService *movie.Service
+	logger       *slog.Logger
+}
+
+func newHandlers(ms *movie.Service, logger *slog.Logger) *handlers {
+	return &handlers{
+		movieService: ms,
+		logger:       logger,
+	}
+}
+
+type searchRequest struct {
+	Search string `json:"search"`
+}
+
+func (h *handlers) lists(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+
+	watchlist, err := h.movieService.Watchlist(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get watchlist", "username", user.Username, "error", err)
+		http.Error(w, "Failed to get watchlist", http.StatusInternalServerError)
+		return
+	}
+	lists, err := h.movieService.Lists(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to list movie lists", "username", user.Username, "error", err)
+		http.Error(w, "Failed to get lists", http.StatusInternalServerError)
+		return
+	}
+
+	web.RenderPage(pages.ListsPage(watchlist, lists, h.movieService.HasLists()), "Lists", w, r)
+}
+
+func (h *handlers) search(w http.ResponseWriter, r *http.Request) {
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		h.logger.Warn("Error decoding watchlist search", "error", err)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	watchlist, err := h.movieService.Watchlist(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get watchlist", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to search movies", h.logger)
+		return
+	}
+
+	h.patchSearch(w, r, datastar.NewSSE(w, r), req.Search, watchlist)
+}
+
+// toggleWatchlist puts a movie on the watchlist or takes it off, from the watchlist or
+// the search results, and redraws both
+func (h *handlers) toggleWatchlist(w http.ResponseWriter, r *http.Request) {
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		h.logger.Warn("Error decoding watchlist toggle", "error", err)
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	movieID := chi.URLParam(r, "movieId")
+	if _, err := h.movieService.ToggleWatchlist(r.Context(), user.ID, movieID); err != nil {
+		h.logger.Error("Failed to update watchlist", "username", user.Username, "movieId", movieID, "error", err)
+		web.SendSSEError(w, r, "Failed to update your watchlist", h.logger)
+		return
+	}
+
+	watchlist, err := h.movieService.Watchlist(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get watchlist", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to update your watchlist", h.logger)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	if err := sse.PatchElementTempl(pages.Watchlist(watchlist)); err != nil {
+		h.logger.Error("Error patching watchlist", "error", err)
+		return
+	}
+	h.patchSearch(w, r, sse, req.Search, watchlist)
+}
+
+// patchSearch redraws the search results, only with the movies the user can see
+func (h *handlers) patchSearch(w http.ResponseWriter, r *http.Request, sse *datastar.ServerSentEventGenerator, search string, watchlist []movie.Movie) {
+	search = strings.TrimSpace(search)
+	if search == "" {
+		if err := sse.PatchElementTempl(pages.WatchlistSearch(nil, nil, false)); err != nil {
+			h.logger.Error("Error patching watchlist search", "error", err)
+		}
+		return
+	}
+
+	user := appctx.GetUserFromRequest(r)
+	movies, err := h.movieService.GetMoviesWithQuery(r.Context(), movie.Query{
+		Search: search,
+		SortBy: movie.SortByName,
+	})
+	if err == nil {
+		movies, err = h.movieService.VisibleTo(r.Context(), user.Username, movies)
+	}
+	if err != nil {
+		h.logger.Error("Watchlist search failed", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to search movies", h.logger)
+		return
+	}
+	if len(movies) > maxSearchResults {
+		movies = movies[:maxSearchResults]
+	}
+
+	if err := sse.PatchElementTempl(pages.WatchlistSearch(movies, watchlist, true)); err != nil {
+		h.logger.Error("Error patching watchlist search", "error", err)
+	}
+}
+
+func (h *handlers) importLists(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+
+	imported, err := h.movieService.ImportLists(r.Context(), user.ID, user.Username)
+	if errors.Is(err, movie.ErrListsUnsupported) {
+		web.SendSSEError(w, r, "This movie library doesn't have collections or playlists", h.logger)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to import movie lists", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Could not reach the movie library, try again later", h.logger)
+		return
+	}
+
+	lists, err := h.movieService.Lists(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to list movie lists", "username", user.Username, "error", err)
+		web.SendSSEError(w, r, "Failed to get your lists", h.logger)
+		return
+	}
+
+	notice := fmt.Sprintf("Imported %d lists", imported)
+	if imported == 1 {
+		notice = "Imported 1 list"
+	}
+	sse := datastar.NewSSE(w, r)
+	sse.PatchElementTempl(common.Error(""))
+	sse.PatchElementTempl(pages.MovieLists(lists, notice))
+}
+
+func (h *handlers) list(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid list id", http.StatusBadRequest)
+		return
+	}
+	list, movies, err := h.movieService.List(r.Context(), user.ID, listID)
+	if err != nil {
+		h.logger.Warn("Failed to get movie list", "username", user.Username, "id", listID, "error", err)
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	movies, err = h.movieService.VisibleTo(r.Context(), user.Username, movies)
+	if err != nil {
+		h.logger.Error("Failed to get visible movies", "username", user.Username, "error", err)
+		http.Error(w, "Failed to get movies", http.StatusInternalServerError)
+		return
+	}
+
+	web.RenderPage(pages.ListPage(list, movies), list.Name, w, r)
+}
+
+func (h *handlers) deleteList(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid list id", http.StatusBadRequest)
+		return
+	}
+	if err := h.movieService.DeleteList(r.Context(), user.ID, listID); err != nil {
+		h.logger.Warn("Failed to delete movie list", "username", user.Username, "id", listID, "error", err)
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/lists", http.StatusSeeOther)
+}
@@ -0,0 +1,26 @@
+package lists
+
+import (
+	"log/slog"
+
+	"watchma/pkg/movie"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func SetupRoutes(
+	r chi.Router,
+	movieService *movie.Service,
+	logger *slog.Logger,
+) error {
+	handlers := newHandlers(movieService, logger)
+
+	r.Get("/lists", handlers.lists)
+	r.Post("/lists/search", handlers.search)
+	r.Post("/lists/watchlist/{movieId}", handlers.toggleWatchlist)
+	r.Post("/lists/import", handlers.importLists)
+	r.Get("/lists/{id}", handlers.list)
+	r.Post("/lists/{id}/delete", handlers.deleteList)
+
+	return nil
+}
@@ -1,10 +1,12 @@
 package rooms
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	appctx "watchma/pkg/context"
 	"watchma/pkg/movie"
@@ -12,33 +14,37 @@ import (
 	"watchma/web"
 	"watchma/web/features/rooms/pages"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/nats-io/nats.go"
 	"github.com/starfederation/datastar-go/datastar"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type handlers struct {
-	roomService *room.Service
-	logger      *slog.Logger
-	nats        *nats.Conn
+	roomService  *room.Service
+	movieService *movie.Service
+	logger       *slog.Logger
+	nats         *nats.Conn
 }
 
-func newHandlers(rs *room.Service, logger *slog.Logger, nc *nats.Conn) *handlers {
+func newHandlers(rs *room.Service, ms *movie.Service, logger *slog.Logger, nc *nats.Conn) *handlers {
 	return &handlers{
-		roomService: rs,
-		logger:      logger,
-		nats:        nc,
+		roomService:  rs,
+		movieService: ms,
+		logger:       logger,
+		nats:         nc,
 	}
 }
 
 func (h *handlers) join(w http.ResponseWriter, r *http.Request) {
-	web.RenderPage(pages.JoinPage(h.roomService.Rooms), "Join Room", w, r)
+	web.RenderPage(pages.JoinPage(h.roomService.ListedRooms()), "Join Room", w, r)
 }
 
 func (h *handlers) joinSSE(w http.ResponseWriter, r *http.Request) {
 	sse := datastar.NewSSE(w, r)
 
 	// Send initial room list to new client
-	roomList := pages.RoomListBody(h.roomService.Rooms)
+	roomList := pages.RoomListBody(h.roomService.ListedRooms())
 	if err := sse.PatchElementTempl(roomList); err != nil {
 		h.logger.Error("Error patching initial room list", "error", err)
 	}
@@ -59,7 +65,7 @@ func (h *handlers) joinSSE(w http.ResponseWriter, r *http.Request) {
 		}
 		switch string(msg.Data) {
 		case room.RoomListUpdateEvent:
-			roomList := pages.RoomListBody(h.roomService.Rooms)
+			roomList := pages.RoomListBody(h.roomService.ListedRooms())
 			if err := sse.PatchElementTempl(roomList); err != nil {
 				h.logger.Error("Error patching room list", "error", err)
 				return
@@ -69,8 +75,80 @@ func (h *handlers) joinSSE(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// defaultHostForm is what the host form starts with when not hosting from a preset
+var defaultHostForm = room.Preset{MaxDraftCount: 3, MaxPlayers: 4, Visibility: room.Public}
+
 func (h *handlers) host(w http.ResponseWriter, r *http.Request) {
-	web.RenderPage(pages.HostPage(), "Host Room", w, r)
+	user := appctx.GetUserFromRequest(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		h.logger.Error("User was nil")
+		return
+	}
+
+	presets, err := h.roomService.Presets(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to list room presets", "username", user.Username, "error", err)
+	}
+	lists, err := h.movieService.Lists(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("Failed to list movie lists", "username", user.Username, "error", err)
+	}
+	// Presets only keep which list they draft from, the name is the list's current one
+	for i := range presets {
+		presets[i].Source.ListName = listName(lists, presets[i].Source)
+	}
+
+	form := defaultHostForm
+	if id := r.URL.Query().Get("preset"); id != "" {
+		presetID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid preset id", http.StatusBadRequest)
+			return
+		}
+		if form, err = h.roomService.Preset(r.Context(), user.ID, presetID); err != nil {
+			h.logger.Warn("Failed to get room preset", "username", user.Username, "id", presetID, "error", err)
+			http.Error(w, "Preset not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	web.RenderPage(pages.HostPage(form, presets, lists), "Host Room", w, r)
+}
+
+// listName is the name of the list src drafts from, empty if it isn't one of lists
+func listName(lists []movie.List, src movie.Source) string {
+	if src.Kind != movie.FromList {
+		return ""
+	}
+	for _, l := range lists {
+		if l.ID == src.ListID {
+			return l.Name
+		}
+	}
+	return ""
+}
+
+func (h *handlers) deletePreset(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		h.logger.Error("User was nil")
+		return
+	}
+
+	presetID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid preset id", http.StatusBadRequest)
+		return
+	}
+	if err := h.roomService.DeletePreset(r.Context(), user.ID, presetID); err != nil {
+		h.logger.Error("Failed to delete room preset", "username", user.Username, "id", presetID, "error", err)
+		http.Error(w, "Failed to delete preset", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/host", http.StatusSeeOther)
 }
 
 func (h *handlers) hostForm(w http.ResponseWriter, r *http.Request) {
@@ -106,16 +184,65 @@ func (h *handlers) hostForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	visibility, ok := room.ParseVisibility(r.FormValue("visibility"))
+	if !ok {
+		http.Error(w, "Unknown room visibility", http.StatusBadRequest)
+		return
+	}
+	var passwordHash []byte
+	if visibility == room.PasswordProtected {
+		password := r.FormValue("password")
+		if password == "" {
+			http.Error(w, "Password protected rooms need a password", http.StatusBadRequest)
+			return
+		}
+		if passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err != nil {
+			http.Error(w, "Failed to set room password", http.StatusInternalServerError)
+			h.logger.Error("Failed to hash room password", "error", err)
+			return
+		}
+	}
+
+	source, err := h.sourceFromForm(r, user.ID)
+	if err != nil {
+		http.Error(w, "Invalid draft source: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	constraints, err := constraintsFromForm(r)
+	if err != nil {
+		http.Error(w, "Invalid movie pool: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if h.roomService.RoomExists(roomName) {
 		http.Error(w, "This room name already exists", http.StatusConflict)
 		return
 	}
-	h.roomService.AddRoom(roomName, &room.Session{
+
+	session := &room.Session{
 		MaxDraftCount: movies,
 		MaxPlayers:    maxPlayers,
 		Host:          user.Username,
+		Visibility:    visibility,
+		PasswordHash:  passwordHash,
+		Source:        source,
+		Constraints:   constraints,
 		Votes:         make(map[*movie.Movie]int),
-	})
+	}
+
+	// Filling in a preset name saves the settings for next time, the password isn't kept
+	if presetName := r.FormValue("presetName"); presetName != "" {
+		_, err := h.roomService.SavePreset(r.Context(), user.ID, room.PresetOf(presetName, session))
+		if errors.Is(err, room.ErrInvalidPresetName) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			h.logger.Error("Failed to save room preset", "username", user.Username, "preset", presetName, "error", err)
+		}
+	}
+
+	h.roomService.AddRoom(roomName, session)
 
 	http.Redirect(w, r, fmt.Sprintf("/room/%s/lobby", roomName), http.StatusSeeOther)
 }
@@ -132,6 +259,58 @@ func atoiField(r *http.Request, key string) (int, error) {
 	return i, nil
 }
 
+// sourceFromForm reads where the room drafts from, lists are written as list:ID. Only
+// the host's own lists can be drafted from.
+func (h *handlers) sourceFromForm(r *http.Request, userID int64) (movie.Source, error) {
+	value := r.FormValue("source")
+	id, isList := strings.CutPrefix(value, "list:")
+	if !isList {
+		kind, ok := movie.ParseSourceKind(value)
+		if !ok || kind == movie.FromList {
+			return movie.Source{}, fmt.Errorf("unknown source %q", value)
+		}
+		return movie.Source{Kind: kind}, nil
+	}
+
+	listID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return movie.Source{}, fmt.Errorf("invalid list id %q", id)
+	}
+	list, _, err := h.movieService.List(r.Context(), userID, listID)
+	if err != nil {
+		h.logger.Warn("Failed to get movie list", "userID", userID, "id", listID, "error", err)
+		return movie.Source{}, errors.New("list not found")
+	}
+	return movie.Source{Kind: movie.FromList, ListID: list.ID, ListName: list.Name}, nil
+}
+
+// constraintsFromForm reads which movies the room drafts from, every field is optional
+func constraintsFromForm(r *http.Request) (movie.Constraints, error) {
+	c := movie.Constraints{
+		Genres:    r.Form["genres"],
+		MaxRating: r.FormValue("maxRating"),
+	}
+	var err error
+	if c.MinYear, err = optionalAtoiField(r, "minYear"); err != nil {
+		return c, err
+	}
+	if c.MaxYear, err = optionalAtoiField(r, "maxYear"); err != nil {
+		return c, err
+	}
+	if c.MaxRuntime, err = optionalAtoiField(r, "maxRuntime"); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// optionalAtoiField is atoiField for fields that can be left empty, which reads as 0
+func optionalAtoiField(r *http.Request, key string) (int, error) {
+	if r.FormValue(key) == "" {
+		return 0, nil
+	}
+	return atoiField(r, key)
+}
+
 func isValidRoomName(name string) bool {
 	if name == "" {
 		return false
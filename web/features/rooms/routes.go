@@ -2,7 +2,9 @@ package rooms
 
 import (
 	"log/slog"
+	"net/http"
 
+	"watchma/pkg/movie"
 	"watchma/pkg/room"
 
 	"github.com/go-chi/chi/v5"
@@ -12,15 +14,34 @@ import (
 func SetupRoutes(
 	r chi.Router,
 	roomService *room.Service,
+	movieService *movie.Service,
+	hostLimit func(http.Handler) http.Handler,
 	logger *slog.Logger,
 	nats *nats.Conn,
 ) error {
-	handlers := newHandlers(roomService, logger, nats)
+	handlers := newHandlers(roomService, movieService, logger, nats)
 
 	r.Get("/host", handlers.host)
-	r.Post("/host", handlers.hostForm)
+	r.With(hostLimit).Post("/host", handlers.hostForm)
+	r.Post("/host/presets/{id}/delete", handlers.deletePreset)
 	r.Get("/join", handlers.join)
 	r.Get("/sse/join", handlers.joinSSE)
+	r.Get("/room/{roomName}/qr.svg", handlers.qrCode)
+
+	return nil
+}
+
+// SetupShareRoutes registers the public share links of rooms, /j/{code} leads to the
+// room's lobby
+func SetupShareRoutes(
+	r chi.Router,
+	roomService *room.Service,
+	logger *slog.Logger,
+	nats *nats.Conn,
+) error {
+	handlers := newHandlers(roomService, nil, logger, nats)
+
+	r.Get("/j/{code}", handlers.joinByCode)
 
 	return nil
 }
@@ -0,0 +1,55 @@
+package rooms
+
+import (
+	"fmt"
+	"net/http"
+
+	appctx "watchma/pkg/context"
+	"watchma/pkg/qrcode"
+	"watchma/web"
+	"watchma/web/views/http_error"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ShareURL is the link that joins a room by its code, the one the lobby's QR code holds
+func ShareURL(r *http.Request, joinCode string) string {
+	return fmt.Sprintf("%s/j/%s", web.RequestOrigin(r), joinCode)
+}
+
+// joinByCode sends a share link on to the room's lobby. Signed out visitors are taken
+// to sign in or join as a guest from there, and come back to the room afterwards.
+func (h *handlers) joinByCode(w http.ResponseWriter, r *http.Request) {
+	myRoom, ok := h.roomService.RoomByCode(chi.URLParam(r, "code"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		web.RenderPageNoLayout(http_error.NotFound(), "404-gang", w, r)
+		return
+	}
+	// The code comes along, it lets people into unlisted and password protected rooms
+	http.Redirect(w, r, fmt.Sprintf("/room/%s/lobby?code=%s", myRoom.Name, myRoom.JoinCode), http.StatusSeeOther)
+}
+
+// qrCode draws the room's share link as an SVG, shown in the lobby so people on the
+// couch can scan it off the TV. The link lets anyone in, so only people already inside
+// get it, everyone else is told the room doesn't exist.
+func (h *handlers) qrCode(w http.ResponseWriter, r *http.Request) {
+	user := appctx.GetUserFromRequest(r)
+	roomName := chi.URLParam(r, "roomName")
+	myRoom, ok := h.roomService.GetRoom(roomName)
+	if !ok || !myRoom.CanEnter(user.Username) {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+
+	code, err := qrcode.Encode(ShareURL(r, myRoom.JoinCode))
+	if err != nil {
+		h.logger.Error("Failed to draw room QR code", "error", err, "roomName", roomName)
+		http.Error(w, "Failed to draw QR code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	fmt.Fprint(w, code.SVG())
+}
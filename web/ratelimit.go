@@ -0,0 +1,47 @@
+package web
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	appctx "watchma/pkg/context"
+	"watchma/pkg/ratelimit"
+)
+
+// RateLimit rejects requests over the limiter's limit with a 429. Signed in users are
+// limited per user, everyone else per IP. A nil limiter lets everything through.
+func RateLimit(limiter *ratelimit.Limiter, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + ClientIP(r)
+			if user := appctx.GetUserFromRequest(r); user != nil {
+				key = "user:" + user.Username
+			}
+
+			ok, retryAfter := limiter.Allow(key)
+			if ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Warn("Rate limited", "key", key, "path", r.URL.Path, "retryAfter", retryAfter)
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+
+			message := fmt.Sprintf("Slow down! Try again in %ds", seconds)
+			// Datastar requests show the error on the page, form posts get a plain page
+			if r.Header.Get("Datastar-Request") == "true" {
+				SendSSEError(w, r, message, logger)
+				return
+			}
+			http.Error(w, message, http.StatusTooManyRequests)
+		})
+	}
+}
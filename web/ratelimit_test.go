@@ -0,0 +1,45 @@
+package web
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"watchma/pkg/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name     string
+		limiter  *ratelimit.Limiter
+		requests int
+		wantLast int
+	}{
+		{"nil limiter lets everything through", nil, 100, http.StatusOK},
+		{"within the limit", ratelimit.NewLimiter(3, time.Minute), 3, http.StatusOK},
+		{"over the limit", ratelimit.NewLimiter(3, time.Minute), 4, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RateLimit(tt.limiter, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			var rec *httptest.ResponseRecorder
+			for range tt.requests {
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/message", nil))
+			}
+
+			if rec.Code != tt.wantLast {
+				t.Errorf("last status = %d, want %d", rec.Code, tt.wantLast)
+			}
+			if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "20" {
+				t.Errorf("Retry-After = %q, want 20", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/starfederation/datastar-go/datastar"
 )
@@ -41,3 +42,14 @@ func ClearSSEError(w http.ResponseWriter, r *http.Request, logger *slog.Logger)
 		}
 	}
 }
+
+// RequestOrigin is the scheme and host the browser reached us on, for links that get
+// shared outside the app. Behind a TLS terminating proxy the scheme comes from
+// X-Forwarded-Proto.
+func RequestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
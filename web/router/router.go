@@ -1,21 +1,32 @@
 package router
 
 import (
-	"fmt"
-	"io"
+	"errors"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
 
 	"watchma/db/sqlcgen"
 	authPkg "watchma/pkg/auth"
+	"watchma/pkg/embedding"
+	"watchma/pkg/host"
+	"watchma/pkg/images"
+	"watchma/pkg/jellyfin"
+	"watchma/pkg/llm"
 	"watchma/pkg/movie"
-	"watchma/pkg/openai"
+	"watchma/pkg/ratelimit"
+	"watchma/pkg/recommend"
 	"watchma/pkg/room"
+	"watchma/pkg/usage"
 	"watchma/web"
+	"watchma/web/features/admin"
 	"watchma/web/features/auth"
 	"watchma/web/features/debug"
 	"watchma/web/features/game"
 	"watchma/web/features/index"
+	"watchma/web/features/lists"
 	"watchma/web/features/rooms"
 	"watchma/web/views/http_error"
 
@@ -26,35 +37,44 @@ import (
 type WebHandlerServices struct {
 	MovieService   *movie.Service
 	RoomService    *room.Service
+	HostService    *host.Service
+	Recommender    *recommend.Service
+	VibeIndex      *embedding.Index // nil when vibe search is disabled
 	AuthService    *authPkg.AuthService
-	OpenAiProvider *openai.Provider
+	LLMProvider    llm.Provider   // nil when AI features are disabled
+	UsageTracker   *usage.Tracker // nil when AI features are disabled
+	ImageCache     *images.Cache
+	JellyfinClient *jellyfin.Client // nil when running on dummy data
+	LoginLockout   *ratelimit.Lockout
+	ChatLimiter    *ratelimit.Limiter // nil when chat isn't rate limited
+	RoomLimiter    *ratelimit.Limiter // nil when hosting rooms isn't rate limited
+	TrustedProxies []netip.Prefix     // Proxies whose X-Forwarded-For is believed
 }
 
 // WebHandler holds dependencies needed by web handlers
 type WebHandler struct {
-	jfinBaseUrl string
-	jfinApiKey  string
-	services    *WebHandlerServices
-	queries     *sqlcgen.Queries
-	logger      *slog.Logger
-	NATS        *nats.Conn
+	services *WebHandlerServices
+	queries  *sqlcgen.Queries
+	logger   *slog.Logger
+	NATS     *nats.Conn
 }
 
 // NewWebHandler creates a new web handlers instance
-func NewWebHandler(jellyfinBaseUrl string, jellyfinApiKey string, logger *slog.Logger, nc *nats.Conn, queries *sqlcgen.Queries, services *WebHandlerServices) *WebHandler {
+func NewWebHandler(logger *slog.Logger, nc *nats.Conn, queries *sqlcgen.Queries, services *WebHandlerServices) *WebHandler {
 	return &WebHandler{
-		jfinBaseUrl: jellyfinBaseUrl,
-		jfinApiKey:  jellyfinApiKey,
-		logger:      logger,
-		NATS:        nc,
-		queries:     queries,
-		services:    services,
+		logger:   logger,
+		NATS:     nc,
+		queries:  queries,
+		services: services,
 	}
 }
 
 // Sets up all Web Routes through Chi Router.
 // Web Routes should write web elements to http.ResponseWriter (I.E. SSE, HTML, JSON)
 func (h *WebHandler) SetupRoutes(r chi.Router) {
+	// Before anything that logs or limits by IP
+	r.Use(web.RealIP(h.services.TrustedProxies))
+
 	// Disable buffering for reverse proxies like NGINX
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -63,20 +83,36 @@ func (h *WebHandler) SetupRoutes(r chi.Router) {
 		})
 	})
 
-	r.Get("/images/{itemId}", proxyJellyfinImage(h.jfinBaseUrl, h.jfinApiKey, h.logger))
+	// Every POST/PATCH/DELETE needs the CSRF token and a same-origin Origin or Referer
+	r.Use(web.CSRF(!h.services.AuthService.IsDev, h.logger))
+
+	r.Get("/images/{itemId}", serveImage(h.services.ImageCache, h.services.MovieService, h.logger))
 
-	auth.SetupRoutes(r, h.services.AuthService, h.logger)
+	auth.SetupRoutes(r, h.services.AuthService, h.services.LoginLockout, h.logger)
+	auth.SetupGuestRoutes(r, h.services.AuthService, h.services.RoomService, h.services.LoginLockout, web.RateLimit(h.services.RoomLimiter, h.logger), h.logger)
+	rooms.SetupShareRoutes(r, h.services.RoomService, h.logger, h.NATS)
 
 	// Protected web routes
 	r.Group(func(r chi.Router) {
 		r.Use(auth.RequireLogin(h.services.AuthService, h.logger))
+		r.Use(auth.RestrictGuests(h.logger))
 
 		index.SetupRoutes(r, h.services.MovieService, h.queries)
-		debug.SetupRoutes(r, h.services.RoomService, h.logger, h.NATS)
+		lists.SetupRoutes(r, h.services.MovieService, h.logger)
+		auth.SetupAccountRoutes(r, h.services.AuthService, h.services.LoginLockout, h.logger)
+		auth.SetupGuestAccountRoutes(r, h.services.AuthService, h.services.RoomService, h.services.LoginLockout, h.logger)
 		// Room Setup
-		rooms.SetupRoutes(r, h.services.RoomService, h.logger, h.NATS)
+		rooms.SetupRoutes(r, h.services.RoomService, h.services.MovieService, web.RateLimit(h.services.RoomLimiter, h.logger), h.logger, h.NATS)
 		// Main Game Loop (lobby, draft, voting, announce)
-		game.SetupRoutes(r, h.services.RoomService, h.services.MovieService, h.services.OpenAiProvider, h.logger, h.NATS)
+		game.SetupRoutes(r, h.services.RoomService, h.services.AuthService, h.services.MovieService, h.services.HostService, h.services.Recommender, h.services.VibeIndex, h.services.LLMProvider, h.services.LoginLockout, web.RateLimit(h.services.ChatLimiter, h.logger), h.logger, h.NATS)
+
+		// Admin only pages
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireAdmin(h.services.AuthService, h.logger))
+
+			admin.SetupRoutes(r, h.queries, h.services.AuthService, h.services.UsageTracker, h.logger)
+			debug.SetupRoutes(r, h.services.RoomService, h.services.JellyfinClient, h.logger, h.NATS)
+		})
 	})
 
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
@@ -85,54 +121,57 @@ func (h *WebHandler) SetupRoutes(r chi.Router) {
 	})
 }
 
-// proxyJellyfinImage is to allow aggressive caching of jellyfin movie posters.
-// Jellyfin by default is no-cache.
-func proxyJellyfinImage(jfinBaseUrl string, jfinApiKey string, logger *slog.Logger) http.HandlerFunc {
+// serveImage serves posters from the on-disk image cache. Posters are immutable for a
+// given tag, so browsers can cache them forever and revalidate with the ETag. Only the
+// tag stored for the movie is served, whatever tag was asked for, so made up tags can't
+// force fetches from the media server or push real posters out of the cache.
+func serveImage(cache *images.Cache, movieService *movie.Service, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		itemId := chi.URLParam(r, "itemId")
-		tag := r.URL.Query().Get("tag")
-		width := r.URL.Query().Get("width")
-		height := r.URL.Query().Get("height")
+		width, _ := strconv.Atoi(r.URL.Query().Get("width"))
 
-		if jfinApiKey == "" {
-			http.Error(w, "Failed to fetch image", http.StatusInternalServerError)
-			logger.Warn("Cannot fetch Image without jellyfinApiKey, Please set JELLYFIN_API_KEY in your environment")
+		tag, err := movieService.ImageTag(r.Context(), itemId)
+		if errors.Is(err, movie.ErrMovieNotFound) {
+			http.Error(w, "Image not found", http.StatusNotFound)
 			return
 		}
-
-		jellyfinURL := fmt.Sprintf("%s/Items/%s/Images/Primary?tag=%s&width=%s&height=%s",
-			jfinBaseUrl, itemId, tag, width, height)
-
-		req, err := http.NewRequest("GET", jellyfinURL, nil)
 		if err != nil {
 			http.Error(w, "Failed to fetch image", http.StatusInternalServerError)
-			logger.Error("Failed to create jellyfin image request", "error", err, "url", jellyfinURL)
+			logger.Error("Failed to get image tag", "error", err, "itemId", itemId)
 			return
 		}
 
-		req.Header.Set("X-Emby-Token", jfinApiKey)
+		etag := images.ETag(itemId, tag, width)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", etag)
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			http.Error(w, "Failed to fetch image", http.StatusInternalServerError)
-			logger.Error("Failed to fetch jellyfin image", "error", err, "url", jellyfinURL)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			http.Error(w, fmt.Sprintf("Jellyfin error: %d", resp.StatusCode), http.StatusInternalServerError)
-			logger.Warn("Jellyfin returned non-200 status", "status", resp.StatusCode, "url", jellyfinURL)
+		img, err := cache.Get(r.Context(), itemId, tag, width)
+		if errors.Is(err, images.ErrInvalidKey) {
+			http.Error(w, "Invalid image", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			w.Header().Del("Cache-Control")
+			w.Header().Del("ETag")
+			http.Error(w, "Failed to fetch image", http.StatusInternalServerError)
+			logger.Error("Failed to get cached image", "error", err, "itemId", itemId, "tag", tag)
 			return
 		}
 
-		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
-
-		if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
-			w.Header().Set("Last-Modified", lastModified)
+		f, err := os.Open(img.Path)
+		if err != nil {
+			http.Error(w, "Failed to fetch image", http.StatusInternalServerError)
+			logger.Error("Failed to open cached image", "error", err, "path", img.Path)
+			return
 		}
+		defer f.Close()
 
-		io.Copy(w, resp.Body)
+		w.Header().Set("Content-Type", "image/jpeg")
+		http.ServeContent(w, r, "", img.ModTime, f)
 	}
 }